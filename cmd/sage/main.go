@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -9,7 +10,9 @@ import (
 
 func main() {
 	if err := cli.Execute(); err != nil {
-		fmt.Println(err)
+		if !errors.Is(err, cli.ErrReported) {
+			fmt.Println(err)
+		}
 		os.Exit(1)
 	}
 }
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/divijg19/sage/internal/event"
+	"github.com/divijg19/sage/internal/state"
 )
 
 type chronicleRowKind string
@@ -22,6 +23,10 @@ type chronicleFilters struct {
 	EnabledKinds    map[event.EntryKind]bool
 	EnabledTags     map[string]bool
 	InitialAllScope bool
+
+	// Matches holds the seqs the search index returned for Query. When nil,
+	// Query is matched in memory instead.
+	Matches map[int64]bool
 }
 
 type chronicleRow struct {
@@ -37,7 +42,8 @@ type chronicleRow struct {
 
 func filterChronicleEvents(events []event.Event, filters chronicleFilters) []event.Event {
 	out := make([]event.Event, 0, len(events))
-	query := strings.ToLower(strings.TrimSpace(filters.Query))
+	query := strings.TrimSpace(filters.Query)
+	match := chronicleQueryMatcher(query)
 
 	for _, e := range events {
 		if strings.TrimSpace(filters.Project) != "" && e.Project != filters.Project {
@@ -61,8 +67,14 @@ func filterChronicleEvents(events []event.Event, filters chronicleFilters) []eve
 			}
 		}
 
-		if query != "" && !chronicleMatchesQuery(e, query) {
-			continue
+		if query != "" {
+			if filters.Matches != nil {
+				if !filters.Matches[e.Seq] {
+					continue
+				}
+			} else if !match(e) {
+				continue
+			}
 		}
 
 		out = append(out, e)
@@ -71,6 +83,19 @@ func filterChronicleEvents(events []event.Event, filters chronicleFilters) []eve
 	return out
 }
 
+// chronicleQueryMatcher evaluates the search bar in memory with the shared
+// query syntax. Input that does not parse yet (say, a half-typed "kind:")
+// falls back to a plain substring match.
+func chronicleQueryMatcher(raw string) func(event.Event) bool {
+	if q, err := parseQuery(raw); err == nil {
+		return q.Match
+	}
+	lowered := strings.ToLower(raw)
+	return func(e event.Event) bool {
+		return chronicleMatchesQuery(e, lowered)
+	}
+}
+
 func chronicleMatchesQuery(e event.Event, query string) bool {
 	haystack := strings.ToLower(strings.Join([]string{
 		e.Title,
@@ -223,6 +248,66 @@ func chronicleBodyExcerpt(body string, maxLines int) string {
 	return strings.Join(lines, "\n")
 }
 
+// chronicleCommitFiles returns a commit's diff stat, e.g. "3 files · +12 -4",
+// and the files it changed. Commits recorded before Sage kept the file list
+// have neither.
+func chronicleCommitFiles(e *event.Event) (string, []string) {
+	if e == nil || e.Kind != event.CommitKind {
+		return "", nil
+	}
+	var files []string
+	for _, f := range strings.Split(e.Metadata[event.MetaFiles], "\n") {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	if len(files) == 0 {
+		return "", nil
+	}
+	noun := "files"
+	if len(files) == 1 {
+		noun = "file"
+	}
+	return fmt.Sprintf("%d %s · +%s -%s", len(files), noun, chronicleCount(e.Metadata["insertions"]), chronicleCount(e.Metadata["deletions"])), files
+}
+
+func chronicleCount(s string) string {
+	if s == "" {
+		return "0"
+	}
+	return s
+}
+
+// chronicleDecisionStanding describes where a decision stands in the
+// projection, e.g. "Superseded by [12]".
+func chronicleDecisionStanding(d state.Decision) string {
+	if d.SupersededBy != nil {
+		return fmt.Sprintf("Superseded by [%d]", d.SupersededBy.Seq)
+	}
+	return titleCase(string(d.Status))
+}
+
+// chronicleStateSummary is the one-line answer to the `state` command.
+func chronicleStateSummary(st state.State) string {
+	parts := make([]string, 0, len(state.Statuses())+1)
+	for _, status := range state.Statuses() {
+		parts = append(parts, fmt.Sprintf("%d %s", len(st.WithStatus(status)), status))
+	}
+	commits, branches := 0, 0
+	for _, g := range st.Commits {
+		commits += g.Count
+		branches++
+	}
+	summary := "Decisions: " + strings.Join(parts, " · ")
+	switch {
+	case branches == 1:
+		summary += fmt.Sprintf(" · %s on 1 branch", pluralCommits(commits))
+	case branches > 1:
+		summary += fmt.Sprintf(" · %s on %d branches", pluralCommits(commits), branches)
+	}
+	return summary
+}
+
 func chronicleDaySummary(day string, count int) string {
 	parsed, err := time.Parse("2006-01-02", day)
 	if err != nil {
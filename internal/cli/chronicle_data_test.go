@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/divijg19/sage/internal/event"
+	"github.com/divijg19/sage/internal/state"
 )
 
 func TestFilterChronicleEvents_QueryAndFilters(t *testing.T) {
@@ -176,6 +177,27 @@ func TestChronicleBodyExcerpt_EmptyAndLimited(t *testing.T) {
 	}
 }
 
+func TestChronicleCommitFiles(t *testing.T) {
+	commit := &event.Event{Kind: event.CommitKind, Metadata: map[string]string{
+		event.MetaFiles: "go.mod\ninternal/store/store.go",
+		"insertions":    "12",
+		"deletions":     "4",
+	}}
+	summary, files := chronicleCommitFiles(commit)
+	if summary != "2 files · +12 -4" || !reflect.DeepEqual(files, []string{"go.mod", "internal/store/store.go"}) {
+		t.Fatalf("unexpected commit files %q %v", summary, files)
+	}
+
+	for _, e := range []*event.Event{
+		{Kind: event.CommitKind},
+		{Kind: event.RecordKind, Metadata: map[string]string{event.MetaFiles: "go.mod"}},
+	} {
+		if summary, files := chronicleCommitFiles(e); summary != "" || files != nil {
+			t.Fatalf("expected no files for %+v, got %q %v", e, summary, files)
+		}
+	}
+}
+
 func TestChronicleDaySummary_ValidAndFallback(t *testing.T) {
 	if got := chronicleDaySummary("bad-date", 2); got != "2 entries" {
 		t.Fatalf("unexpected fallback day summary: %q", got)
@@ -186,3 +208,24 @@ func TestChronicleDaySummary_ValidAndFallback(t *testing.T) {
 		t.Fatalf("unexpected parsed day summary: %q", got)
 	}
 }
+
+func TestChronicleStateSummaryAndStanding(t *testing.T) {
+	jwt := event.Event{Seq: 4, ID: "jwt", Kind: event.DecisionKind}
+	st := state.State{
+		Decisions: []state.Decision{
+			{Entry: event.Event{Seq: 1, ID: "sessions"}, Status: state.Superseded, SupersededBy: &jwt},
+			{Entry: jwt, Status: state.Active},
+		},
+		Commits: []state.CommitGroup{{Project: "api", Branch: "main", Count: 3}},
+	}
+
+	if got := chronicleStateSummary(st); got != "Decisions: 1 active · 1 superseded · 0 reverted · 3 commits on 1 branch" {
+		t.Fatalf("unexpected summary %q", got)
+	}
+	if got := chronicleDecisionStanding(st.Decisions[0]); got != "Superseded by [4]" {
+		t.Fatalf("unexpected standing %q", got)
+	}
+	if got := chronicleDecisionStanding(st.Decisions[1]); got != "Active" {
+		t.Fatalf("unexpected standing %q", got)
+	}
+}
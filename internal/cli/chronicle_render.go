@@ -10,6 +10,7 @@ import (
 	"github.com/charmbracelet/x/ansi"
 
 	"github.com/divijg19/sage/internal/event"
+	"github.com/divijg19/sage/internal/state"
 )
 
 func (m chronicleModel) View() string {
@@ -304,6 +305,9 @@ func (m chronicleModel) renderTimelineRow(row chronicleRow, selected bool, width
 		if selected {
 			marker = "›"
 		}
+		if row.Event.Retracted {
+			title = retractedMarker + " " + title
+		}
 		head := fmt.Sprintf("%s %s  %s", marker, row.Event.Timestamp.Format("15:04"), title)
 		sub := "  " + strings.Join(metaParts, " · ")
 
@@ -333,6 +337,9 @@ func (m chronicleModel) renderTimelineRow(row chronicleRow, selected bool, width
 	}
 }
 
+// chronicleInspectorFiles is how many of a commit's files the inspector lists.
+const chronicleInspectorFiles = 6
+
 func (m chronicleModel) renderPreview(theme chronicleTheme, width int, height int) string {
 	contentWidth := max(22, width-4)
 	lines := []string{truncateLine(theme.sectionTitle().Render("Inspector"), contentWidth), ""}
@@ -374,6 +381,9 @@ func (m chronicleModel) renderPreview(theme chronicleTheme, width int, height in
 		theme.chip(e.Timestamp.Format("2006-01-02 15:04"), true, false),
 		theme.chip("Project: "+project, true, false),
 	}
+	if e.Retracted {
+		metaTokens = append(metaTokens, theme.chip("Retracted", true, true))
+	}
 
 	tagTokens := []string{theme.chip("Tags: (none)", false, false)}
 	if len(e.Tags) > 0 {
@@ -385,11 +395,40 @@ func (m chronicleModel) renderPreview(theme chronicleTheme, width int, height in
 		}
 	}
 
-	bodyLines := max(4, height-16)
+	var standing []string
+	if d, ok := m.projection.Decision(e.ID); ok {
+		standing = append(standing,
+			theme.sectionTitle().Render("State"),
+			wrapStyledTokens([]string{theme.chip(chronicleDecisionStanding(d), true, d.Status == state.Active)}, contentWidth),
+		)
+		if d.Context != nil {
+			standing = append(standing, truncateLine(theme.muted().Render(fmt.Sprintf("Context: [%d] %s", d.Context.Seq, chroniclePreviewTitle(d.Context))), contentWidth))
+		}
+		standing = append(standing, "")
+	}
+
+	if summary, files := chronicleCommitFiles(e); len(files) > 0 {
+		standing = append(standing,
+			theme.sectionTitle().Render("Files"),
+			truncateLine(theme.muted().Render(summary), contentWidth),
+		)
+		for _, f := range files[:min(len(files), chronicleInspectorFiles)] {
+			standing = append(standing, truncateLine(theme.body().Render(f), contentWidth))
+		}
+		if more := len(files) - chronicleInspectorFiles; more > 0 {
+			standing = append(standing, theme.muted().Render(fmt.Sprintf("... and %d more", more)))
+		}
+		standing = append(standing, "")
+	}
+
+	bodyLines := max(4, height-16-len(standing))
 	lines = append(lines,
 		truncateLine(theme.title().Render(chroniclePreviewTitle(e)), contentWidth),
 		wrapStyledTokens(metaTokens, contentWidth),
 		"",
+	)
+	lines = append(lines, standing...)
+	lines = append(lines,
 		theme.sectionTitle().Render("Tags"),
 		wrapStyledTokens(tagTokens, contentWidth),
 		"",
@@ -404,7 +443,14 @@ func (m chronicleModel) renderFilterOverlay(theme chronicleTheme) string {
 	items := m.filterItems()
 	width := min(62, max(44, m.width-10))
 	height := min(max(14, len(items)+8), max(14, m.height-8))
-	bodyHeight := max(4, height-8)
+	// Group headings take a line each, so they come out of the item window.
+	groups := 0
+	for i, item := range items {
+		if i == 0 || item.Group != items[i-1].Group {
+			groups++
+		}
+	}
+	bodyHeight := max(4, height-8-groups)
 
 	start := 0
 	if m.filterIndex >= bodyHeight {
@@ -567,8 +613,22 @@ func wrapFooterTokens(tokens []string, width int) string {
 	return strings.Join(lines, "\n")
 }
 
+// allKinds is a kind filter with every kind enabled.
+func allKinds() map[event.EntryKind]bool {
+	filter := make(map[event.EntryKind]bool)
+	for _, kind := range event.EntryKinds() {
+		filter[kind] = true
+	}
+	return filter
+}
+
 func allKindsEnabled(kindFilter map[event.EntryKind]bool) bool {
-	return kindFilter[event.RecordKind] && kindFilter[event.DecisionKind] && kindFilter[event.CommitKind]
+	for _, kind := range event.EntryKinds() {
+		if !kindFilter[kind] {
+			return false
+		}
+	}
+	return true
 }
 
 func wrapStyledTokens(tokens []string, width int) string {
@@ -17,6 +17,10 @@ var (
 	addDecision       bool
 	addChooseTemplate bool
 	addTags           []string
+	addBody           string
+	addBodyFile       string
+	addStdin          bool
+	addYes            bool
 )
 
 var addCmd = &cobra.Command{
@@ -27,15 +31,44 @@ var addCmd = &cobra.Command{
 		"  1) Provide a title (arg or prompt)\n" +
 		"  2) Your editor opens with a template (including title/kind front matter)\n" +
 		"  3) Save & close to append; exit without saving to cancel\n\n" +
-		"Sage will not save entries that are unchanged boilerplate or semantically empty.",
+		"Sage will not save entries that are unchanged boilerplate or semantically empty.\n\n" +
+		"Non-interactive use (scripts, CI, pipes):\n" +
+		"  --body, --body-file or --stdin (or a trailing -) supply the content and skip\n" +
+		"  the editor. The content may start with the same front matter the editor shows\n" +
+		"  (title, kind, supersedes, ...). --yes saves without asking; the kind then\n" +
+		"  defaults to the template's suggestion, or record. Without a terminal on stdin,\n" +
+		"  sage add fails instead of waiting for an answer, and entries that are empty or\n" +
+		"  repeat the previous one are reported as errors.",
 	Example: "  sage add \"Investigate flaky CI on linux\"\n" +
 		"  sage add d \"Use SQLite WAL mode\"\n" +
 		"  sage add --template 1 \"Template by numeric id\"\n" +
 		"  sage add --template decision \"Template by name\"\n" +
 		"  sage add \"Fix OAuth callback\" --tags auth,backend --tags cleanup\n" +
-		"  EDITOR=\"code --wait\" sage add \"Use VS Code as editor\"",
+		"  EDITOR=\"code --wait\" sage add \"Use VS Code as editor\"\n" +
+		"  sage add d \"Use SQLite WAL mode\" --body \"Readers never block writers.\" --yes\n" +
+		"  git log -1 --format=%B | sage add \"Release notes\" - --yes",
 	RunE: func(cmd *cobra.Command, args []string) error {
 
+		// ---- 0. Non-interactive content ----
+
+		args, fromStdin := trimStdinArg(args)
+		fromStdin = fromStdin || addStdin
+
+		// Prompts read stdin, so they are only possible from a terminal that
+		// is not also the source of the content. Fail before reading anything.
+		interactive := stdinInteractive() && !fromStdin && addBodyFile != "-"
+		if !interactive && !addYes {
+			return fmt.Errorf("sage add cannot prompt: stdin is not a terminal or carries the content; pass --yes to save without confirming")
+		}
+		if !interactive && addChooseTemplate {
+			return fmt.Errorf("--choose-template needs a terminal; use --template <name|id>")
+		}
+
+		body, supplied, err := readAddBody(cmd.Flags().Changed("body"), fromStdin)
+		if err != nil {
+			return err
+		}
+
 		// ---- 1. Explicit kind + title (shorthand) ----
 
 		explicitKind := ""
@@ -60,6 +93,12 @@ var addCmd = &cobra.Command{
 
 		// ---- 2. Resolve title ----
 
+		if titleArg == "" && addTitle == "" && supplied {
+			titleArg, _, _ = entryflow.ExtractMetaAndBodyFromEditor(body)
+		}
+		if titleArg == "" && addTitle == "" && !interactive {
+			return fmt.Errorf("title is required: pass it as an argument, with --title, or as title: in the content's front matter")
+		}
 		title, err := resolveTitle(titleArg, addTitle)
 		if err != nil {
 			return err
@@ -110,11 +149,14 @@ var addCmd = &cobra.Command{
 		}
 		prepared := entryflow.PrepareInitialBuffer(title, explicitKind, suggested, templateBody)
 
-		// ---- 5. Editor (unless skipped) ----
+		// ---- 5. Editor (unless content was supplied) ----
 
-		edited, err := openEditor(prepared.Body)
-		if err != nil {
-			return err
+		edited := body
+		if !supplied {
+			edited, err = openEditor(prepared.Body)
+			if err != nil {
+				return err
+			}
 		}
 
 		tags := parseTags(addTags)
@@ -137,17 +179,16 @@ var addCmd = &cobra.Command{
 			Edited:        edited,
 			Project:       project,
 			Tags:          tags,
-		}, entryflow.Dependencies{
-			Store:       s,
-			EnsureTags:  ensureTagsConfigured,
-			ResolveKind: resolveKind,
-			ConfirmSave: func() bool { return confirm("Save entry? [y/N]: ") },
-		})
+		}, addDependencies(s))
 		if err != nil {
 			return err
 		}
 		if result.Status == entryflow.StatusSaved {
 			fmt.Println("entry recorded")
+			return nil
+		}
+		if supplied || !interactive {
+			return fmt.Errorf("entry not saved: %s", addStatusReason(result.Status))
 		}
 		return nil
 	},
@@ -159,6 +200,11 @@ func init() {
 	addCmd.Flags().BoolVar(&addDecision, "decision", false, "mark as decision")
 	addCmd.Flags().BoolVar(&addChooseTemplate, "choose-template", false, "choose a template interactively")
 	addCmd.Flags().StringArrayVar(&addTags, "tags", nil, "categorize entry (repeatable or comma-separated, e.g. --tags auth,backend)")
+	addCmd.Flags().StringVar(&addBody, "body", "", "entry content (skips the editor)")
+	addCmd.Flags().StringVar(&addBodyFile, "body-file", "", "read entry content from a file, or - for stdin (skips the editor)")
+	addCmd.Flags().BoolVar(&addStdin, "stdin", false, "read entry content from stdin (skips the editor; same as a trailing -)")
+	addCmd.Flags().BoolVarP(&addYes, "yes", "y", false, "save without asking (kind defaults to the template's suggestion, or record)")
+	addCmd.MarkFlagsMutuallyExclusive("body", "body-file", "stdin")
 
 	rootCmd.AddCommand(addCmd)
 }
@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/divijg19/sage/internal/event"
+	"github.com/divijg19/sage/internal/store"
+)
+
+// setupNonInteractiveAdd isolates HOME, detaches stdin from the terminal and
+// resets the add flags, restoring everything afterwards.
+func setupNonInteractiveAdd(t *testing.T, stdin string) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("SAGE_PROJECT", "")
+
+	oldInteractive, oldReader := stdinInteractive, stdinReader
+	stdinInteractive = func() bool { return false }
+	stdinReader = bufio.NewReader(strings.NewReader(stdin))
+
+	reset := func() {
+		addTitle, addTemplate, addDecision, addChooseTemplate, addTags = "", "", false, false, nil
+		addBody, addBodyFile, addStdin, addYes = "", "", false, false
+		addCmd.Flags().Lookup("body").Changed = false
+	}
+	reset()
+	t.Cleanup(func() {
+		stdinInteractive, stdinReader = oldInteractive, oldReader
+		reset()
+	})
+}
+
+func setAddBody(t *testing.T, body string) {
+	t.Helper()
+	if err := addCmd.Flags().Set("body", body); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func addedEntries(t *testing.T) []event.Event {
+	t.Helper()
+	s, err := openGlobalStore()
+	if err != nil {
+		t.Fatalf("openGlobalStore: %v", err)
+	}
+	events, err := store.Collect(s.Query(context.Background(), store.Filter{}))
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	return events
+}
+
+func TestAdd_NonInteractiveRequiresYes(t *testing.T) {
+	setupNonInteractiveAdd(t, "")
+	setAddBody(t, "Readers never block writers.")
+
+	err := addCmd.RunE(addCmd, []string{"Use WAL"})
+	if err == nil || !strings.Contains(err.Error(), "--yes") {
+		t.Fatalf("expected an error pointing at --yes, got %v", err)
+	}
+	if got := addedEntries(t); len(got) != 0 {
+		t.Fatalf("nothing should be saved, got %d entries", len(got))
+	}
+}
+
+func TestAdd_BodyFlagSavesWithoutEditor(t *testing.T) {
+	setupNonInteractiveAdd(t, "")
+	setAddBody(t, "Readers never block writers.")
+	addYes = true
+	addTags = []string{"storage"}
+
+	if err := addCmd.RunE(addCmd, []string{"d", "Use WAL"}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	got := addedEntries(t)
+	if len(got) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(got))
+	}
+	e := got[0]
+	if e.Kind != event.DecisionKind || e.Title != "Use WAL" || e.Content != "Readers never block writers." {
+		t.Fatalf("unexpected entry: %+v", e)
+	}
+	if len(e.Tags) != 1 || e.Tags[0] != "storage" {
+		t.Fatalf("unexpected tags: %v", e.Tags)
+	}
+}
+
+func TestAdd_StdinWithFrontMatter(t *testing.T) {
+	setupNonInteractiveAdd(t, "---\ntitle: Cache drift\nkind: decision\n---\n\nInvalidate on deploy.\n")
+	addYes = true
+
+	if err := addCmd.RunE(addCmd, []string{"-"}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	got := addedEntries(t)
+	if len(got) != 1 || got[0].Title != "Cache drift" || got[0].Kind != event.DecisionKind || got[0].Content != "Invalidate on deploy." {
+		t.Fatalf("unexpected entries: %+v", got)
+	}
+}
+
+func TestAdd_BodyFileDefaultsToRecord(t *testing.T) {
+	setupNonInteractiveAdd(t, "")
+	path := filepath.Join(t.TempDir(), "notes.md")
+	if err := os.WriteFile(path, []byte("Flaky on linux only.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	addBodyFile = path
+	addYes = true
+
+	if err := addCmd.RunE(addCmd, []string{"Investigate", "CI"}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	got := addedEntries(t)
+	if len(got) != 1 || got[0].Kind != event.RecordKind || got[0].Title != "Investigate CI" {
+		t.Fatalf("unexpected entries: %+v", got)
+	}
+}
+
+func TestAdd_NonInteractiveReportsUnsavedEntries(t *testing.T) {
+	setupNonInteractiveAdd(t, "")
+	addYes = true
+
+	setAddBody(t, "## Context\n\n---")
+	err := addCmd.RunE(addCmd, []string{"Nothing here"})
+	if err == nil || !strings.Contains(err.Error(), "no meaningful text") {
+		t.Fatalf("expected an empty-content error, got %v", err)
+	}
+
+	setAddBody(t, "Same thing twice.")
+	if err := addCmd.RunE(addCmd, []string{"Repeat"}); err != nil {
+		t.Fatalf("first add: %v", err)
+	}
+	err = addCmd.RunE(addCmd, []string{"Repeat"})
+	if err == nil || !strings.Contains(err.Error(), "repeats the previous entry") {
+		t.Fatalf("expected a duplicate error, got %v", err)
+	}
+
+	addTitle = ""
+	err = addCmd.RunE(addCmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "title is required") {
+		t.Fatalf("expected a missing-title error, got %v", err)
+	}
+
+	if got := addedEntries(t); len(got) != 1 {
+		t.Fatalf("expected only the first entry to be saved, got %d", len(got))
+	}
+}
+
+func TestAdd_RejectsSeveralBodySources(t *testing.T) {
+	setupNonInteractiveAdd(t, "from stdin")
+	setAddBody(t, "from flag")
+	addYes = true
+
+	err := addCmd.RunE(addCmd, []string{"Title", "-"})
+	if err == nil || !strings.Contains(err.Error(), "only one of") {
+		t.Fatalf("expected a conflicting-sources error, got %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/divijg19/sage/internal/entryflow"
+	"github.com/divijg19/sage/internal/event"
+)
+
+var amendCmd = &cobra.Command{
+	Use:   "amend <id>",
+	Short: "Amend a past entry by numeric ID",
+	Long: "Open an existing entry in your editor and record your changes as a revision.\n\n" +
+		"The original entry is never rewritten: a revision event linked to it is appended,\n" +
+		"`sage view` shows the latest revision, and `sage state --at` shows whichever\n" +
+		"revision was current at that time.",
+	Example: "  sage amend 42\n" +
+		"  sage view 42 --revisions",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.ParseInt(strings.TrimSpace(args[0]), 10, 64)
+		if err != nil || id <= 0 {
+			return fmt.Errorf("invalid entry id: %s", args[0])
+		}
+
+		s, err := openGlobalStore()
+		if err != nil {
+			return err
+		}
+
+		target, err := s.GetBySeq(id)
+		if err != nil {
+			return err
+		}
+		if err := checkAmendable(target, id); err != nil {
+			return err
+		}
+
+		explicitKind := string(target.Kind)
+		if explicitKind == "" {
+			explicitKind = string(event.RecordKind)
+		}
+		prepared := entryflow.PrepareInitialBuffer(target.Title, explicitKind, "", target.Content)
+
+		edited, err := openEditor(prepared.Body)
+		if err != nil {
+			return err
+		}
+
+		result, err := entryflow.Finalize(entryflow.FinalizeRequest{
+			Title:        target.Title,
+			ExplicitKind: explicitKind,
+			SeedKind:     prepared.SeedKind,
+			InitialBody:  prepared.Body,
+			Edited:       edited,
+			Project:      target.Project,
+			Amends:       target,
+		}, entryflow.Dependencies{
+			Store:       s,
+			ResolveKind: resolveKind,
+			ConfirmSave: func() bool { return confirm("Save revision? [y/N]: ") },
+		})
+		if err != nil {
+			return err
+		}
+
+		switch result.Status {
+		case entryflow.StatusSaved:
+			fmt.Printf("entry %d amended\n", id)
+		case entryflow.StatusUnchanged:
+			fmt.Println("no changes recorded")
+		}
+		return nil
+	},
+}
+
+func checkAmendable(e *event.Event, id int64) error {
+	if e == nil {
+		return fmt.Errorf("no entry with id %d", id)
+	}
+	if e.Retracted {
+		return fmt.Errorf("entry %d is retracted", id)
+	}
+	switch e.Kind {
+	case "", event.RecordKind, event.DecisionKind:
+		return nil
+	case event.CommitKind:
+		return fmt.Errorf("entry %d mirrors a git commit and cannot be amended", id)
+	case event.GitKind:
+		return fmt.Errorf("entry %d mirrors git activity and cannot be amended", id)
+	default:
+		return fmt.Errorf("entry %d is a %s event and cannot be amended", id, e.Kind)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(amendCmd)
+}
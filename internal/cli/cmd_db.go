@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/divijg19/sage/internal/store"
+)
+
+var dbMigrateDryRun bool
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Inspect and maintain the Sage database",
+	Long: "Sage keeps every entry in ~/.sage/sage.db. Its schema is versioned: each change\n" +
+		"is a numbered migration recorded in the schema_version table.\n\n" +
+		"Any command that opens the database applies pending migrations first, each in\n" +
+		"its own transaction, after copying the database to sage.db.v<version>.bak.",
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Apply pending schema migrations",
+	Example: "  sage db migrate --dry-run\n" +
+		"  sage db migrate",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := globalDBPath()
+		if path == "" {
+			return fmt.Errorf("could not determine Sage directory")
+		}
+		if dbMigrateDryRun {
+			return runDBMigrateDryRun(path)
+		}
+
+		result, err := store.Migrate(path)
+		if err != nil {
+			return err
+		}
+		if len(result.Applied) == 0 {
+			fmt.Printf("%s is up to date (schema version %d)\n", path, result.From)
+			return nil
+		}
+		if result.Backup != "" {
+			fmt.Printf("Backed up to %s\n", result.Backup)
+		}
+		for _, m := range result.Applied {
+			fmt.Printf("Applied %d: %s\n", m.Version, m.Name)
+		}
+		fmt.Printf("%s is at schema version %d\n", path, result.Applied[len(result.Applied)-1].Version)
+		return nil
+	},
+}
+
+func runDBMigrateDryRun(path string) error {
+	version, pending, err := store.Pending(path)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		fmt.Printf("%s is up to date (schema version %d)\n", path, version)
+		return nil
+	}
+	fmt.Printf("%s is at schema version %d; %d pending:\n", path, version, len(pending))
+	for _, m := range pending {
+		fmt.Printf("  %d: %s\n", m.Version, m.Name)
+	}
+	return nil
+}
+
+func init() {
+	dbMigrateCmd.Flags().BoolVar(&dbMigrateDryRun, "dry-run", false, "list pending migrations without applying them")
+
+	dbCmd.AddCommand(dbMigrateCmd)
+	rootCmd.AddCommand(dbCmd)
+}
@@ -0,0 +1,350 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/divijg19/sage/internal/output"
+	"github.com/divijg19/sage/internal/store"
+)
+
+var doctorRepo string
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the Sage install for problems",
+	Long: "Check ~/.sage, the database, config.json, the editor and the Git hooks of the\n" +
+		"current repository (or --repo). Each problem comes with a suggested fix.\n\n" +
+		"doctor never migrates or repairs anything itself. It exits non-zero when it\n" +
+		"finds a problem, so it can run in scripts.",
+	Example: "  sage doctor\n" +
+		"  sage doctor --format json",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := outputFormat()
+		if err != nil {
+			return err
+		}
+
+		checks := runDoctor(sageDir(), doctorRepo)
+		if format.Structured() {
+			if err := writeOutput(format, output.NewDoctor(checks)); err != nil {
+				return err
+			}
+		} else {
+			printDoctor(os.Stdout, checks)
+		}
+		if doctorProblems(checks) > 0 {
+			return ErrReported
+		}
+		return nil
+	},
+}
+
+// runDoctor runs every check against the Sage directory dir and the Git
+// repository at repo ("" for the current directory).
+func runDoctor(dir, repo string) []output.DoctorCheck {
+	if dir == "" {
+		return []output.DoctorCheck{doctorCheck("Sage directory", "",
+			doctorProblem("could not determine your home directory", "set $HOME"))}
+	}
+	return []output.DoctorCheck{
+		checkSageDir(dir),
+		checkDatabase(filepath.Join(dir, "sage.db")),
+		checkSpool(filepath.Join(dir, "spool")),
+		checkConfig(filepath.Join(dir, "config.json")),
+		checkEditor(),
+		checkHooks(repo),
+	}
+}
+
+func doctorProblem(problem, fix string) output.DoctorProblem {
+	return output.DoctorProblem{Problem: problem, Fix: fix}
+}
+
+// doctorCheck reports a check, which passed unless it found problems.
+func doctorCheck(name, detail string, problems ...output.DoctorProblem) output.DoctorCheck {
+	return output.DoctorCheck{Name: name, OK: len(problems) == 0, Detail: detail, Problems: problems}
+}
+
+func doctorProblems(checks []output.DoctorCheck) int {
+	n := 0
+	for _, c := range checks {
+		n += len(c.Problems)
+	}
+	return n
+}
+
+func checkSageDir(dir string) output.DoctorCheck {
+	const name = "Sage directory"
+	info, err := os.Stat(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return doctorCheck(name, dir+" does not exist yet; Sage creates it on first use")
+	}
+	if err != nil {
+		return doctorCheck(name, dir, doctorProblem(err.Error(), "check the permissions of "+filepath.Dir(dir)))
+	}
+	if !info.IsDir() {
+		return doctorCheck(name, dir, doctorProblem(dir+" is not a directory", "move it aside: mv "+dir+" "+dir+".old"))
+	}
+
+	var problems []output.DoctorProblem
+	if info.Mode().Perm()&0o022 != 0 {
+		problems = append(problems, doctorProblem(
+			fmt.Sprintf("%s is writable by other users (%s)", dir, info.Mode().Perm()),
+			"chmod go-w "+dir))
+	}
+	if f, err := os.CreateTemp(dir, ".doctor-*"); err != nil {
+		problems = append(problems, doctorProblem(dir+" is not writable: "+err.Error(), "chmod u+rwx "+dir+" (and chown it if another user owns it)"))
+	} else {
+		f.Close()
+		_ = os.Remove(f.Name())
+	}
+	for _, file := range []string{"sage.db", "config.json"} {
+		path := filepath.Join(dir, file)
+		f, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			problems = append(problems, doctorProblem(path+" is not writable: "+err.Error(), "chmod u+rw "+path))
+			continue
+		}
+		f.Close()
+	}
+	return doctorCheck(name, fmt.Sprintf("%s (%s)", dir, info.Mode().Perm()), problems...)
+}
+
+func checkDatabase(path string) output.DoctorCheck {
+	const name = "Database"
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return doctorCheck(name, path+" does not exist yet; Sage creates it on the first entry")
+	}
+
+	backups, _ := filepath.Glob(path + ".v*.bak")
+	restore := "restore a backup (" + path + ".v<version>.bak) with Sage closed"
+	if len(backups) > 0 {
+		restore = "restore the backup " + backups[len(backups)-1] + " over " + path + " with Sage closed"
+	}
+
+	h, err := store.Inspect(path)
+	var tooNew *store.SchemaTooNewError
+	switch {
+	case errors.As(err, &tooNew):
+		return doctorCheck(name, path, doctorProblem(
+			fmt.Sprintf("schema version %d is newer than this sage supports (%d)", tooNew.Version, tooNew.Supported),
+			"upgrade sage"))
+	case err != nil:
+		return doctorCheck(name, path, doctorProblem("could not open the database: "+err.Error(), restore))
+	}
+
+	var problems []output.DoctorProblem
+	for _, msg := range h.Integrity {
+		problems = append(problems, doctorProblem("integrity check: "+msg,
+			restore+", or salvage it with: sqlite3 "+path+" .recover"))
+	}
+	if len(h.Pending) > 0 {
+		problems = append(problems, doctorProblem(
+			fmt.Sprintf("schema version %d is behind this sage (%d); %d migration(s) pending", h.Version, store.LatestVersion(), len(h.Pending)),
+			"sage db migrate (any command that opens the database also migrates it)"))
+	}
+	for _, r := range h.BadRows {
+		problems = append(problems, doctorProblem(
+			fmt.Sprintf("event %d (%s) does not decode: %v", r.Seq, r.ID, r.Err),
+			restore+", or drop the row: sqlite3 "+path+" \"DELETE FROM events WHERE seq = "+fmt.Sprint(r.Seq)+"\""))
+	}
+	for _, d := range h.DuplicateIDs {
+		seqs := make([]string, len(d.Seqs))
+		for i, s := range d.Seqs {
+			seqs[i] = fmt.Sprint(s)
+		}
+		problems = append(problems, doctorProblem(
+			fmt.Sprintf("event id %s is stored %d times (seqs %s)", d.ID, len(d.Seqs), strings.Join(seqs, ", ")),
+			"keep the first copy: sqlite3 "+path+" \"DELETE FROM events WHERE seq IN ("+strings.Join(seqs[1:], ", ")+")\""))
+	}
+	for _, g := range h.SeqGaps {
+		missing := fmt.Sprint(g.First)
+		if g.Last > g.First {
+			missing = fmt.Sprintf("%d-%d", g.First, g.Last)
+		}
+		problems = append(problems, doctorProblem(
+			"events "+missing+" are missing from the append-only log (deleted outside Sage)",
+			restore+" to recover them"))
+	}
+	return doctorCheck(name, fmt.Sprintf("%s: %d events, schema version %d", path, h.Events, h.Version), problems...)
+}
+
+func checkSpool(dir string) output.DoctorCheck {
+	const name = "Hook spool"
+	files, err := readSpool(dir)
+	if err != nil {
+		return doctorCheck(name, dir, doctorProblem(err.Error(), "check the permissions of "+dir))
+	}
+	if len(files) == 0 {
+		return doctorCheck(name, "empty")
+	}
+
+	var problems []output.DoctorProblem
+	waiting := 0
+	for _, f := range files {
+		if f.err != nil {
+			problems = append(problems, doctorProblem(
+				fmt.Sprintf("%s cannot be recorded: %v", f.path, f.err),
+				"inspect it and delete it: rm "+f.path))
+			continue
+		}
+		waiting++
+	}
+	if waiting > 0 {
+		problems = append(problems, doctorProblem(
+			fmt.Sprintf("%d hook event(s) are waiting in %s: the database could not be written when they happened", waiting, dir),
+			"run any sage command (e.g. sage timeline) to record them; if they stay, fix the Database problems above"))
+	}
+	return doctorCheck(name, fmt.Sprintf("%s: %d file(s)", dir, len(files)), problems...)
+}
+
+func checkConfig(path string) output.DoctorCheck {
+	const name = "Config"
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return doctorCheck(name, path+" does not exist; defaults apply")
+	}
+	if err != nil {
+		return doctorCheck(name, path, doctorProblem(err.Error(), "chmod u+rw "+path))
+	}
+
+	var cfg userConfig
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		var syntax *json.SyntaxError
+		var typ *json.UnmarshalTypeError
+		if errors.As(err, &syntax) || errors.As(err, &typ) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return doctorCheck(name, path, doctorProblem("not valid: "+err.Error(),
+				"fix it by hand, or move it aside (mv "+path+" "+path+".old) and run sage editor again"))
+		}
+		return doctorCheck(name, path, doctorProblem(err.Error(),
+			`remove the field; Sage only reads "editor" and "tags"`))
+	}
+	return doctorCheck(name, path)
+}
+
+func checkEditor() output.DoctorCheck {
+	const name = "Editor"
+	editor, err := resolveEditorCommand()
+	if err != nil {
+		return doctorCheck(name, "", doctorProblem("could not read the configured editor: "+err.Error(), "fix config.json (see Config)"))
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	_, source, _, _ := resolveSelectedEditorForDisplay()
+
+	bin := strings.Fields(editor)[0]
+	launch := effectiveEditorInvocation(editor)
+	path, err := exec.LookPath(bin)
+	if err != nil {
+		return doctorCheck(name, launch+" ("+source+")", doctorProblem(
+			fmt.Sprintf("%s is not on PATH", bin),
+			"install it, or choose another editor: sage editor <command> (see sage editor list)"))
+	}
+	return doctorCheck(name, fmt.Sprintf("%s (%s) -> %s", launch, source, path))
+}
+
+func checkHooks(repo string) output.DoctorCheck {
+	const name = "Git hooks"
+	root, err := gitRepoRoot(repo)
+	if err != nil {
+		return doctorCheck(name, "not in a Git repository; skipped")
+	}
+	hooksDir, _, err := gitHooksDir(repo)
+	if err != nil {
+		return doctorCheck(name, root, doctorProblem(err.Error(), "check the repository with git status"))
+	}
+
+	var notes []string
+	var problems []output.DoctorProblem
+	for _, hook := range supportedHooks {
+		ins, err := InspectHook(hooksDir, hook)
+		if err != nil {
+			problems = append(problems, doctorProblem(err.Error(), "check the permissions of "+hooksDir))
+			continue
+		}
+		switch {
+		case !ins.Exists:
+			notes = append(notes, hook+" not installed")
+			continue
+		case !ins.SageManaged:
+			notes = append(notes, hook+" is not managed by Sage")
+			continue
+		}
+		notes = append(notes, hook+" installed")
+
+		if info, err := os.Stat(ins.HookPath); err == nil && info.Mode().Perm()&0o111 == 0 {
+			problems = append(problems, doctorProblem(ins.HookPath+" is not executable, so Git skips it", "chmod +x "+ins.HookPath))
+		}
+		if _, err := exec.LookPath("sage"); err != nil {
+			problems = append(problems, doctorProblem("the "+hook+" hook runs sage, but sage is not on PATH",
+				"add the directory holding the sage binary to PATH"))
+		}
+		lock := filepath.Join(hooksDir, ".sage-"+hook+".lock")
+		if ins.Global {
+			// Global hooks lock inside the repository, not the shared directory.
+			if common, err := gitCommonDirAbs(root); err == nil {
+				lock = filepath.Join(common, ".sage-"+hook+".lock")
+			}
+		}
+		if _, err := os.Stat(lock); err == nil {
+			problems = append(problems, doctorProblem("a stale lock "+lock+" makes the "+hook+" hook skip every run",
+				"rmdir "+lock+" (unless a Git command is running right now)"))
+		}
+		if legacy := ins.LegacyHookPath; legacy != "" {
+			if info, err := os.Stat(legacy); err != nil || info.Mode().Perm()&0o111 == 0 {
+				problems = append(problems, doctorProblem("the chained hook "+legacy+" is missing or not executable, so it no longer runs",
+					"restore it (chmod +x "+legacy+"), or stop chaining it: sage hooks uninstall && sage hooks install"))
+			}
+		}
+	}
+	return doctorCheck(name, root+": "+strings.Join(notes, "; "), problems...)
+}
+
+func printDoctor(w io.Writer, checks []output.DoctorCheck) {
+	for _, c := range checks {
+		status := "ok"
+		if !c.OK {
+			status = "FAIL"
+		}
+		fmt.Fprintf(w, "%-5s %s", status, c.Name)
+		if c.Detail != "" {
+			fmt.Fprintf(w, ": %s", c.Detail)
+		}
+		fmt.Fprintln(w)
+		for _, p := range c.Problems {
+			fmt.Fprintf(w, "      - %s\n        fix: %s\n", p.Problem, p.Fix)
+		}
+	}
+
+	switch n := doctorProblems(checks); n {
+	case 0:
+		fmt.Fprintln(w, "\nNo problems found.")
+	case 1:
+		fmt.Fprintln(w, "\n1 problem found.")
+	default:
+		fmt.Fprintf(w, "\n%d problems found.\n", n)
+	}
+}
+
+func init() {
+	doctorCmd.Flags().StringVar(&doctorRepo, "repo", "", "repository whose hooks to check (defaults to current directory)")
+	rootCmd.AddCommand(doctorCmd)
+}
@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/divijg19/sage/internal/event"
+	"github.com/divijg19/sage/internal/output"
+)
+
+func resetDoctorFlags(t *testing.T) {
+	t.Helper()
+	reset := func() { doctorRepo, outputFormatFlag = "", "text" }
+	reset()
+	t.Cleanup(reset)
+}
+
+// doctorFindings flattens checks into "Check: problem" strings.
+func doctorFindings(checks []output.DoctorCheck) []string {
+	var out []string
+	for _, c := range checks {
+		for _, p := range c.Problems {
+			if p.Fix == "" {
+				out = append(out, c.Name+": "+p.Problem+" (no fix)")
+				continue
+			}
+			out = append(out, c.Name+": "+p.Problem)
+		}
+	}
+	return out
+}
+
+func TestDoctor_HealthyInstallPasses(t *testing.T) {
+	resetDoctorFlags(t)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("SAGE_PROJECT", "")
+	t.Setenv("SAGE_EDITOR", "")
+	t.Setenv("EDITOR", "sh")
+	doctorRepo = t.TempDir()
+
+	s, err := openGlobalStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Append(event.Event{ID: "a", Timestamp: time.Now(), Project: "p", Kind: event.RecordKind, Title: "t"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(sageDir(), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	doctorCmd.SetContext(context.Background())
+	got := captureStdout(t, func() error { return doctorCmd.RunE(doctorCmd, nil) })
+	for _, want := range []string{"ok    Database: ", "1 events, schema version", "ok    Editor: sh <file> ($EDITOR)", "not in a Git repository", "No problems found."} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestDoctor_ReportsProblemsWithFixes(t *testing.T) {
+	resetDoctorFlags(t)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("SAGE_PROJECT", "")
+	doctorRepo = t.TempDir()
+
+	s, err := openGlobalStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Append(event.Event{ID: "a", Timestamp: time.Now(), Project: "p", Kind: event.RecordKind, Title: "t"}); err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite", globalDBPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE events SET data = '{' WHERE id = 'a';`); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+	if err := os.Chmod(sageDir(), 0o777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath(), []byte(`{"editor": "no-such-editor-for-sage-doctor", "colour": "blue"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	checks := runDoctor(sageDir(), doctorRepo)
+	got := strings.Join(doctorFindings(checks), "\n")
+	for _, want := range []string{
+		"Sage directory: " + sageDir() + " is writable by other users",
+		"Database: event 1 (a) does not decode",
+		`Config: json: unknown field "colour"`,
+		"Editor: no-such-editor-for-sage-doctor is not on PATH",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("missing %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "(no fix)") {
+		t.Fatalf("every problem needs a fix:\n%s", got)
+	}
+
+	doctorCmd.SetContext(context.Background())
+	outputFormatFlag = "json"
+	var runErr error
+	_ = captureStdout(t, func() error { runErr = doctorCmd.RunE(doctorCmd, nil); return nil })
+	if !errors.Is(runErr, ErrReported) {
+		t.Fatalf("expected a non-zero exit, got %v", runErr)
+	}
+}
+
+func TestDoctor_ChecksInstalledHooks(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repo := t.TempDir()
+	if out, err := exec.Command("git", "-C", repo, "init", "-q").CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+	hooksDir := filepath.Join(repo, ".git", "hooks")
+
+	if got := checkHooks(repo); !got.OK || !strings.Contains(got.Detail, "post-commit not installed") {
+		t.Fatalf("a repo without hooks is fine: %+v", got)
+	}
+
+	legacy := filepath.Join(hooksDir, "post-commit")
+	if err := os.WriteFile(legacy, []byte("#!/bin/sh\nexit 0\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	res, err := InstallHook(hooksDir, "post-commit", HookInstallOptions{})
+	if err != nil {
+		t.Fatalf("InstallHook: %v", err)
+	}
+	if err := os.Remove(res.BackupPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(hooksDir, ".sage-post-commit.lock"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	got := strings.Join(doctorFindings([]output.DoctorCheck{checkHooks(repo)}), "\n")
+	for _, want := range []string{"stale lock", "chained hook " + res.BackupPath + " is missing"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("missing %q in:\n%s", want, got)
+		}
+	}
+}
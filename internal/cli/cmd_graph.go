@@ -0,0 +1,265 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/divijg19/sage/internal/event"
+	"github.com/divijg19/sage/internal/graph"
+	"github.com/divijg19/sage/internal/output"
+	"github.com/divijg19/sage/internal/store"
+)
+
+var graphAt string
+var graphHops int
+var graphTags []string
+var graphAll bool
+var graphProject string
+var graphExportFormat string
+var graphExportOutput string
+
+var graphCmd = &cobra.Command{
+	Use:   "graph [node]",
+	Short: "Explore the semantic graph derived from your entries",
+	Long: "Replay the event log into a graph of concepts (tags), decisions, records,\n" +
+		"artifacts (repos, files and commits) and typed relationships.\n\n" +
+		"The graph is a projection: it is rebuilt from events every time, so --at shows\n" +
+		"the graph as it was at that moment.\n\n" +
+		"Without a node, prints a summary. With a node, prints everything connected to it\n" +
+		"within --hops. Nodes can be named as #tag, a numeric entry ID, commit:<sha>,\n" +
+		"repo:<name>, or a full node ID.",
+	Example: "  sage graph\n" +
+		"  sage graph \"#auth\" --hops 2\n" +
+		"  sage graph 42 --at 2026-01-09\n" +
+		"  sage graph commit:1a2b3c4",
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := outputFormat()
+		if err != nil {
+			return err
+		}
+		g, label, err := loadGraph(cmd.Context(), graphAt, graphProject, graphAll, graphTags)
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 0 {
+			if format.Structured() {
+				return writeOutput(format, graphOutput(g, label, "", nil, nil))
+			}
+			printGraphSummary(g, label)
+			return nil
+		}
+
+		start, ok := g.Resolve(args[0])
+		if !ok {
+			return fmt.Errorf("no graph node matches %q", args[0])
+		}
+		hops, sub := g.Neighborhood(start, graphHops)
+		if format.Structured() {
+			return writeOutput(format, graphOutput(g, label, start, hops, sub))
+		}
+		printNeighborhood(g, hops, sub, graphHops)
+		return nil
+	},
+}
+
+var graphExportCmd = &cobra.Command{
+	Use:   "export [node]",
+	Short: "Export the graph as DOT, Mermaid, GraphML or JSON",
+	Long: "Write the semantic graph in a format other tools understand.\n\n" +
+		"Scoping matches `sage graph`: --project/--all/--tags pick the entries and --at\n" +
+		"replays the past. With a node, only its --hops neighbourhood is exported.\n\n" +
+		"Entry nodes carry their numeric ID, kind and title. Mermaid output is a\n" +
+		"flowchart that pastes straight into Markdown design docs.",
+	Example: "  sage graph export --format dot | dot -Tsvg > graph.svg\n" +
+		"  sage graph export \"#auth\" --format mermaid\n" +
+		"  sage graph export --all --format graphml -o sage.graphml",
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, ok := graph.ParseFormat(graphExportFormat)
+		if !ok {
+			return fmt.Errorf("unknown format %q (use %s)", graphExportFormat, strings.Join(graphFormatNames(), ", "))
+		}
+
+		g, _, err := loadGraph(cmd.Context(), graphAt, graphProject, graphAll, graphTags)
+		if err != nil {
+			return err
+		}
+		if len(args) == 1 {
+			start, ok := g.Resolve(args[0])
+			if !ok {
+				return fmt.Errorf("no graph node matches %q", args[0])
+			}
+			_, g = g.Neighborhood(start, graphHops)
+		}
+
+		var w io.Writer = cmd.OutOrStdout()
+		if graphExportOutput != "" && graphExportOutput != "-" {
+			f, err := os.Create(graphExportOutput)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+		return graph.Export(w, g, format)
+	},
+}
+
+func graphFormatNames() []string {
+	formats := graph.Formats()
+	names := make([]string, 0, len(formats))
+	for _, f := range formats {
+		names = append(names, string(f))
+	}
+	return names
+}
+
+// loadGraph replays the store (optionally up to at) into a graph, using the
+// same project and tag scoping as the other read commands.
+func loadGraph(ctx context.Context, at string, explicitProject string, all bool, rawTags []string) (*graph.Graph, string, error) {
+	s, err := openGlobalStore()
+	if err != nil {
+		return nil, "", err
+	}
+
+	q, err := scopedQuery("", rawTags, explicitProject, all)
+	if err != nil {
+		return nil, "", err
+	}
+	filter := store.Filter{Match: q}
+
+	label := "now"
+	var links []event.Link
+	if strings.TrimSpace(at) != "" {
+		t, err := parseTime(at)
+		if err != nil {
+			return nil, "", invalidTimeFlag("at", at)
+		}
+		label = t.Format(time.RFC3339)
+		filter.AsOf = t
+		links, err = s.LinksUntil(t)
+		if err != nil {
+			return nil, "", err
+		}
+	} else {
+		links, err = s.Links()
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	events, err := store.Collect(s.Query(ctx, filter))
+	if err != nil {
+		return nil, "", err
+	}
+	return graph.Build(events, links), label, nil
+}
+
+// graphOutput is the structured form of printGraphSummary (sub == nil) and
+// printNeighborhood.
+func graphOutput(g *graph.Graph, label, start string, hops []graph.Hop, sub *graph.Graph) output.Graph {
+	at := ""
+	if label != "now" {
+		at = label
+	}
+	counted := g
+	if sub != nil {
+		counted = sub
+	}
+	counts := map[string]int{}
+	for _, n := range counted.Nodes() {
+		counts[string(n.Kind)]++
+	}
+	if sub == nil {
+		return output.NewGraph(at, "", counts, nil, nil)
+	}
+
+	nodes := make([]output.GraphNode, 0, len(hops))
+	for _, h := range hops {
+		nodes = append(nodes, output.GraphNode{
+			ID:       h.Node.ID,
+			Kind:     string(h.Node.Kind),
+			Label:    h.Node.Label,
+			Seq:      h.Node.Seq,
+			Project:  h.Node.Project,
+			Distance: h.Distance,
+		})
+	}
+	var edges []output.GraphEdge
+	for _, e := range sub.Edges() {
+		edges = append(edges, output.GraphEdge{From: e.From, To: e.To, Relation: string(e.Relation)})
+	}
+	return output.NewGraph(at, start, counts, nodes, edges)
+}
+
+func printGraphSummary(g *graph.Graph, label string) {
+	nodes := g.Nodes()
+	fmt.Printf("Graph as of %s: %d nodes, %d edges\n\n", label, len(nodes), len(g.Edges()))
+
+	counts := map[graph.NodeKind]int{}
+	for _, n := range nodes {
+		counts[n.Kind]++
+	}
+	for _, kind := range []graph.NodeKind{graph.ConceptNode, graph.DecisionNode, graph.RecordNode, graph.CommitNode, graph.RepoNode, graph.FileNode} {
+		if counts[kind] > 0 {
+			fmt.Printf("%-9s %d\n", kind, counts[kind])
+		}
+	}
+
+	fmt.Println()
+	fmt.Println("To explore: sage graph \"#tag\" --hops 2  (or a numeric entry ID)")
+}
+
+func printNeighborhood(g *graph.Graph, hops []graph.Hop, sub *graph.Graph, maxHops int) {
+	if len(hops) == 0 {
+		fmt.Println("(none)")
+		return
+	}
+
+	fmt.Printf("Connected to %s within %d hop(s):\n\n", graphNodeLabel(hops[0].Node), maxHops)
+	for _, h := range hops[1:] {
+		fmt.Printf("%d  %-8s %s\n", h.Distance, h.Node.Kind, graphNodeLabel(h.Node))
+	}
+	if len(hops) == 1 {
+		fmt.Println("(nothing)")
+	}
+
+	edges := sub.Edges()
+	if len(edges) == 0 {
+		return
+	}
+	fmt.Println()
+	fmt.Println("Edges:")
+	for _, e := range edges {
+		from, _ := g.Node(e.From)
+		to, _ := g.Node(e.To)
+		fmt.Printf("- %s -%s-> %s\n", graphNodeLabel(from), e.Relation, graphNodeLabel(to))
+	}
+}
+
+func graphNodeLabel(n graph.Node) string {
+	if n.Seq != 0 {
+		return fmt.Sprintf("[%d] %s", n.Seq, n.Label)
+	}
+	return n.Label
+}
+
+func init() {
+	graphCmd.PersistentFlags().StringVar(&graphAt, "at", "", "replay up to this time (RFC3339, YYYY-MM-DD, or e.g. yesterday; see: sage help time)")
+	graphCmd.PersistentFlags().StringArrayVar(&graphTags, "tags", nil, "only include entries with these tags (repeatable or comma-separated)")
+	graphCmd.PersistentFlags().BoolVar(&graphAll, "all", false, "include entries from all projects")
+	graphCmd.PersistentFlags().StringVar(&graphProject, "project", "", "override project scope (ignores active project)")
+	graphCmd.PersistentFlags().IntVar(&graphHops, "hops", 2, "how far to walk from the node")
+	graphExportCmd.Flags().StringVar(&graphExportFormat, "format", string(graph.FormatDOT), "output format: dot, mermaid, graphml or json")
+	graphExportCmd.Flags().StringVarP(&graphExportOutput, "output", "o", "", "write to a file instead of stdout")
+	graphCmd.AddCommand(graphExportCmd)
+	rootCmd.AddCommand(graphCmd)
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/divijg19/sage/internal/event"
+)
+
+func TestLoadGraph_ReplaysAtTime(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("SAGE_PROJECT", "")
+
+	s, err := openGlobalStore()
+	if err != nil {
+		t.Fatalf("openGlobalStore: %v", err)
+	}
+	base := time.Date(2026, 1, 9, 10, 0, 0, 0, time.Local)
+	appendChronicleEvent(t, s, event.Event{ID: "d1", Timestamp: base, Project: "api", Kind: event.DecisionKind, Title: "Use JWT", Content: "c", Tags: []string{"auth"}})
+	appendChronicleEvent(t, s, event.Event{ID: "t1", Timestamp: base.Add(48 * time.Hour), Project: "api", Kind: event.TagKind, Tags: []string{"security"},
+		Metadata: map[string]string{event.MetaTarget: "d1", event.MetaOp: event.TagOpAdd}})
+
+	then, _, err := loadGraph(context.Background(), "2026-01-10", "", false, nil)
+	if err != nil {
+		t.Fatalf("loadGraph (then): %v", err)
+	}
+	if _, ok := then.Resolve("#security"); ok {
+		t.Fatalf("expected #security to be absent before it was tagged")
+	}
+
+	now, label, err := loadGraph(context.Background(), "", "", false, nil)
+	if err != nil {
+		t.Fatalf("loadGraph (now): %v", err)
+	}
+	if label != "now" {
+		t.Fatalf("expected label now, got %q", label)
+	}
+	start, ok := now.Resolve("#security")
+	if !ok {
+		t.Fatalf("expected #security concept")
+	}
+	hops, _ := now.Neighborhood(start, 2)
+	if len(hops) != 3 || hops[1].Node.Seq != 1 {
+		t.Fatalf("expected #security -> [1] -> #auth, got %+v", hops)
+	}
+}
@@ -1,14 +1,21 @@
 package cli
 
 import (
+	"bufio"
 	"crypto/sha1"
 	"encoding/hex"
 	"fmt"
+	"io"
+	"net/url"
+	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/divijg19/sage/internal/event"
+	"github.com/divijg19/sage/internal/store"
 	"github.com/spf13/cobra"
 )
 
@@ -31,86 +38,493 @@ var hookPostCommitCmd = &cobra.Command{
 	},
 }
 
+var hookPostCheckoutCmd = &cobra.Command{
+	Use:    "post-checkout <prev> <new> <branch-flag>",
+	Hidden: true,
+	Short:  "Record a git branch switch",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_ = runHookPostCheckout(hookRepo, args)
+		return nil
+	},
+}
+
+var hookPostMergeCmd = &cobra.Command{
+	Use:    "post-merge <squash-flag>",
+	Hidden: true,
+	Short:  "Record a git merge commit",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_ = runHookPostMerge(hookRepo, args)
+		return nil
+	},
+}
+
+var hookPostRewriteCmd = &cobra.Command{
+	Use:    "post-rewrite <amend|rebase>",
+	Hidden: true,
+	Short:  "Record commits rewritten by amend or rebase",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_ = runHookPostRewrite(hookRepo, args, cmd.InOrStdin())
+		return nil
+	},
+}
+
+var hookPrepareCommitMsgCmd = &cobra.Command{
+	Use:    "prepare-commit-msg <file> [source] [sha]",
+	Hidden: true,
+	Short:  "Pre-fill a Sage-Decision trailer",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_ = runHookPrepareCommitMsg(hookRepo, args)
+		return nil
+	},
+}
+
+var hookPrePushCmd = &cobra.Command{
+	Use:    "pre-push <remote> <url>",
+	Hidden: true,
+	Short:  "Record a git push",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Sage never blocks a push, whatever happens here.
+		_ = runHookPrePush(hookRepo, args, cmd.InOrStdin())
+		return nil
+	},
+}
+
 func init() {
 	hookCmd.PersistentFlags().StringVar(&hookRepo, "repo", "", "path to repo (defaults to current directory)")
 	_ = hookCmd.PersistentFlags().MarkHidden("repo")
 
 	hookCmd.AddCommand(hookPostCommitCmd)
+	hookCmd.AddCommand(hookPostCheckoutCmd)
+	hookCmd.AddCommand(hookPostMergeCmd)
+	hookCmd.AddCommand(hookPostRewriteCmd)
+	hookCmd.AddCommand(hookPrepareCommitMsgCmd)
+	hookCmd.AddCommand(hookPrePushCmd)
 	rootCmd.AddCommand(hookCmd)
 }
 
-func runHookPostCommit(repo string) error {
+// zeroSHA is how Git names a missing commit in hook arguments and input.
+var zeroSHA = regexp.MustCompile(`^0+$`)
+
+// hookRepoInfo identifies the repository a hook runs in.
+type hookRepoInfo struct {
+	root    string
+	id      string
+	project string
+}
+
+func resolveHookRepo(repo string) (hookRepoInfo, error) {
 	root, err := gitRepoRoot(repo)
 	if err != nil {
-		return nil
+		return hookRepoInfo{}, err
 	}
 	project := normalizeProjectName(strings.TrimSpace(filepath.Base(root)))
 	if project == "" {
 		project = "global"
 	}
+	return hookRepoInfo{root: root, id: repoHash(root), project: project}, nil
+}
+
+// commitEventID is the deterministic ID of the event for commit sha.
+func (r hookRepoInfo) commitEventID(sha string) string {
+	return fmt.Sprintf("git:%s:%s", r.id, sha)
+}
 
-	sha, err := gitOutput(repo, "rev-parse", "HEAD")
+func runHookPostCommit(repo string) error {
+	r, err := resolveHookRepo(repo)
 	if err != nil {
 		return nil
 	}
-	subject, _ := gitOutput(repo, "show", "-s", "--format=%s", "HEAD")
-	body, _ := gitOutput(repo, "show", "-s", "--format=%b", "HEAD")
-	authorName, _ := gitOutput(repo, "show", "-s", "--format=%an", "HEAD")
-	authorEmail, _ := gitOutput(repo, "show", "-s", "--format=%ae", "HEAD")
-	commitTimeRaw, _ := gitOutput(repo, "show", "-s", "--format=%aI", "HEAD")
+	e, err := commitEvent(repo, r, "HEAD")
+	if err != nil {
+		return nil
+	}
+	recordHookEvents(e)
+	return nil
+}
+
+// commitFormat prints the fields of a commit that its event records,
+// separated by NULs, which Git never allows in a commit message. The \x1d
+// ends the fields; with commitDiffArgs, Git prints the diff stat after it.
+const commitFormat = "%H%x00%P%x00%an%x00%ae%x00%aI%x00%s%x00%b%x00%(trailers:only,unfold)%x1d"
+
+// commitDiffArgs make git show and git log follow commitFormat with one
+// "added<TAB>deleted<TAB>path" line per changed file. Merges are diffed
+// against their first parent, which is what they changed on the branch.
+var commitDiffArgs = []string{"--numstat", "--no-renames", "--diff-merges=first-parent", "--no-show-signature"}
+
+// maxCommitFiles bounds the paths recorded for one commit. A vendored
+// dependency or a mass rename can touch tens of thousands of files, and the
+// counts still cover every one of them.
+const maxCommitFiles = 1000
+
+// gitCommit is one commit as printed by commitFormat and commitDiffArgs.
+type gitCommit struct {
+	SHA         string
+	Parents     []string
+	AuthorName  string
+	AuthorEmail string
+	CommitTime  string
+	Subject     string
+	Body        string
+	Trailers    string
+
+	Files      []string
+	Insertions int
+	Deletions  int
+}
+
+func parseGitCommit(raw string) (gitCommit, bool) {
+	fields, stat, _ := strings.Cut(strings.TrimLeft(raw, "\n"), "\x1d")
+	f := strings.Split(fields, "\x00")
+	if len(f) != 8 || strings.TrimSpace(f[0]) == "" {
+		return gitCommit{}, false
+	}
+	c := gitCommit{
+		SHA:         strings.TrimSpace(f[0]),
+		Parents:     strings.Fields(f[1]),
+		AuthorName:  f[2],
+		AuthorEmail: f[3],
+		CommitTime:  strings.TrimSpace(f[4]),
+		Subject:     f[5],
+		Body:        f[6],
+		Trailers:    f[7],
+	}
+	c.parseNumstat(stat)
+	return c, true
+}
+
+// parseNumstat reads git's --numstat lines. Binary files count as changed
+// but add no lines; paths Git had to quote are unquoted.
+func (c *gitCommit) parseNumstat(stat string) {
+	for _, line := range strings.Split(stat, "\n") {
+		added, rest, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		deleted, path, ok := strings.Cut(rest, "\t")
+		if !ok || path == "" {
+			continue
+		}
+		if strings.HasPrefix(path, `"`) {
+			if p, err := strconv.Unquote(path); err == nil {
+				path = p
+			}
+		}
+		n, _ := strconv.Atoi(added)
+		c.Insertions += n
+		n, _ = strconv.Atoi(deleted)
+		c.Deletions += n
+		c.Files = append(c.Files, path)
+	}
+}
+
+// commitEvent builds the commit event for rev, with the current branch.
+func commitEvent(repo string, r hookRepoInfo, rev string) (event.Event, error) {
+	args := append([]string{"show", "--format=" + commitFormat}, commitDiffArgs...)
+	out, err := gitOutput(repo, append(args, rev+"^{commit}")...)
+	if err != nil {
+		return event.Event{}, err
+	}
+	c, ok := parseGitCommit(out)
+	if !ok {
+		return event.Event{}, fmt.Errorf("could not read commit %s", rev)
+	}
 	branch, _ := gitOutput(repo, "rev-parse", "--abbrev-ref", "HEAD")
 
+	e := newCommitEvent(r, c, branch)
+	_ = ensureTagsConfigured(e.Tags)
+	return e, nil
+}
+
+// newCommitEvent is the event for commit c. Its ID depends only on the
+// repository and the SHA, so every hook (and backfill) that sees the commit
+// dedupes.
+func newCommitEvent(r hookRepoInfo, c gitCommit, branch string) event.Event {
 	timestamp := time.Now()
-	if strings.TrimSpace(commitTimeRaw) != "" {
-		if t, err := time.Parse(time.RFC3339, strings.TrimSpace(commitTimeRaw)); err == nil {
+	if c.CommitTime != "" {
+		if t, err := time.Parse(time.RFC3339, c.CommitTime); err == nil {
 			timestamp = t
 		}
 	}
 
-	repoID := repoHash(root)
-	eventID := fmt.Sprintf("git:%s:%s", repoID, sha)
-
-	content := buildCommitContent(sha, branch, strings.TrimSpace(body))
-	title := strings.TrimSpace(subject)
+	content := buildCommitContent(c.SHA, branch, strings.TrimSpace(c.Body))
+	title := strings.TrimSpace(c.Subject)
 	if title == "" {
 		title = "(no subject)"
 	}
 
-	tags := []string{"git", "commit"}
-	_ = ensureTagsConfigured(tags)
+	e := event.Event{
+		ID:        r.commitEventID(c.SHA),
+		Timestamp: timestamp,
+		Project:   r.project,
+		Kind:      event.CommitKind,
+		Title:     title,
+		Content:   content,
+		Tags:      []string{"git", "commit"},
+		Metadata: map[string]string{
+			"repo_root":    r.root,
+			"repo_id":      r.id,
+			"sha":          c.SHA,
+			"branch":       branch,
+			"author_name":  c.AuthorName,
+			"author_email": c.AuthorEmail,
+			"commit_time":  c.CommitTime,
+			"parents":      strings.Join(c.Parents, ","),
+			"insertions":   strconv.Itoa(c.Insertions),
+			"deletions":    strconv.Itoa(c.Deletions),
+		},
+	}
+	if len(c.Files) > 0 {
+		e.Metadata[event.MetaFiles] = strings.Join(c.Files[:min(len(c.Files), maxCommitFiles)], "\n")
+	}
+	parseCommitTrailers(c.Trailers).apply(&e)
+	return e
+}
 
-	s, err := openGlobalStore()
+// runHookPostMerge records the merge commit a merge created. Fast-forwards
+// create none, and a squash merge is recorded by post-commit once committed.
+func runHookPostMerge(repo string, args []string) error {
+	if len(args) > 0 && args[0] == "1" {
+		return nil
+	}
+	r, err := resolveHookRepo(repo)
+	if err != nil {
+		return nil
+	}
+	parents, err := gitOutput(repo, "show", "-s", "--format=%P", "HEAD")
+	if err != nil || len(strings.Fields(parents)) < 2 {
+		return nil
+	}
+	e, err := commitEvent(repo, r, "HEAD")
+	if err != nil {
+		return nil
+	}
+	e.Metadata["merge"] = "true"
+	recordHookEvents(e)
+	return nil
+}
+
+// runHookPostRewrite records each commit an amend or rebase wrote, and links
+// it to the commit it replaced: "new supersedes old". The link names the old
+// commit by its event ID, so it resolves whenever that commit was recorded.
+func runHookPostRewrite(repo string, args []string, in io.Reader) error {
+	rewrite := "rebase"
+	if len(args) > 0 && strings.TrimSpace(args[0]) != "" {
+		rewrite = strings.TrimSpace(args[0])
+	}
+	r, err := resolveHookRepo(repo)
 	if err != nil {
 		return nil
 	}
 
-	e := event.Event{
-		ID:        eventID,
-		Timestamp: timestamp,
-		Project:   project,
-		Kind:      event.CommitKind,
+	var events []event.Event
+	sc := bufio.NewScanner(in)
+	for sc.Scan() {
+		// Each line is "<old-sha> <new-sha> [extra]".
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		oldSHA, newSHA := fields[0], fields[1]
+		e, err := commitEvent(repo, r, newSHA)
+		if err != nil {
+			continue
+		}
+		// post-commit has usually recorded the new commit already (it runs
+		// first on an amend), so the rewrite is described on the link, whose
+		// ID is new, rather than on the commit event.
+		link := event.NewLinkEvent(
+			fmt.Sprintf("git:%s:rewrite:%s:%s", r.id, oldSHA, e.Metadata["sha"]),
+			time.Now(), e, event.Supersedes, event.Event{ID: r.commitEventID(oldSHA)},
+		)
+		link.Metadata["rewrite"] = rewrite
+		link.Metadata["rewritten_from"] = oldSHA
+		events = append(events, e, link)
+	}
+	recordHookEvents(events...)
+	return nil
+}
+
+// runHookPostCheckout records a branch switch. File checkouts, the initial
+// checkout of a clone and the checkouts a rebase makes along the way are not
+// switches a reader would recognise, so they are skipped.
+func runHookPostCheckout(repo string, args []string) error {
+	if len(args) < 3 || args[2] != "1" || zeroSHA.MatchString(args[0]) {
+		return nil
+	}
+	if strings.HasPrefix(os.Getenv("GIT_REFLOG_ACTION"), "rebase") {
+		return nil
+	}
+	r, err := resolveHookRepo(repo)
+	if err != nil {
+		return nil
+	}
+	fromSHA, sha := args[0], args[1]
+	branch, _ := gitOutput(repo, "rev-parse", "--abbrev-ref", "HEAD")
+	fromBranch, _ := gitOutput(repo, "rev-parse", "--abbrev-ref", "@{-1}")
+
+	title := "Switched to " + branch
+	if branch == "" || branch == "HEAD" {
+		branch = ""
+		title = "Detached HEAD at " + shortSHA(sha)
+	}
+
+	lines := []string{"from: " + describeRef(fromBranch, fromSHA), "to: " + describeRef(branch, sha)}
+	tags := []string{"git", "checkout"}
+	_ = ensureTagsConfigured(tags)
+
+	now := time.Now()
+	recordHookEvents(event.Event{
+		ID:        fmt.Sprintf("git:%s:checkout:%d", r.id, now.UnixNano()),
+		Timestamp: now,
+		Project:   r.project,
+		Kind:      event.GitKind,
 		Title:     title,
-		Content:   content,
+		Content:   strings.Join(lines, "\n"),
 		Tags:      tags,
 		Metadata: map[string]string{
-			"repo_root":    root,
-			"repo_id":      repoID,
-			"sha":          sha,
-			"branch":       branch,
-			"author_name":  authorName,
-			"author_email": authorEmail,
-			"commit_time":  commitTimeRaw,
+			"git_event":   "checkout",
+			"repo_root":   r.root,
+			"repo_id":     r.id,
+			"sha":         sha,
+			"branch":      branch,
+			"from_sha":    fromSHA,
+			"from_branch": fromBranch,
 		},
+	})
+	return nil
+}
+
+// runHookPrePush records a push before it happens: Git gives no hook after
+// one. The input has one "<local-ref> <local-sha> <remote-ref> <remote-sha>"
+// line per ref; a zero local SHA deletes the remote ref.
+func runHookPrePush(repo string, args []string, in io.Reader) error {
+	if len(args) < 1 {
+		return nil
+	}
+	remote := args[0]
+	remoteURL := remote
+	if len(args) > 1 && strings.TrimSpace(args[1]) != "" {
+		remoteURL = redactURL(args[1])
+	}
+	r, err := resolveHookRepo(repo)
+	if err != nil {
+		return nil
 	}
 
-	if err := s.Append(e); err != nil {
-		// Ignore duplicates or any failures: never block git.
+	var pushed, deleted, refs, lines []string
+	sc := bufio.NewScanner(in)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		localSHA, remoteRef, remoteSHA := fields[1], fields[2], fields[3]
+		name := shortRef(remoteRef)
+		refs = append(refs, remoteRef)
+		switch {
+		case zeroSHA.MatchString(localSHA):
+			deleted = append(deleted, name)
+			lines = append(lines, "delete "+remoteRef)
+		case zeroSHA.MatchString(remoteSHA):
+			pushed = append(pushed, name)
+			lines = append(lines, "create "+remoteRef+" at "+shortSHA(localSHA))
+		default:
+			pushed = append(pushed, name)
+			lines = append(lines, "update "+remoteRef+" "+shortSHA(remoteSHA)+".."+shortSHA(localSHA))
+		}
+	}
+	if len(refs) == 0 {
 		return nil
 	}
 
+	title := "Pushed " + strings.Join(pushed, ", ") + " to " + remote
+	if len(pushed) == 0 {
+		title = "Deleted " + strings.Join(deleted, ", ") + " from " + remote
+	}
+	tags := []string{"git", "push"}
+	_ = ensureTagsConfigured(tags)
+
+	now := time.Now()
+	recordHookEvents(event.Event{
+		ID:        fmt.Sprintf("git:%s:push:%d", r.id, now.UnixNano()),
+		Timestamp: now,
+		Project:   r.project,
+		Kind:      event.GitKind,
+		Title:     title,
+		Content:   strings.Join(lines, "\n"),
+		Tags:      tags,
+		Metadata: map[string]string{
+			"git_event":  "push",
+			"repo_root":  r.root,
+			"repo_id":    r.id,
+			"remote":     remote,
+			"remote_url": remoteURL,
+			"refs":       strings.Join(refs, ","),
+		},
+	})
 	return nil
 }
 
+// recordHookEvents appends events, spooling any the store cannot take so they
+// are recorded later rather than lost. It never fails: hooks must not block
+// Git.
+func recordHookEvents(events ...event.Event) {
+	if len(events) == 0 {
+		return
+	}
+	s, err := openGlobalStore()
+	if err == nil {
+		// Trailer links resolve against the store; spooled commits keep the
+		// trailers in their metadata and are linked when drained.
+		if linked, err := withTrailerLinks(s, events); err == nil {
+			events = linked
+		}
+	}
+	for _, e := range events {
+		if err != nil {
+			_ = spoolEvent(e)
+			continue
+		}
+		if err := s.Append(e); err != nil && !store.IsDuplicate(err) {
+			_ = spoolEvent(e)
+		}
+	}
+}
+
+// redactURL drops credentials from a remote URL before it is stored.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	u.User = nil
+	return u.String()
+}
+
+func shortRef(ref string) string {
+	for _, prefix := range []string{"refs/heads/", "refs/tags/"} {
+		if name, ok := strings.CutPrefix(ref, prefix); ok {
+			return name
+		}
+	}
+	return ref
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+func describeRef(branch, sha string) string {
+	if branch == "" || branch == "HEAD" {
+		return shortSHA(sha)
+	}
+	return branch + " (" + shortSHA(sha) + ")"
+}
+
 func repoHash(s string) string {
 	h := sha1.Sum([]byte(s))
 	// 10 bytes (20 hex chars) is enough to avoid collisions in practice.
@@ -2,7 +2,9 @@ package cli
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -13,31 +15,54 @@ var hooksHook string
 var hooksForce bool
 var hooksDryRun bool
 var hooksSync bool
+var hooksGlobal bool
+var hooksBackfillSince string
+var hooksBackfillBranch string
+
+// supportedHooks lists the Git hooks Sage can install.
+var supportedHooks = []string{"post-commit", "post-checkout", "post-merge", "post-rewrite", "pre-push", "prepare-commit-msg"}
 
 var hooksCmd = &cobra.Command{
 	Use:   "hooks",
 	Short: "Install and manage Git hooks",
 	Long: "Install and manage Sage Git hooks for the current repository (or --repo).\n\n" +
-		"Sage hooks are designed to be safe: they never block commits, and will back up\n" +
+		"Sage hooks are designed to be safe: they never block Git, and will back up\n" +
 		"existing hooks and chain them by default.\n\n" +
-		"Commit events are recorded under a project derived from the repo name.",
+		"post-commit, post-merge and post-rewrite record commits; post-checkout and\n" +
+		"pre-push record branch switches and pushes. Events are recorded under a\n" +
+		"project derived from the repo name.\n\n" +
+		"prepare-commit-msg is optional: it pre-fills a Sage-Decision trailer naming\n" +
+		"the most recent decision, which links the commit to it.\n\n" +
+		"With --global, install, status and uninstall manage hooks in ~/.sage/hooks for\n" +
+		"every repository at once, through the global core.hooksPath. Each repository's\n" +
+		"own .git/hooks/<name> still runs after Sage.",
 }
 
 var hooksInstallCmd = &cobra.Command{
 	Use:   "install",
 	Short: "Install Sage Git hook(s)",
+	Example: "  sage hooks install\n" +
+		"  sage hooks install --hook pre-push\n" +
+		"  sage hooks install --global",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		hook, err := validateHookName(hooksHook)
 		if err != nil {
 			return err
 		}
+		opts := HookInstallOptions{Force: hooksForce, DryRun: hooksDryRun, Sync: hooksSync}
+		if hooksGlobal {
+			return installHooksGlobal(hook, opts)
+		}
 
 		hooksDir, coreHooksPath, err := gitHooksDir(hooksRepo)
 		if err != nil {
 			return err
 		}
+		if err := refuseGlobalHooksDir(hooksDir); err != nil {
+			return err
+		}
 
-		res, err := InstallHook(hooksDir, hook, HookInstallOptions{Force: hooksForce, DryRun: hooksDryRun, Sync: hooksSync})
+		res, err := InstallHook(hooksDir, hook, opts)
 		if err != nil {
 			return err
 		}
@@ -66,6 +91,9 @@ var hooksStatusCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		if hooksGlobal {
+			return printGlobalHooksStatus(hook)
+		}
 
 		root, err := gitRepoRoot(hooksRepo)
 		if err != nil {
@@ -94,6 +122,9 @@ var hooksStatusCmd = &cobra.Command{
 		}
 		fmt.Printf("%s: installed at %s\n", hook, ins.HookPath)
 		fmt.Printf("Sage-managed: %t\n", ins.SageManaged)
+		if ins.Global {
+			fmt.Println("Global: true (see: sage hooks status --global)")
+		}
 		if ins.LegacyHookPath != "" {
 			fmt.Printf("Chained legacy hook: %s\n", ins.LegacyHookPath)
 		}
@@ -109,11 +140,17 @@ var hooksUninstallCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		if hooksGlobal {
+			return uninstallHooksGlobal(hook)
+		}
 
 		hooksDir, coreHooksPath, err := gitHooksDir(hooksRepo)
 		if err != nil {
 			return err
 		}
+		if err := refuseGlobalHooksDir(hooksDir); err != nil {
+			return err
+		}
 
 		msg, err := UninstallHook(hooksDir, hook, HookInstallOptions{Force: hooksForce, DryRun: hooksDryRun})
 		if err != nil {
@@ -133,27 +170,180 @@ var hooksUninstallCmd = &cobra.Command{
 	},
 }
 
+var hooksBackfillCmd = &cobra.Command{
+	Use:   "backfill",
+	Short: "Record past commits from git log",
+	Long: "Record the commits made before the hooks were installed, oldest first, as\n" +
+		"post-commit would have: same IDs, metadata and trailer links. Commits already in\n" +
+		"the log are skipped, so backfill can be run again at any time.\n\n" +
+		"--since takes the same times as `sage state --at` (see `sage help time`).\n" +
+		"--branch walks another branch instead of HEAD.",
+	Example: "  sage hooks backfill\n" +
+		"  sage hooks backfill --since 2026-01-01 --branch main\n" +
+		"  sage hooks backfill --dry-run",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := backfillOptions{Branch: hooksBackfillBranch, DryRun: hooksDryRun}
+		if hooksBackfillSince != "" {
+			t, err := parseTime(hooksBackfillSince)
+			if err != nil {
+				return invalidTimeFlag("since", hooksBackfillSince)
+			}
+			opts.Since = t
+		}
+		if stderrIsTTY() {
+			opts.Progress = os.Stderr
+		}
+
+		s, err := openGlobalStore()
+		if err != nil {
+			return err
+		}
+		res, err := runHooksBackfill(s, hooksRepo, opts)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Repo: %s\n", res.Root)
+		fmt.Printf("Project: %s\n", res.Project)
+		if hooksDryRun {
+			fmt.Printf("Would record %d of %d commit(s) on %s\n", res.Recorded, res.Commits, res.Rev)
+			fmt.Println("(dry-run) no entries were recorded")
+			return nil
+		}
+		fmt.Printf("Recorded %d of %d commit(s) on %s (%d already recorded)\n", res.Recorded, res.Commits, res.Rev, res.Commits-res.Recorded)
+		return nil
+	},
+}
+
+func installHooksGlobal(hook string, opts HookInstallOptions) error {
+	res, err := installGlobalHook(hook, opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Global hooks dir: %s\n", res.HooksDir)
+	if res.Previous != "" {
+		fmt.Printf("Replaced core.hooksPath: %s\n", res.Previous)
+	}
+	fmt.Printf("core.hooksPath (global): %s\n", res.HooksDir)
+	fmt.Printf("Installed: %s\n", res.HookPath)
+	if res.BackedUp {
+		fmt.Printf("Backed up existing hook to: %s\n", res.BackupPath)
+	}
+	fmt.Printf("Each repository's own .git/hooks/%s still runs after Sage.\n", hook)
+	if hooksDryRun {
+		fmt.Println("(dry-run) no files or settings were modified")
+	}
+	return nil
+}
+
+func uninstallHooksGlobal(hook string) error {
+	msg, unset, err := uninstallGlobalHook(hook, HookInstallOptions{Force: hooksForce, DryRun: hooksDryRun})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Global hooks dir: %s\n", globalHooksDir())
+	fmt.Printf("%s: %s\n", hook, msg)
+	if unset {
+		fmt.Println("Unset core.hooksPath (global); repositories run .git/hooks again.")
+	}
+	if hooksDryRun {
+		fmt.Println("(dry-run) no files or settings were modified")
+	}
+	return nil
+}
+
+func printGlobalHooksStatus(hook string) error {
+	dir := globalHooksDir()
+	ins, err := InspectHook(dir, hook)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Global hooks dir: %s\n", dir)
+	switch current := globalCoreHooksPath(); {
+	case current == "":
+		fmt.Println("core.hooksPath (global): not set")
+	case samePath(current, dir):
+		fmt.Printf("core.hooksPath (global): %s\n", current)
+	default:
+		fmt.Printf("core.hooksPath (global): %s\n", current)
+		fmt.Println("Warning: the global core.hooksPath points elsewhere, so Sage's global hooks do not run.")
+	}
+	if !ins.Exists || !ins.SageManaged {
+		fmt.Printf("%s: not installed globally\n", hook)
+		return nil
+	}
+	fmt.Printf("%s: installed at %s\n", hook, ins.HookPath)
+	if ins.LegacyHookPath != "" {
+		fmt.Printf("Chained legacy hook: %s\n", ins.LegacyHookPath)
+	}
+
+	var known []string
+	if s, err := openGlobalStore(); err == nil {
+		known, _ = s.ListRepoRoots()
+	}
+	repos := globalHookCoverage(hook, coveredRepoRoots(known, hooksRepo))
+	covered := 0
+	for _, r := range repos {
+		if r.Covered {
+			covered++
+		}
+	}
+	fmt.Printf("Repos: %d of %d covered\n", covered, len(repos))
+	for _, r := range repos {
+		status := "covered"
+		if !r.Covered {
+			status = "not covered"
+		}
+		line := fmt.Sprintf("  %-12s %s", status, r.Root)
+		if r.Note != "" {
+			line += " (" + r.Note + ")"
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// refuseGlobalHooksDir stops a per-repository install or uninstall from
+// rewriting the hooks every repository shares.
+func refuseGlobalHooksDir(hooksDir string) error {
+	if samePath(hooksDir, globalHooksDir()) {
+		return fmt.Errorf("this repository runs Sage's global hooks in %s; manage them with --global", hooksDir)
+	}
+	return nil
+}
+
 func validateHookName(name string) (string, error) {
 	name = strings.TrimSpace(name)
 	if name == "" {
 		name = "post-commit"
 	}
-	if name != "post-commit" {
-		return "", fmt.Errorf("unsupported hook: %s (only post-commit is supported)", name)
+	if !slices.Contains(supportedHooks, name) {
+		return "", fmt.Errorf("unsupported hook: %s (use %s)", name, strings.Join(supportedHooks, ", "))
 	}
 	return name, nil
 }
 
 func init() {
 	hooksCmd.PersistentFlags().StringVar(&hooksRepo, "repo", "", "path to repo (defaults to current directory)")
-	hooksCmd.PersistentFlags().StringVar(&hooksHook, "hook", "post-commit", "hook name (currently only post-commit is supported)")
-	hooksCmd.PersistentFlags().BoolVar(&hooksForce, "force", false, "overwrite existing hook instead of backing it up")
+	hooksCmd.PersistentFlags().StringVar(&hooksHook, "hook", "post-commit", "hook name: post-commit, post-checkout, post-merge, post-rewrite, pre-push or prepare-commit-msg")
+	hooksCmd.PersistentFlags().BoolVar(&hooksForce, "force", false, "overwrite existing hook instead of backing it up (with --global, also replace another global core.hooksPath)")
 	hooksCmd.PersistentFlags().BoolVar(&hooksDryRun, "dry-run", false, "print what would change without modifying files")
 	hooksInstallCmd.Flags().BoolVar(&hooksSync, "sync", false, "run Sage synchronously on commit (default: background)")
+	hooksInstallCmd.Flags().BoolVar(&hooksGlobal, "global", false, "install in ~/.sage/hooks for every repository (sets the global core.hooksPath)")
+	hooksStatusCmd.Flags().BoolVar(&hooksGlobal, "global", false, "show the global hooks and the repositories they cover")
+	hooksUninstallCmd.Flags().BoolVar(&hooksGlobal, "global", false, "uninstall from ~/.sage/hooks (unsets the global core.hooksPath once empty)")
 
 	hooksCmd.AddCommand(hooksInstallCmd)
 	hooksCmd.AddCommand(hooksStatusCmd)
 	hooksCmd.AddCommand(hooksUninstallCmd)
 
+	hooksBackfillCmd.Flags().StringVar(&hooksBackfillSince, "since", "", "only commits made at or after this time")
+	hooksBackfillCmd.Flags().StringVar(&hooksBackfillBranch, "branch", "", "branch to walk (default: HEAD)")
+	hooksCmd.AddCommand(hooksBackfillCmd)
+
 	rootCmd.AddCommand(hooksCmd)
 }
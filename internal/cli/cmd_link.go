@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+
+	"github.com/divijg19/sage/internal/entryflow"
+	"github.com/divijg19/sage/internal/event"
+)
+
+var linkCmd = &cobra.Command{
+	Use:   "link <from-id> <relation> <to-id>",
+	Short: "Relate two entries",
+	Long: "Record a typed relationship between two entries by numeric ID.\n\n" +
+		"Relations: affects, depends_on, supersedes, references, implements.\n\n" +
+		"Links are appended as their own events; neither entry is modified. They can also\n" +
+		"be declared in the editor front matter, for example `supersedes: 42`.\n" +
+		"A decision that has been superseded is marked as such in `sage state`.",
+	Example: "  sage link 43 supersedes 42\n" +
+		"  sage link 50 depends_on 43\n" +
+		"  sage view 42",
+	Args: cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from, err := strconv.ParseInt(strings.TrimSpace(args[0]), 10, 64)
+		if err != nil || from <= 0 {
+			return fmt.Errorf("invalid entry id: %s", args[0])
+		}
+		to, err := strconv.ParseInt(strings.TrimSpace(args[2]), 10, 64)
+		if err != nil || to <= 0 {
+			return fmt.Errorf("invalid entry id: %s", args[2])
+		}
+
+		s, err := openGlobalStore()
+		if err != nil {
+			return err
+		}
+		return runLink(s, from, args[1], to)
+	},
+}
+
+type storeLinker interface {
+	GetBySeq(seq int64) (*event.Event, error)
+	Append(e event.Event) error
+}
+
+func runLink(s storeLinker, fromSeq int64, rawRelation string, toSeq int64) error {
+	rel, ok := event.ParseRelation(rawRelation)
+	if !ok {
+		return fmt.Errorf("unknown relation: %s (use %s)", rawRelation, relationNames())
+	}
+	if fromSeq == toSeq {
+		return fmt.Errorf("an entry cannot link to itself")
+	}
+
+	from, err := entryflow.LinkableEntry(s, fromSeq)
+	if err != nil {
+		return err
+	}
+	to, err := entryflow.LinkableEntry(s, toSeq)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Append(event.NewLinkEvent(uuid.NewString(), time.Now(), *from, rel, *to)); err != nil {
+		return err
+	}
+	fmt.Printf("Linked [%d] %s [%d]\n", fromSeq, rel, toSeq)
+	return nil
+}
+
+func relationNames() string {
+	var names []string
+	for _, r := range event.Relations() {
+		names = append(names, string(r))
+	}
+	return strings.Join(names, ", ")
+}
+
+func init() {
+	rootCmd.AddCommand(linkCmd)
+}
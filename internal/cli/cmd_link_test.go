@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/divijg19/sage/internal/event"
+	"github.com/divijg19/sage/internal/state"
+	"github.com/divijg19/sage/internal/store"
+)
+
+func TestRunLink_AppendsLinkAndMarksSuperseded(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	s, err := openGlobalStore()
+	if err != nil {
+		t.Fatalf("openGlobalStore: %v", err)
+	}
+	base := time.Date(2026, 4, 24, 9, 0, 0, 0, time.UTC)
+	for i, title := range []string{"Use redis", "Use postgres"} {
+		if err := s.Append(event.Event{
+			ID:        title,
+			Timestamp: base.Add(time.Duration(i) * time.Hour),
+			Project:   "alpha",
+			Kind:      event.DecisionKind,
+			Title:     title,
+			Content:   "context",
+		}); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	if err := runLink(s, 2, "supersedes", 2); err == nil {
+		t.Fatalf("expected self-link to fail")
+	}
+	if err := runLink(s, 2, "replaces", 1); err == nil {
+		t.Fatalf("expected unknown relation to fail")
+	}
+	if err := runLink(s, 2, "supersedes", 1); err != nil {
+		t.Fatalf("runLink: %v", err)
+	}
+
+	links, err := s.LinksFor("Use redis")
+	if err != nil {
+		t.Fatalf("LinksFor: %v", err)
+	}
+	if len(links) != 1 || links[0].From != "Use postgres" || links[0].Relation != event.Supersedes {
+		t.Fatalf("unexpected links: %+v", links)
+	}
+
+	events, err := store.Collect(s.Query(context.Background(), store.Filter{}))
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected link events to stay out of listings, got %d entries", len(events))
+	}
+
+	now, err := s.LinksUntil(time.Now())
+	if err != nil {
+		t.Fatalf("LinksUntil: %v", err)
+	}
+	superseded := state.SupersededBy(events, now)
+	if by, ok := superseded["Use redis"]; !ok || by.Seq != 2 {
+		t.Fatalf("expected [1] superseded by [2], got %+v", superseded)
+	}
+
+	// A retracted superseder no longer supersedes anything.
+	if err := runRetract(s, 2, "", true); err != nil {
+		t.Fatalf("runRetract: %v", err)
+	}
+	events, err = store.Collect(s.Query(context.Background(), store.Filter{}))
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(state.SupersededBy(events, now)) != 0 {
+		t.Fatalf("expected retracted superseder to be ignored")
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+
+	"github.com/divijg19/sage/internal/output"
 )
 
 type shellKind string
@@ -138,6 +140,19 @@ func runProjectsList() error {
 	sort.Strings(filtered)
 
 	cur := activeProjectFromEnv()
+
+	format, err := outputFormat()
+	if err != nil {
+		return err
+	}
+	if format.Structured() {
+		list := make([]output.Project, 0, len(filtered))
+		for _, p := range filtered {
+			list = append(list, output.Project{Name: p, Active: p == cur})
+		}
+		return writeOutput(format, output.NewProjects(list))
+	}
+
 	if cur == "" {
 		cur = "(none)"
 	}
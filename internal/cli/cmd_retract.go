@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+
+	"github.com/divijg19/sage/internal/event"
+)
+
+// retractedMarker prefixes retracted entries wherever they are shown.
+const retractedMarker = "[retracted]"
+
+var retractReason string
+var retractYes bool
+
+var retractCmd = &cobra.Command{
+	Use:   "retract <id>",
+	Short: "Retract a past entry by numeric ID",
+	Long: "Hide an entry logged by mistake (wrong project, sensitive content) from\n" +
+		"timeline, tag, state and Chronicle.\n\n" +
+		"Retraction is itself an event: the original row stays in the log so history is\n" +
+		"auditable. Use --include-retracted on read commands to show retracted entries,\n" +
+		"and `sage state --at` before the retraction still includes the entry.",
+	Example: "  sage retract 42\n" +
+		"  sage retract 42 --reason \"logged in the wrong project\" --yes\n" +
+		"  sage timeline --include-retracted",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.ParseInt(strings.TrimSpace(args[0]), 10, 64)
+		if err != nil || id <= 0 {
+			return fmt.Errorf("invalid entry id: %s", args[0])
+		}
+
+		s, err := openGlobalStore()
+		if err != nil {
+			return err
+		}
+
+		return runRetract(s, id, retractReason, retractYes)
+	},
+}
+
+type storeRetracter interface {
+	GetBySeq(seq int64) (*event.Event, error)
+	Append(e event.Event) error
+}
+
+func runRetract(s storeRetracter, id int64, reason string, yes bool) error {
+	e, err := s.GetBySeq(id)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return fmt.Errorf("no entry with id %d", id)
+	}
+	if e.Kind.IsAnnotation() {
+		return fmt.Errorf("entry %d is a %s event and cannot be retracted", id, e.Kind)
+	}
+	if e.Retracted {
+		fmt.Printf("Entry %d is already retracted\n", id)
+		return nil
+	}
+
+	if !yes && !confirm(fmt.Sprintf("Retract entry %d %q? [y/N]: ", id, e.Title)) {
+		fmt.Println("retraction canceled")
+		return nil
+	}
+
+	if err := s.Append(newRetractEvent(*e, reason)); err != nil {
+		return err
+	}
+	fmt.Printf("Retracted entry %d\n", id)
+	return nil
+}
+
+func newRetractEvent(target event.Event, reason string) event.Event {
+	return event.Event{
+		ID:        uuid.NewString(),
+		Timestamp: time.Now(),
+		Project:   target.Project,
+		Kind:      event.RetractKind,
+		Title:     "retract " + strconv.FormatInt(target.Seq, 10),
+		Content:   strings.TrimSpace(reason),
+		Metadata: map[string]string{
+			event.MetaTarget: target.ID,
+		},
+	}
+}
+
+func init() {
+	retractCmd.Flags().StringVar(&retractReason, "reason", "", "why the entry is being retracted")
+	retractCmd.Flags().BoolVar(&retractYes, "yes", false, "skip the confirmation prompt")
+	rootCmd.AddCommand(retractCmd)
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/divijg19/sage/internal/event"
+	"github.com/divijg19/sage/internal/store"
+)
+
+func TestRunRetract_AppendsTombstoneAndHidesEntry(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	s, err := openGlobalStore()
+	if err != nil {
+		t.Fatalf("openGlobalStore: %v", err)
+	}
+	if err := s.Append(event.Event{
+		ID:        "evt-1",
+		Timestamp: time.Date(2026, 4, 24, 9, 0, 0, 0, time.UTC),
+		Project:   "alpha",
+		Kind:      event.RecordKind,
+		Title:     "Wrong project",
+		Content:   "Added context",
+	}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	if err := runRetract(s, 1, "logged in the wrong project", true); err != nil {
+		t.Fatalf("runRetract: %v", err)
+	}
+	// Retracting twice is a no-op.
+	if err := runRetract(s, 1, "", true); err != nil {
+		t.Fatalf("runRetract (again): %v", err)
+	}
+
+	events, err := store.Collect(s.Query(context.Background(), store.Filter{}))
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("expected retracted entry to be hidden, got %+v", events)
+	}
+
+	annotations, err := s.Annotations("evt-1")
+	if err != nil {
+		t.Fatalf("Annotations: %v", err)
+	}
+	if len(annotations) != 1 || annotations[0].Kind != event.RetractKind || annotations[0].Content != "logged in the wrong project" {
+		t.Fatalf("unexpected retraction events: %+v", annotations)
+	}
+
+	if err := runTagApply(s, 1, "auth"); err == nil {
+		t.Fatalf("expected tagging a retracted entry to fail")
+	}
+}
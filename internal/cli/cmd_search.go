@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/divijg19/sage/internal/output"
+	"github.com/divijg19/sage/internal/store"
+)
+
+var searchAll bool
+var searchProject string
+var searchLimit int
+var searchIncludeRetracted bool
+
+var searchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Full-text search across entries",
+	Long: "Search entry titles, content, tags and projects using the store's full-text index.\n" +
+		"Results are ranked best-first (title matches weigh the most) and show a snippet\n" +
+		"with the matched terms highlighted.\n\n" +
+		"Words match as prefixes (\"auth\" finds \"authentication\"); \"quoted text\" matches\n" +
+		"as an exact phrase. Every word must match.\n\n" +
+		"The query may also use filters such as kind:, tag:, project: and after:\n" +
+		"(see `sage help query`); free text drives the ranking.",
+	Example: "  sage search cache\n" +
+		"  sage search '\"token expiry\" jwt'\n" +
+		"  sage search auth --all --limit 5\n" +
+		"  sage search 'kind:decision tag:auth rotation'",
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := openGlobalStore()
+		if err != nil {
+			return err
+		}
+		if searchIncludeRetracted {
+			s = s.IncludingRetracted()
+		}
+
+		raw := strings.Join(args, " ")
+		q, err := scopedQuery(raw, nil, searchProject, searchAll)
+		if err != nil {
+			return err
+		}
+		if bare, _ := parseQuery(raw); bare.Empty() {
+			return fmt.Errorf("nothing to search for")
+		}
+
+		format, err := outputFormat()
+		if err != nil {
+			return err
+		}
+
+		opts := store.SearchOptions{Limit: searchLimit, HighlightStart: "**", HighlightEnd: "**"}
+		if stdoutIsTTY() && !format.Structured() {
+			opts.HighlightStart, opts.HighlightEnd = "\x1b[1;4m", "\x1b[0m"
+		}
+
+		hits, err := s.Search(q, opts)
+		if err != nil {
+			return err
+		}
+		if format.Structured() {
+			results := make([]output.SearchResult, 0, len(hits))
+			for _, h := range hits {
+				results = append(results, output.SearchResult{
+					Entry:   output.NewEntry(h.Entry),
+					Snippet: strings.Join(strings.Fields(h.Snippet), " "),
+					Score:   h.Score,
+				})
+			}
+			return writeOutput(format, output.NewSearch(raw, results))
+		}
+		if len(hits) == 0 {
+			fmt.Println("(no matches)")
+			return nil
+		}
+		printSearchHits(hits)
+		return nil
+	},
+}
+
+func printSearchHits(hits []store.SearchHit) {
+	for i, h := range hits {
+		if i > 0 {
+			fmt.Println()
+		}
+		printEvent(h.Entry)
+		if snippet := strings.Join(strings.Fields(h.Snippet), " "); snippet != "" {
+			fmt.Printf("    %s\n", snippet)
+		}
+	}
+}
+
+func init() {
+	searchCmd.Flags().BoolVar(&searchAll, "all", false, "search entries from all projects")
+	searchCmd.Flags().StringVar(&searchProject, "project", "", "override project scope (ignores active project)")
+	searchCmd.Flags().IntVar(&searchLimit, "limit", 20, "maximum number of results (0 for all)")
+	searchCmd.Flags().BoolVar(&searchIncludeRetracted, "include-retracted", false, "include retracted entries (marked)")
+	rootCmd.AddCommand(searchCmd)
+}
@@ -2,33 +2,61 @@ package cli
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/divijg19/sage/internal/event"
+	"github.com/divijg19/sage/internal/output"
+	"github.com/divijg19/sage/internal/query"
+	"github.com/divijg19/sage/internal/state"
+	"github.com/divijg19/sage/internal/store"
+	"github.com/divijg19/sage/internal/timeexpr"
 )
 
 var stateAt string
 var stateTags []string
 var stateAll bool
 var stateProject string
+var stateIncludeRetracted bool
+var stateQuery string
+var stateFrom string
+var stateTo string
 
 var stateCmd = &cobra.Command{
 	Use:   "state",
 	Short: "Reconstruct state at a point in time",
-	Long: "Replays the event log up to a given timestamp and prints a concise view\n" +
-		"of decisions and contextual records.\n\n" +
-		"Use --at with RFC3339, local datetime (YYYY-MM-DDTHH:MM), or date-only (YYYY-MM-DD).",
+	Long: "Replays the event log up to a given timestamp and prints what stood then:\n" +
+		"decisions grouped as active, superseded or reverted (each with its most recent\n" +
+		"related context), context records, and commits per project and branch.\n\n" +
+		"Use --at with RFC3339, local datetime (YYYY-MM-DDTHH:MM), date-only (YYYY-MM-DD),\n" +
+		"or a relative expression such as yesterday, \"last friday 17:00\" or 3d.ago\n" +
+		"(see `sage help time`). A day or week means its start.\n\n" +
+		"With --from (and optionally --to, default now) it instead lists what changed in\n" +
+		"between: decisions added, superseded or retracted, tag changes, and new context\n" +
+		"records. A day or week given to --to is included whole. Project, tag and query\n" +
+		"scope apply to either end, so an entry that gained a filtered tag is listed.",
 	Example: "  sage state --at 2026-01-09\n" +
 		"  sage state --at 2026-01-09T21:30\n" +
-		"  sage state --at 2026-01-09T23:59:59+05:30",
+		"  sage state --at 2026-01-09T23:59:59+05:30\n" +
+		"  sage state --at \"last friday 17:00\"\n" +
+		"  sage state --at 2026-01-09 -q 'tag:auth -tag:wip'\n" +
+		"  sage state --from \"last monday\" --to yesterday --tags auth",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if stateFrom != "" || stateTo != "" {
+			return runStateDiff(cmd.Context())
+		}
+		if stateAt == "" {
+			return fmt.Errorf("either --at or --from is required")
+		}
+
 		// 1. Parse timestamp
 		t, err := parseTime(stateAt)
 		if err != nil {
-			return fmt.Errorf("invalid time format, use RFC3339 or YYYY-MM-DD")
+			return invalidTimeFlag("at", stateAt)
 		}
 
 		// 2. Open global store
@@ -37,87 +65,113 @@ var stateCmd = &cobra.Command{
 			return err
 		}
 
-		// 3. Load events up to time (optionally project-scoped)
-		project, filter := resolveProjectFilter(stateProject, stateAll)
-		var events []event.Event
-		if filter {
-			events, err = s.ListUntilByProject(t, project)
-		} else {
-			events, err = s.ListUntil(t)
+		// 3. Load everything up to time within the project scope, retracted
+		// entries included: they still count as having been retracted by
+		// then (Build drops them unless asked), and the other filters apply
+		// to each entry as it read then.
+		q, err := scopedQuery(stateQuery, stateTags, stateProject, stateAll)
+		if err != nil {
+			return err
 		}
+		events, err := store.Collect(s.IncludingRetracted().Query(cmd.Context(), store.Filter{AsOf: t, Match: q.Only(query.FieldProject)}))
 		if err != nil {
 			return err
 		}
-
-		// Optional tag filter.
-		want := parseTags(stateTags)
-		if len(want) > 0 {
-			filtered := make([]event.Event, 0, len(events))
-			for _, e := range events {
-				if eventHasAnyTag(e, want) {
-					filtered = append(filtered, e)
-				}
-			}
-			events = filtered
+		links, err := s.LinksUntil(t)
+		if err != nil {
+			return err
 		}
 
-		// 5. Replay & print
-		replayState(events, t)
+		// 4. Project & print
+		st := state.Build(t, events, links, state.Options{Match: q, IncludeRetracted: stateIncludeRetracted})
+		format, err := outputFormat()
+		if err != nil {
+			return err
+		}
+		if format.Structured() {
+			return writeOutput(format, output.NewState(st))
+		}
+		printState(os.Stdout, st)
 		return nil
 	},
 }
 
-func replayState(events []event.Event, at time.Time) {
-	fmt.Printf("State at %s\n\n", at.Format(time.RFC3339))
+func printState(w io.Writer, st state.State) {
+	fmt.Fprintf(w, "State at %s\n", st.At.Format(time.RFC3339))
 
-	fmt.Println("Decisions:")
-	for _, e := range events {
-		if e.Kind == event.DecisionKind {
-			title := strings.TrimSpace(e.Title)
-			if title == "" {
-				title = "(untitled)"
+	for _, status := range state.Statuses() {
+		decisions := st.WithStatus(status)
+		if status != state.Active && len(decisions) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s decisions:\n", titleCase(string(status)))
+		for _, d := range decisions {
+			suffix := ""
+			if d.SupersededBy != nil {
+				suffix = fmt.Sprintf(" (superseded by [%d])", d.SupersededBy.Seq)
+			}
+			fmt.Fprintf(w, "- [%d] %s%s\n", d.Entry.Seq, stateTitle(d.Entry), suffix)
+			if d.Context != nil {
+				fmt.Fprintf(w, "    context: [%d] %s\n", d.Context.Seq, stateTitle(*d.Context))
 			}
-			fmt.Printf("- [%d] %s\n", e.Seq, title)
 		}
 	}
 
-	fmt.Println("\nContext:")
-	for _, e := range events {
-		if e.Kind == event.RecordKind {
-			title := strings.TrimSpace(e.Title)
-			if title == "" {
-				title = "(untitled)"
-			}
-			fmt.Printf("- [%d] %s\n", e.Seq, title)
+	fmt.Fprintln(w, "\nContext:")
+	for _, e := range st.Context {
+		fmt.Fprintf(w, "- [%d] %s\n", e.Seq, stateTitle(e))
+	}
+
+	if len(st.Commits) > 0 {
+		fmt.Fprintln(w, "\nCommits:")
+		for _, g := range st.Commits {
+			fmt.Fprintf(w, "- %s %s: %s, latest [%d] %s\n", commitGroupProject(g.Project), commitGroupBranch(g.Branch), pluralCommits(g.Count), g.Latest.Seq, stateTitle(g.Latest))
 		}
 	}
 }
 
-func parseTime(input string) (time.Time, error) {
-	// 1. Full RFC3339
-	if t, err := time.Parse(time.RFC3339, input); err == nil {
-		return t, nil
+func commitGroupProject(project string) string {
+	if strings.TrimSpace(project) == "" {
+		return "global"
+	}
+	return project
+}
+
+func commitGroupBranch(branch string) string {
+	if strings.TrimSpace(branch) == "" {
+		return "(no branch)"
 	}
+	return branch
+}
 
-	// 2. Local datetime
-	if t, err := time.ParseInLocation(
-		"2006-01-02T15:04",
-		input,
-		time.Local,
-	); err == nil {
-		return t, nil
+func pluralCommits(n int) string {
+	if n == 1 {
+		return "1 commit"
 	}
+	return fmt.Sprintf("%d commits", n)
+}
 
-	// 3. Date only
-	if t, err := time.ParseInLocation(
-		"2006-01-02",
-		input,
-		time.Local,
-	); err == nil {
-		return t, nil
+func stateTitle(e event.Event) string {
+	title := strings.TrimSpace(e.Title)
+	if title == "" {
+		title = "(untitled)"
 	}
+	if e.Retracted {
+		title = retractedMarker + " " + title
+	}
+	return title
+}
+
+// parseTime reads every --at/--since/--until value and after:/before: term
+// (see `sage help time`). Calendar expressions such as "yesterday" resolve
+// to the start of the period.
+func parseTime(input string) (time.Time, error) {
+	return timeexpr.Parse(input)
+}
 
-	return time.Time{}, fmt.Errorf("invalid time format")
+// invalidTimeFlag reports an unparseable time given to --name.
+func invalidTimeFlag(name, value string) error {
+	return fmt.Errorf("invalid --%s %q (see: sage help time)", name, value)
 }
 
 func init() {
@@ -125,11 +179,16 @@ func init() {
 		&stateAt,
 		"at",
 		"",
-		"timestamp (RFC3339 or YYYY-MM-DD)",
+		"timestamp (RFC3339, YYYY-MM-DD, or e.g. \"yesterday 17:00\"; see: sage help time)",
 	)
 	stateCmd.Flags().StringArrayVar(&stateTags, "tags", nil, "filter replay by tags (repeatable or comma-separated)")
 	stateCmd.Flags().BoolVar(&stateAll, "all", false, "show entries from all projects")
 	stateCmd.Flags().StringVar(&stateProject, "project", "", "override project scope (ignores active project)")
-	stateCmd.MarkFlagRequired("at")
+	stateCmd.Flags().StringVarP(&stateQuery, "query", "q", "", "filter replay with the query syntax (see: sage help query)")
+	stateCmd.Flags().BoolVar(&stateIncludeRetracted, "include-retracted", false, "include retracted entries (marked)")
+	stateCmd.Flags().StringVar(&stateFrom, "from", "", "list changes since this time instead of replaying (see: sage help time)")
+	stateCmd.Flags().StringVar(&stateTo, "to", "", "end of the --from window (default now; a named day is included whole)")
+	stateCmd.MarkFlagsMutuallyExclusive("at", "from")
+	stateCmd.MarkFlagsMutuallyExclusive("at", "to")
 	rootCmd.AddCommand(stateCmd)
 }
@@ -0,0 +1,174 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/divijg19/sage/internal/event"
+)
+
+func resetStateFlags(t *testing.T) {
+	t.Helper()
+	reset := func() {
+		stateAt, stateTags, stateAll, stateProject, stateIncludeRetracted, stateQuery = "", nil, false, "", false, ""
+		stateFrom, stateTo, outputFormatFlag = "", "", "text"
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+// seedStateHistory writes a small history for project "api" (and one entry
+// in "web") spanning 2026-01-01 to 2026-01-12, UTC.
+func seedStateHistory(t *testing.T) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("SAGE_PROJECT", "")
+
+	s, err := openGlobalStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := func(d int) time.Time { return time.Date(2026, 1, d, 9, 0, 0, 0, time.UTC) }
+	entry := func(id string, d int, project string, kind event.EntryKind, title string, tags ...string) event.Event {
+		return event.Event{ID: id, Timestamp: day(d), Project: project, Kind: kind, Title: title, Content: "x", Tags: tags}
+	}
+	at := func(e event.Event, d int) event.Event {
+		e.Timestamp = day(d)
+		return e
+	}
+
+	sessions := entry("sessions", 1, "api", event.DecisionKind, "Use sessions", "auth")
+	notes := entry("notes", 1, "api", event.RecordKind, "Old notes")
+	cookies := entry("cookies", 1, "api", event.DecisionKind, "Drop cookies")
+	jwt := entry("jwt", 6, "api", event.DecisionKind, "Use JWT", "auth")
+	events := []event.Event{
+		sessions, notes, cookies, jwt,
+		event.NewLinkEvent("l1", day(6), jwt, event.Supersedes, sessions),
+		entry("web", 6, "web", event.DecisionKind, "Web only"),
+		at(newTagEvent(notes, event.TagOpAdd, []string{"backend"}), 7),
+		at(newRetractEvent(cookies, "wrong"), 7),
+		entry("rollout", 8, "api", event.RecordKind, "Rollout plan"),
+		entry("later", 12, "api", event.DecisionKind, "Too late"),
+	}
+	rollout := events[8]
+	events = append(events, event.NewLinkEvent("l2", day(8), rollout, event.References, jwt))
+	for _, e := range events {
+		if err := s.Append(e); err != nil {
+			t.Fatalf("Append %s: %v", e.ID, err)
+		}
+	}
+}
+
+func TestState_FromToListsChanges(t *testing.T) {
+	resetStateFlags(t)
+	seedStateHistory(t)
+	stateCmd.SetContext(context.Background())
+	run := func() error { return stateCmd.RunE(stateCmd, nil) }
+
+	stateFrom, stateTo, stateProject = "2026-01-05", "2026-01-10T00:00:00Z", "api"
+	got := captureStdout(t, run)
+	for _, want := range []string{
+		"Decisions added:\n- [4] Use JWT\n",
+		"Decisions superseded:\n- [1] Use sessions (superseded by [4])\n",
+		"Decisions retracted:\n- [3] [retracted] Drop cookies\n",
+		"Tags changed:\n- [2] Old notes +backend\n",
+		"New context:\n- [9] Rollout plan\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("missing %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "Web only") || strings.Contains(got, "Too late") {
+		t.Fatalf("out-of-scope entries listed:\n%s", got)
+	}
+
+	// Tag scope keeps entries that match at either end.
+	stateTags = []string{"backend"}
+	got = captureStdout(t, run)
+	if !strings.Contains(got, "- [2] Old notes +backend") || strings.Contains(got, "Use JWT") {
+		t.Fatalf("unexpected tag-scoped diff:\n%s", got)
+	}
+
+	stateTags, outputFormatFlag = nil, "json"
+	var doc struct {
+		Type      string `json:"type"`
+		From      string `json:"from"`
+		Decisions struct {
+			Added      []map[string]any `json:"added"`
+			Superseded []map[string]any `json:"superseded"`
+			Retracted  []map[string]any `json:"retracted"`
+		} `json:"decisions"`
+		Tags []struct {
+			Entry   map[string]any `json:"entry"`
+			Added   []string       `json:"added"`
+			Removed []string       `json:"removed"`
+		} `json:"tags"`
+		Context []map[string]any `json:"context"`
+	}
+	raw := captureStdout(t, run)
+	if err := json.Unmarshal([]byte(raw), &doc); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, raw)
+	}
+	if doc.Type != "state_diff" || len(doc.Decisions.Added) != 1 || doc.Decisions.Superseded[0]["superseded_by"] != float64(4) {
+		t.Fatalf("unexpected document: %s", raw)
+	}
+	if len(doc.Decisions.Retracted) != 1 || len(doc.Tags) != 1 || doc.Tags[0].Added[0] != "backend" || doc.Tags[0].Removed == nil || len(doc.Context) != 1 {
+		t.Fatalf("unexpected document: %s", raw)
+	}
+}
+
+func TestState_FromToEmptyWindowAndErrors(t *testing.T) {
+	resetStateFlags(t)
+	seedStateHistory(t)
+	stateCmd.SetContext(context.Background())
+
+	stateFrom, stateTo, stateAll = "2026-01-02", "2026-01-03", true
+	if got := captureStdout(t, func() error { return stateCmd.RunE(stateCmd, nil) }); !strings.Contains(got, "No changes.") {
+		t.Fatalf("expected no changes, got:\n%s", got)
+	}
+
+	for _, tt := range []struct {
+		from, to, want string
+	}{
+		{"", "2026-01-03", "--to needs --from"},
+		{"2026-01-05", "2026-01-01", "--to is before --from"},
+		{"soon", "", `invalid --from "soon"`},
+	} {
+		stateFrom, stateTo = tt.from, tt.to
+		err := stateCmd.RunE(stateCmd, nil)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("from=%q to=%q: expected %q, got %v", tt.from, tt.to, tt.want, err)
+		}
+	}
+
+	stateFrom, stateTo = "", ""
+	if err := stateCmd.RunE(stateCmd, nil); err == nil || !strings.Contains(err.Error(), "--at or --from") {
+		t.Fatalf("expected a missing --at error, got %v", err)
+	}
+}
+
+func TestState_AtGroupsDecisionsByStatus(t *testing.T) {
+	resetStateFlags(t)
+	seedStateHistory(t)
+	stateCmd.SetContext(context.Background())
+
+	stateAt, stateProject = "2026-01-10", "api"
+	run := func() error { return stateCmd.RunE(stateCmd, nil) }
+	got := captureStdout(t, run)
+	want := "\nActive decisions:\n- [4] Use JWT\n    context: [9] Rollout plan\n" +
+		"\nSuperseded decisions:\n- [1] Use sessions (superseded by [4])\n" +
+		"\nContext:\n- [2] Old notes\n- [9] Rollout plan\n"
+	if !strings.HasSuffix(got, want) || strings.Contains(got, "Drop cookies") {
+		t.Fatalf("unexpected state:\n%s\nwant suffix:\n%s", got, want)
+	}
+
+	// Retracted decisions are only listed, as reverted, when asked for.
+	stateIncludeRetracted = true
+	got = captureStdout(t, run)
+	if want := "\nReverted decisions:\n- [3] [retracted] Drop cookies\n"; !strings.Contains(got, want) {
+		t.Fatalf("expected %q with --include-retracted, got:\n%s", want, got)
+	}
+}
@@ -1,13 +1,19 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"iter"
 	"os"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/divijg19/sage/internal/event"
+	"github.com/divijg19/sage/internal/output"
+	"github.com/divijg19/sage/internal/store"
+	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 )
 
@@ -18,32 +24,41 @@ var tagCmd = &cobra.Command{
 		"Forms:\n" +
 		"  sage tag                 List configured tags with counts (scoped by active project)\n" +
 		"  sage tag \"name\"          List entries with tag (scoped by active project)\n" +
-		"  sage tag <id> \"name\"     Apply tag(s) to an entry (comma-separated supported)",
+		"  sage tag <id> \"name\"     Apply tag(s) to an entry (comma-separated supported)\n\n" +
+		"Tagging never rewrites an entry: each change is appended as its own tag event,\n" +
+		"so `sage state --at` shows the tags an entry had at that time.\n" +
+		"Use --remove to untag.",
 	Args: cobra.ArbitraryArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		s, err := openGlobalStore()
 		if err != nil {
 			return err
 		}
+		if tagIncludeRetracted {
+			s = s.IncludingRetracted()
+		}
 
 		switch len(args) {
 		case 0:
-			return runTagList(s)
+			return runTagList(cmd.Context(), s)
 		case 1:
 			name := strings.TrimSpace(args[0])
 			if name == "" {
-				return runTagList(s)
+				return runTagList(cmd.Context(), s)
 			}
 			if isDigitsOnly(name) {
 				return fmt.Errorf("to tag an entry, use: sage tag <id> \"name\"")
 			}
 			name = strings.TrimPrefix(name, "#")
-			return runTagShow(s, name)
+			return runTagShow(cmd.Context(), s, name)
 		case 2:
 			id, err := strconv.ParseInt(strings.TrimSpace(args[0]), 10, 64)
 			if err != nil || id <= 0 {
 				return fmt.Errorf("invalid entry id: %s", args[0])
 			}
+			if tagRemove {
+				return runTagRemove(s, id, args[1])
+			}
 			return runTagApply(s, id, args[1])
 		default:
 			return fmt.Errorf("usage: sage tag | sage tag \"name\" | sage tag <id> \"name\"")
@@ -53,22 +68,25 @@ var tagCmd = &cobra.Command{
 
 var tagAll bool
 var tagProject string
+var tagRemove bool
+var tagIncludeRetracted bool
+var tagQuery string
 
 func init() {
 	tagCmd.Flags().BoolVar(&tagAll, "all", false, "show entries from all projects")
 	tagCmd.Flags().StringVar(&tagProject, "project", "", "override project scope (ignores active project)")
+	tagCmd.Flags().BoolVar(&tagIncludeRetracted, "include-retracted", false, "count and show retracted entries (marked)")
+	tagCmd.Flags().StringVarP(&tagQuery, "query", "q", "", "only count and list entries matching this query (see: sage help query)")
+	tagCmd.Flags().BoolVar(&tagRemove, "remove", false, "remove tag(s) from an entry instead of applying them")
 	rootCmd.AddCommand(tagCmd)
 }
 
-func runTagList(s storeLike) error {
-	project, filter := resolveProjectFilter(tagProject, tagAll)
-	var events []event.Event
-	var err error
-	if filter {
-		events, err = s.ListByProject(project)
-	} else {
-		events, err = s.List()
+func runTagList(ctx context.Context, s storeLike) error {
+	q, err := scopedQuery(tagQuery, nil, tagProject, tagAll)
+	if err != nil {
+		return err
 	}
+	events, err := store.Collect(s.Query(ctx, store.Filter{Match: q}))
 	if err != nil {
 		return err
 	}
@@ -106,6 +124,18 @@ func runTagList(s storeLike) error {
 		return err
 	}
 
+	format, err := outputFormat()
+	if err != nil {
+		return err
+	}
+	if format.Structured() {
+		list := make([]output.TagCount, 0, len(configured))
+		for _, t := range configured {
+			list = append(list, output.TagCount{Name: t, Count: counts[t]})
+		}
+		return writeOutput(format, output.NewTags(list))
+	}
+
 	fmt.Println("Tags:")
 	if len(configured) == 0 {
 		fmt.Println("(none)")
@@ -139,32 +169,37 @@ func runTagList(s storeLike) error {
 	return nil
 }
 
-func runTagShow(s storeLike, name string) error {
+func runTagShow(ctx context.Context, s storeLike, name string) error {
 	tags := parseTags([]string{name})
 	if len(tags) == 0 {
 		return fmt.Errorf("invalid tag")
 	}
 	want := tags[0]
 
-	project, filter := resolveProjectFilter(tagProject, tagAll)
-	var events []event.Event
-	var err error
-	if filter {
-		events, err = s.ListByProject(project)
-	} else {
-		events, err = s.List()
+	q, err := scopedQuery(tagQuery, []string{want}, tagProject, tagAll)
+	if err != nil {
+		return err
 	}
+	format, err := outputFormat()
 	if err != nil {
 		return err
 	}
+	if format.Structured() {
+		events, err := store.Collect(s.Query(ctx, store.Filter{Match: q}))
+		if err != nil {
+			return err
+		}
+		return writeOutput(format, output.NewEntries(events))
+	}
 
 	fmt.Printf("Entries tagged '%s':\n", want)
 	found := false
-	for _, e := range events {
-		if eventHasAnyTag(e, []string{want}) {
-			printEvent(e)
-			found = true
+	for e, err := range s.Query(ctx, store.Filter{Match: q}) {
+		if err != nil {
+			return err
 		}
+		printEvent(e)
+		found = true
 	}
 	if !found {
 		fmt.Println("(none)")
@@ -178,25 +213,22 @@ func runTagApply(s storeTagger, id int64, rawName string) error {
 		return fmt.Errorf("invalid tag")
 	}
 
-	e, err := s.GetBySeq(id)
+	e, err := taggableEntry(s, id)
 	if err != nil {
 		return err
 	}
-	if e == nil {
-		return fmt.Errorf("no entry with id %d", id)
-	}
 
 	current := parseTags(e.Tags)
 	set := make(map[string]struct{}, len(current))
-	merged := append([]string(nil), current...)
 	for _, t := range current {
 		set[t] = struct{}{}
 	}
+	var added []string
 	for _, t := range tags {
 		if _, ok := set[t]; ok {
 			continue
 		}
-		merged = append(merged, t)
+		added = append(added, t)
 		set[t] = struct{}{}
 	}
 
@@ -204,17 +236,90 @@ func runTagApply(s storeTagger, id int64, rawName string) error {
 	outTags := append([]string(nil), tags...)
 	sort.Strings(outTags)
 
-	if err := ensureTagsConfigured(merged); err != nil {
+	if err := ensureTagsConfigured(tags); err != nil {
 		return err
 	}
-	if err := s.UpdateTagsBySeq(id, merged); err != nil {
-		return err
+	if len(added) > 0 {
+		if err := s.Append(newTagEvent(*e, event.TagOpAdd, added)); err != nil {
+			return err
+		}
 	}
 
 	fmt.Printf("Tagged entry %d with %s\n", id, formatTags(outTags))
 	return nil
 }
 
+func runTagRemove(s storeTagger, id int64, rawName string) error {
+	tags := parseTags([]string{rawName})
+	if len(tags) == 0 {
+		return fmt.Errorf("invalid tag")
+	}
+
+	e, err := taggableEntry(s, id)
+	if err != nil {
+		return err
+	}
+
+	current := make(map[string]struct{}, len(e.Tags))
+	for _, t := range parseTags(e.Tags) {
+		current[t] = struct{}{}
+	}
+	var removed []string
+	for _, t := range tags {
+		if _, ok := current[t]; ok {
+			removed = append(removed, t)
+		}
+	}
+	if len(removed) == 0 {
+		fmt.Printf("Entry %d has none of %s\n", id, formatTags(tags))
+		return nil
+	}
+
+	if err := s.Append(newTagEvent(*e, event.TagOpRemove, removed)); err != nil {
+		return err
+	}
+
+	sort.Strings(removed)
+	fmt.Printf("Removed %s from entry %d\n", formatTags(removed), id)
+	return nil
+}
+
+// taggableEntry returns the entry with the given seq if its tags can change:
+// annotations are not entries, and retracted entries are frozen.
+func taggableEntry(s storeTagger, id int64) (*event.Event, error) {
+	e, err := s.GetBySeq(id)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, fmt.Errorf("no entry with id %d", id)
+	}
+	if e.Kind.IsAnnotation() {
+		return nil, fmt.Errorf("entry %d is a %s event and cannot be tagged", id, e.Kind)
+	}
+	if e.Retracted {
+		return nil, fmt.Errorf("entry %d is retracted", id)
+	}
+	return e, nil
+}
+
+// newTagEvent builds the append-only event recording a tag change on target.
+// It shares the target's project so project-scoped replays still see it.
+func newTagEvent(target event.Event, op string, tags []string) event.Event {
+	return event.Event{
+		ID:        uuid.NewString(),
+		Timestamp: time.Now(),
+		Project:   target.Project,
+		Kind:      event.TagKind,
+		Title:     fmt.Sprintf("%s %s", op, formatTags(tags)),
+		Tags:      append([]string(nil), tags...),
+		Metadata: map[string]string{
+			event.MetaTarget: target.ID,
+			event.MetaOp:     op,
+		},
+	}
+}
+
 func formatTags(tags []string) string {
 	if len(tags) == 0 {
 		return "(none)"
@@ -247,16 +352,15 @@ func stdinIsTTY() bool {
 	return (fi.Mode() & os.ModeCharDevice) != 0
 }
 
-// Small interfaces to keep cmd_tag testable and avoid importing store in this file.
+// Small interfaces to keep cmd_tag testable.
 // In practice we pass *store.Store.
 
 type storeLike interface {
-	List() ([]event.Event, error)
-	ListByProject(project string) ([]event.Event, error)
+	Query(ctx context.Context, f store.Filter) iter.Seq2[event.Event, error]
 }
 
 type storeTagger interface {
 	storeLike
 	GetBySeq(seq int64) (*event.Event, error)
-	UpdateTagsBySeq(seq int64, tags []string) error
+	Append(e event.Event) error
 }
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/divijg19/sage/internal/event"
+)
+
+func TestRunTagApplyAndRemove_AppendsTagEvents(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	s, err := openGlobalStore()
+	if err != nil {
+		t.Fatalf("openGlobalStore: %v", err)
+	}
+	if err := s.Append(event.Event{
+		ID:        "evt-1",
+		Timestamp: time.Date(2026, 4, 24, 9, 0, 0, 0, time.UTC),
+		Project:   "alpha",
+		Kind:      event.RecordKind,
+		Title:     "Investigate auth cache",
+		Content:   "Added context",
+		Tags:      []string{"auth"},
+	}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	if err := runTagApply(s, 1, "backend,auth"); err != nil {
+		t.Fatalf("runTagApply: %v", err)
+	}
+	if err := runTagRemove(s, 1, "auth"); err != nil {
+		t.Fatalf("runTagRemove: %v", err)
+	}
+
+	got, err := s.GetBySeq(1)
+	if err != nil {
+		t.Fatalf("GetBySeq: %v", err)
+	}
+	if strings.Join(got.Tags, ",") != "backend" {
+		t.Fatalf("expected folded tags [backend], got %v", got.Tags)
+	}
+
+	n, err := s.Count()
+	if err != nil {
+		t.Fatalf("Count: %v", err)
+	}
+	if n != 3 {
+		t.Fatalf("expected entry + 2 tag events, got %d rows", n)
+	}
+
+	annotations, err := s.Annotations("evt-1")
+	if err != nil {
+		t.Fatalf("Annotations: %v", err)
+	}
+	if len(annotations) != 2 || annotations[0].Project != "alpha" || annotations[1].Metadata[event.MetaOp] != event.TagOpRemove {
+		t.Fatalf("unexpected tag events: %+v", annotations)
+	}
+}
+
+func TestRunTagApplyAndRemove_RejectAnnotationsAndRetracted(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	s, err := openGlobalStore()
+	if err != nil {
+		t.Fatalf("openGlobalStore: %v", err)
+	}
+	entry := event.Event{
+		ID:        "evt-1",
+		Timestamp: time.Date(2026, 4, 24, 9, 0, 0, 0, time.UTC),
+		Project:   "alpha",
+		Kind:      event.RecordKind,
+		Title:     "Investigate auth cache",
+		Tags:      []string{"auth"},
+	}
+	if err := s.Append(entry); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if err := runTagApply(s, 1, "backend"); err != nil {
+		t.Fatalf("runTagApply: %v", err)
+	}
+
+	// Seq 2 is the tag event itself, not an entry.
+	for name, run := range map[string]func(storeTagger, int64, string) error{"apply": runTagApply, "remove": runTagRemove} {
+		if err := run(s, 2, "backend"); err == nil || !strings.Contains(err.Error(), "is a tag event") {
+			t.Fatalf("%s on an annotation: expected a refusal, got %v", name, err)
+		}
+	}
+
+	if err := s.Append(newRetractEvent(entry, "")); err != nil {
+		t.Fatalf("Append retraction: %v", err)
+	}
+	if err := runTagRemove(s, 1, "auth"); err == nil || !strings.Contains(err.Error(), "is retracted") {
+		t.Fatalf("expected removing a tag from a retracted entry to fail, got %v", err)
+	}
+	if n, err := s.Count(); err != nil || n != 3 {
+		t.Fatalf("expected no further events, got %d rows (%v)", n, err)
+	}
+}
@@ -2,25 +2,55 @@ package cli
 
 import (
 	"fmt"
+	"io"
+	"iter"
+	"os"
+	"slices"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/divijg19/sage/internal/event"
+	"github.com/divijg19/sage/internal/output"
+	"github.com/divijg19/sage/internal/query"
+	"github.com/divijg19/sage/internal/store"
+	"github.com/divijg19/sage/internal/timeexpr"
 	"github.com/spf13/cobra"
 )
 
 var timelineTags []string
 var timelineAll bool
 var timelineProject string
+var timelineIncludeRetracted bool
+var timelineQuery string
+var timelineSince string
+var timelineUntil string
+var timelineDay string
+var timelineKinds []string
+var timelineFiles []string
+var timelineLimit int
+var timelineReverse bool
 
 var timelineCmd = &cobra.Command{
 	Use:   "timeline",
 	Short: "Show chronological history of entries",
 	Long: "Print a clean chronological view of entries from your global Sage log.\n" +
-		"Output is intentionally summary-only (timestamp, kind, title) to avoid noisy content.",
+		"Output is intentionally summary-only (timestamp, kind, title) to avoid noisy content.\n\n" +
+		"--since, --until and --day take the same times as `sage state --at` (see\n" +
+		"`sage help time`). --until includes a named day, week or month whole.\n" +
+		"--limit keeps the most recent entries.\n" +
+		"--file keeps commits that changed a path (a directory covers the files under it),\n" +
+		"given relative to the repository root.\n" +
+		"Long output is shown through $PAGER (less by default) when writing to a terminal.",
 	Example: "  sage timeline\n" +
 		"  sage timeline --tags auth\n" +
-		"  sage timeline --tags auth,backend",
+		"  sage timeline --tags auth,backend\n" +
+		"  sage timeline --since 2026-01-01 --until 2026-01-31 --kind decision\n" +
+		"  sage timeline --day yesterday\n" +
+		"  sage timeline --since \"start of week\" --kind d\n" +
+		"  sage timeline --limit 10 --reverse\n" +
+		"  sage timeline --file internal/store/store.go\n" +
+		"  sage timeline -q 'kind:decision -tag:wip after:2026-01-01'",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// 1. Open global store
 		s, err := openGlobalStore()
@@ -28,32 +58,154 @@ var timelineCmd = &cobra.Command{
 			return err
 		}
 
-		// 2. Read all events
-		project, filter := resolveProjectFilter(timelineProject, timelineAll)
-		var events []event.Event
-		if filter {
-			events, err = s.ListByProject(project)
-		} else {
-			events, err = s.List()
+		if timelineIncludeRetracted {
+			s = s.IncludingRetracted()
 		}
+
+		// 2. Build the filter; every flag narrows the SQL query
+		q, err := scopedQuery(timelineQuery, timelineTags, timelineProject, timelineAll)
+		if err != nil {
+			return err
+		}
+		filter, err := timelineFilter(q)
+		if err != nil {
+			return err
+		}
+		format, err := outputFormat()
 		if err != nil {
 			return err
 		}
 
-		// 3. Print events
-		want := parseTags(timelineTags)
-		for _, e := range events {
-			if len(want) > 0 && !eventHasAnyTag(e, want) {
-				continue
+		// 3. Read matching entries. --limit keeps the most recent ones, so
+		// oldest-first output reads them newest first and flips them back.
+		results := s.Query(cmd.Context(), filter)
+		if filter.Limit > 0 && !timelineReverse {
+			events, err := store.Collect(results)
+			if err != nil {
+				return err
 			}
-			printEvent(e)
+			slices.Reverse(events)
+			results = eventSeq(events)
 		}
 
-		return nil
+		// 4. Print
+		switch format {
+		case output.FormatJSON, output.FormatYAML:
+			events, err := store.Collect(results)
+			if err != nil {
+				return err
+			}
+			return writeOutput(format, output.NewEntries(events))
+		case output.FormatJSONL:
+			for e, err := range results {
+				if err != nil {
+					return err
+				}
+				if err := output.WriteLine(os.Stdout, output.NewEntry(e)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		return pageOutput(func(w io.Writer) error {
+			for e, err := range results {
+				if err != nil {
+					return err
+				}
+				fprintEvent(w, e)
+			}
+			return nil
+		})
 	},
 }
 
+// timelineFilter turns the timeline flags into a store filter around q.
+func timelineFilter(q query.Query) (store.Filter, error) {
+	filter := store.Filter{Match: q}
+
+	if timelineDay != "" {
+		if timelineSince != "" || timelineUntil != "" {
+			return store.Filter{}, fmt.Errorf("--day cannot be combined with --since or --until")
+		}
+		t, err := parseTime(timelineDay)
+		if err != nil {
+			return store.Filter{}, invalidTimeFlag("day", timelineDay)
+		}
+		filter.After = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		filter.Before = filter.After.AddDate(0, 0, 1)
+	}
+	if timelineSince != "" {
+		t, err := parseTime(timelineSince)
+		if err != nil {
+			return store.Filter{}, invalidTimeFlag("since", timelineSince)
+		}
+		filter.After = t
+	}
+	if timelineUntil != "" {
+		t, err := untilBound(timelineUntil)
+		if err != nil {
+			return store.Filter{}, invalidTimeFlag("until", timelineUntil)
+		}
+		filter.Before = t
+	}
+
+	for _, raw := range timelineKinds {
+		for _, name := range strings.Split(raw, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			kind, ok := query.ParseKind(name)
+			if !ok {
+				return store.Filter{}, fmt.Errorf("unknown --kind %q (use record, decision, commit or git)", name)
+			}
+			filter.Kinds = append(filter.Kinds, kind)
+		}
+	}
+
+	filter.Paths = timelineFiles
+
+	if timelineLimit < 0 {
+		return store.Filter{}, fmt.Errorf("--limit must not be negative")
+	}
+	filter.Limit = timelineLimit
+	if timelineReverse || timelineLimit > 0 {
+		filter.Order = store.Descending
+	}
+	return filter, nil
+}
+
+// untilBound returns the exclusive upper bound for an inclusive --until:
+// a calendar period (2026-01-09, yesterday, last week) is included whole,
+// anything else up to that exact instant.
+func untilBound(raw string) (time.Time, error) {
+	span, err := timeexpr.ParseSpan(raw)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if span.IsInstant() {
+		return span.Start.Add(time.Nanosecond), nil
+	}
+	return span.End, nil
+}
+
+// eventSeq streams an already loaded slice like a store query.
+func eventSeq(events []event.Event) iter.Seq2[event.Event, error] {
+	return func(yield func(event.Event, error) bool) {
+		for _, e := range events {
+			if !yield(e, nil) {
+				return
+			}
+		}
+	}
+}
+
 func printEvent(e event.Event) {
+	fprintEvent(os.Stdout, e)
+}
+
+func fprintEvent(w io.Writer, e event.Event) {
 	ts := e.Timestamp.Format("2006-01-02 15:04")
 	kind := string(e.Kind)
 	if kind == "" {
@@ -63,6 +215,9 @@ func printEvent(e event.Event) {
 	if title == "" {
 		title = "(untitled)"
 	}
+	if e.Retracted {
+		title = retractedMarker + " " + title
+	}
 
 	tagSuffix := ""
 	if len(e.Tags) > 0 {
@@ -74,33 +229,21 @@ func printEvent(e event.Event) {
 		tagSuffix = " " + strings.Join(copyTags, " ")
 	}
 
-	fmt.Printf("[%d] [%s] %-8s %s%s\n", e.Seq, ts, kind, title, tagSuffix)
+	fmt.Fprintf(w, "[%d] [%s] %-8s %s%s\n", e.Seq, ts, kind, title, tagSuffix)
 }
 
 func init() {
 	timelineCmd.Flags().StringArrayVar(&timelineTags, "tags", nil, "filter by tags (repeatable or comma-separated)")
 	timelineCmd.Flags().BoolVar(&timelineAll, "all", false, "show entries from all projects")
 	timelineCmd.Flags().StringVar(&timelineProject, "project", "", "override project scope (ignores active project)")
+	timelineCmd.Flags().StringVarP(&timelineQuery, "query", "q", "", "filter with the query syntax (see: sage help query)")
+	timelineCmd.Flags().StringVar(&timelineSince, "since", "", "only entries recorded at or after this time")
+	timelineCmd.Flags().StringVar(&timelineUntil, "until", "", "only entries recorded up to this time (a named day or week is included whole)")
+	timelineCmd.Flags().StringVar(&timelineDay, "day", "", "only entries recorded on this local day")
+	timelineCmd.Flags().StringArrayVar(&timelineKinds, "kind", nil, "only these kinds: record, decision, commit, git (repeatable or comma-separated)")
+	timelineCmd.Flags().StringArrayVar(&timelineFiles, "file", nil, "only commits that changed this file or directory (repeatable)")
+	timelineCmd.Flags().IntVar(&timelineLimit, "limit", 0, "show at most this many of the most recent entries (0 for all)")
+	timelineCmd.Flags().BoolVar(&timelineReverse, "reverse", false, "newest first")
+	timelineCmd.Flags().BoolVar(&timelineIncludeRetracted, "include-retracted", false, "show retracted entries (marked)")
 	rootCmd.AddCommand(timelineCmd)
 }
-
-func eventHasAnyTag(e event.Event, want []string) bool {
-	if len(want) == 0 {
-		return true
-	}
-	if len(e.Tags) == 0 {
-		return false
-	}
-
-	set := make(map[string]struct{}, len(e.Tags))
-	for _, t := range e.Tags {
-		set[strings.ToLower(strings.TrimSpace(t))] = struct{}{}
-	}
-
-	for _, w := range want {
-		if _, ok := set[w]; ok {
-			return true
-		}
-	}
-	return false
-}
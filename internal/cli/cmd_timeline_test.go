@@ -0,0 +1,208 @@
+package cli
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/divijg19/sage/internal/event"
+	"github.com/divijg19/sage/internal/query"
+	"github.com/divijg19/sage/internal/store"
+)
+
+func resetTimelineFlags(t *testing.T) {
+	t.Helper()
+	reset := func() {
+		timelineTags, timelineAll, timelineProject, timelineIncludeRetracted, timelineQuery = nil, false, "", false, ""
+		timelineSince, timelineUntil, timelineDay, timelineKinds, timelineLimit, timelineReverse = "", "", "", nil, 0, false
+		timelineFiles = nil
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+func TestTimelineFilter_FlagsBecomeFilterFields(t *testing.T) {
+	resetTimelineFlags(t)
+	local := func(s string) time.Time {
+		tm, err := time.ParseInLocation("2006-01-02T15:04", s, time.Local)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+
+	tests := []struct {
+		name    string
+		set     func()
+		check   func(t *testing.T, f store.Filter)
+		wantErr string
+	}{
+		{
+			name: "day covers one local day",
+			set:  func() { timelineDay = "2026-01-09" },
+			check: func(t *testing.T, f store.Filter) {
+				if !f.After.Equal(local("2026-01-09T00:00")) || !f.Before.Equal(local("2026-01-10T00:00")) {
+					t.Fatalf("unexpected bounds %v .. %v", f.After, f.Before)
+				}
+			},
+		},
+		{
+			name: "until a date includes that day",
+			set:  func() { timelineSince, timelineUntil = "2026-01-01", "2026-01-31" },
+			check: func(t *testing.T, f store.Filter) {
+				if !f.After.Equal(local("2026-01-01T00:00")) || !f.Before.Equal(local("2026-02-01T00:00")) {
+					t.Fatalf("unexpected bounds %v .. %v", f.After, f.Before)
+				}
+			},
+		},
+		{
+			name: "until a month includes that month",
+			set:  func() { timelineUntil = "2026-01" },
+			check: func(t *testing.T, f store.Filter) {
+				if !f.Before.Equal(local("2026-02-01T00:00")) {
+					t.Fatalf("unexpected upper bound %v", f.Before)
+				}
+			},
+		},
+		{
+			name: "until an instant includes that instant",
+			set:  func() { timelineUntil = "2026-01-09T21:30" },
+			check: func(t *testing.T, f store.Filter) {
+				if !f.Before.Equal(local("2026-01-09T21:30").Add(time.Nanosecond)) {
+					t.Fatalf("unexpected upper bound %v", f.Before)
+				}
+			},
+		},
+		{
+			name: "kinds accept aliases and commas",
+			set:  func() { timelineKinds = []string{"d,Commit", "r"} },
+			check: func(t *testing.T, f store.Filter) {
+				want := []event.EntryKind{event.DecisionKind, event.CommitKind, event.RecordKind}
+				if len(f.Kinds) != len(want) {
+					t.Fatalf("unexpected kinds %v", f.Kinds)
+				}
+				for i := range want {
+					if f.Kinds[i] != want[i] {
+						t.Fatalf("unexpected kinds %v", f.Kinds)
+					}
+				}
+			},
+		},
+		{
+			name: "limit reads newest first",
+			set:  func() { timelineLimit = 5 },
+			check: func(t *testing.T, f store.Filter) {
+				if f.Limit != 5 || f.Order != store.Descending {
+					t.Fatalf("unexpected limit/order %d/%v", f.Limit, f.Order)
+				}
+			},
+		},
+		{
+			name: "files become paths",
+			set:  func() { timelineFiles = []string{"internal/store/", "go.mod"} },
+			check: func(t *testing.T, f store.Filter) {
+				if len(f.Paths) != 2 || f.Paths[0] != "internal/store/" || f.Paths[1] != "go.mod" {
+					t.Fatalf("unexpected paths %v", f.Paths)
+				}
+			},
+		},
+		{name: "day excludes since", set: func() { timelineDay, timelineSince = "2026-01-09", "2026-01-01" }, wantErr: "--day cannot be combined"},
+		{name: "bad kind", set: func() { timelineKinds = []string{"note"} }, wantErr: `unknown --kind "note"`},
+		{name: "bad time", set: func() { timelineSince = "soon" }, wantErr: `invalid --since "soon"`},
+		{name: "negative limit", set: func() { timelineLimit = -1 }, wantErr: "--limit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetTimelineFlags(t)
+			tt.set()
+			f, err := timelineFilter(query.Query{})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("timelineFilter: %v", err)
+			}
+			tt.check(t, f)
+		})
+	}
+}
+
+// captureStdout returns what fn writes to os.Stdout.
+func captureStdout(t *testing.T, fn func() error) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := os.Stdout
+	os.Stdout = w
+	runErr := fn()
+	os.Stdout = old
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runErr != nil {
+		t.Fatalf("command failed: %v", runErr)
+	}
+	return string(out)
+}
+
+func TestTimeline_LimitKeepsMostRecentInOrder(t *testing.T) {
+	resetTimelineFlags(t)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("SAGE_PROJECT", "")
+
+	s, err := openGlobalStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2026, 1, 9, 9, 0, 0, 0, time.Local)
+	for i, title := range []string{"one", "two", "three", "four"} {
+		kind := event.RecordKind
+		if i%2 == 1 {
+			kind = event.DecisionKind
+		}
+		if err := s.Append(event.Event{ID: title, Timestamp: base.Add(time.Duration(i) * time.Hour), Project: "global", Kind: kind, Title: title, Content: "x"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	titles := func(out string) []string {
+		var got []string
+		for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+			if line != "" {
+				fields := strings.Fields(line)
+				got = append(got, fields[len(fields)-1])
+			}
+		}
+		return got
+	}
+	timelineCmd.SetContext(context.Background())
+	run := func() error { return timelineCmd.RunE(timelineCmd, nil) }
+
+	timelineLimit = 2
+	if got := strings.Join(titles(captureStdout(t, run)), " "); got != "three four" {
+		t.Fatalf("--limit 2: got %q", got)
+	}
+
+	timelineReverse = true
+	if got := strings.Join(titles(captureStdout(t, run)), " "); got != "four three" {
+		t.Fatalf("--limit 2 --reverse: got %q", got)
+	}
+
+	timelineLimit, timelineReverse = 0, false
+	timelineKinds = []string{"decision"}
+	timelineUntil = "2026-01-09T10:00"
+	if got := strings.Join(titles(captureStdout(t, run)), " "); got != "two" {
+		t.Fatalf("--kind decision --until: got %q", got)
+	}
+}
@@ -1,12 +1,14 @@
 package cli
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os/exec"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/charmbracelet/bubbles/textinput"
@@ -16,13 +18,16 @@ import (
 
 	"github.com/divijg19/sage/internal/entryflow"
 	"github.com/divijg19/sage/internal/event"
+	"github.com/divijg19/sage/internal/state"
+	"github.com/divijg19/sage/internal/store"
 )
 
 var (
-	tuiAll     bool
-	tuiProject string
-	tuiTags    []string
-	tuiQuery   string
+	tuiAll              bool
+	tuiProject          string
+	tuiTags             []string
+	tuiQuery            string
+	tuiIncludeRetracted bool
 )
 
 type chronicleProgram interface {
@@ -50,14 +55,16 @@ func init() {
 	tuiCmd.Flags().BoolVar(&tuiAll, "all", false, "show entries from all projects")
 	tuiCmd.Flags().StringVar(&tuiProject, "project", "", "override project scope (ignores active project)")
 	tuiCmd.Flags().StringArrayVar(&tuiTags, "tags", nil, "filter by tags (repeatable or comma-separated)")
-	tuiCmd.Flags().StringVar(&tuiQuery, "query", "", "apply an initial text query")
+	tuiCmd.Flags().StringVar(&tuiQuery, "query", "", "apply an initial search query (see: sage help query)")
+	tuiCmd.Flags().BoolVar(&tuiIncludeRetracted, "include-retracted", false, "show retracted entries (marked)")
 	rootCmd.AddCommand(tuiCmd)
 }
 
 type chronicleOptions struct {
-	Query   string
-	Project string
-	Tags    []string
+	Query            string
+	Project          string
+	Tags             []string
+	IncludeRetracted bool
 }
 
 func chronicleOptionsFromFlags() chronicleOptions {
@@ -66,19 +73,25 @@ func chronicleOptionsFromFlags() chronicleOptions {
 		project = ""
 	}
 	return chronicleOptions{
-		Query:   strings.TrimSpace(tuiQuery),
-		Project: project,
-		Tags:    parseTags(tuiTags),
+		Query:            strings.TrimSpace(tuiQuery),
+		Project:          project,
+		Tags:             parseTags(tuiTags),
+		IncludeRetracted: tuiIncludeRetracted,
 	}
 }
 
 type chronicleDataLoadedMsg struct {
-	events    []event.Event
-	tags      []string
-	highlight int64
-	err       error
+	events     []event.Event
+	projection state.State
+	tags       []string
+	highlight  int64
+	search     chronicleSearchFunc
+	err        error
 }
 
+// chronicleSearchFunc returns the seqs of entries matching a search query.
+type chronicleSearchFunc func(query string) (map[int64]bool, error)
+
 type chronicleEditorFinishedMsg struct {
 	err error
 }
@@ -115,6 +128,19 @@ type chronicleModel struct {
 	collapsedDays   map[string]bool
 	expandedEntries map[int64]bool
 
+	includeRetracted bool
+
+	// projection is the current state of the loaded entries (see
+	// internal/state); the inspector shows where each decision stands.
+	projection state.State
+
+	// search runs queries against the store's full-text index. searchHits
+	// caches the result for searchQuery; without a searcher (or when it
+	// fails) the query falls back to an in-memory substring match.
+	search      chronicleSearchFunc
+	searchQuery string
+	searchHits  map[int64]bool
+
 	selectedRow int
 	scrollLine  int
 
@@ -173,11 +199,7 @@ func newChronicleModel(opts chronicleOptions) chronicleModel {
 	tagsInput.CharLimit = 120
 	tagsInput.Width = 30
 
-	kindFilter := map[event.EntryKind]bool{
-		event.RecordKind:   true,
-		event.DecisionKind: true,
-		event.CommitKind:   true,
-	}
+	kindFilter := allKinds()
 
 	tagFilter := make(map[string]bool)
 	for _, tag := range opts.Tags {
@@ -185,26 +207,27 @@ func newChronicleModel(opts chronicleOptions) chronicleModel {
 	}
 
 	return chronicleModel{
-		queryInput:      queryInput,
-		commandInput:    commandInput,
-		titleInput:      titleInput,
-		tagsInput:       tagsInput,
-		selectedProject: opts.Project,
-		tagFilter:       tagFilter,
-		kindFilter:      kindFilter,
-		collapsedDays:   map[string]bool{},
-		expandedEntries: map[int64]bool{},
-		query:           opts.Query,
-		inputMode:       chronicleInputSearch,
-		loading:         true,
-		quickKind:       event.RecordKind,
-		status:          "Loading Chronicle...",
-		statusTone:      chronicleStatusInfo,
+		queryInput:       queryInput,
+		commandInput:     commandInput,
+		titleInput:       titleInput,
+		tagsInput:        tagsInput,
+		selectedProject:  opts.Project,
+		tagFilter:        tagFilter,
+		kindFilter:       kindFilter,
+		collapsedDays:    map[string]bool{},
+		expandedEntries:  map[int64]bool{},
+		includeRetracted: opts.IncludeRetracted,
+		query:            opts.Query,
+		inputMode:        chronicleInputSearch,
+		loading:          true,
+		quickKind:        event.RecordKind,
+		status:           "Loading Chronicle...",
+		statusTone:       chronicleStatusInfo,
 	}
 }
 
 func (m chronicleModel) Init() tea.Cmd {
-	return tea.Batch(loadChronicleDataCmd(), textinput.Blink)
+	return tea.Batch(m.loadDataCmd(0), textinput.Blink)
 }
 
 func (m chronicleModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -223,7 +246,10 @@ func (m chronicleModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		m.events = msg.events
+		m.projection = msg.projection
 		m.availableTags = msg.tags
+		m.search = msg.search
+		m.searchHits = nil
 		m.projects = chronicleProjectOptions(msg.events)
 		m.rebuildRows(msg.highlight)
 		m.setStatusInfo(m.scopeStatusMessage())
@@ -276,7 +302,7 @@ func (m chronicleModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "r":
 			m.loading = true
 			m.setStatusInfo("Reloading Chronicle...")
-			return m, loadChronicleDataCmd()
+			return m, m.loadDataCmd(0)
 		case "tab":
 			if m.isCompact() {
 				m.showPreview = !m.showPreview
@@ -439,7 +465,9 @@ func (m chronicleModel) runChronicleCommand(raw string) (tea.Model, tea.Cmd) {
 	case "reload", "refresh":
 		m.loading = true
 		m.setStatusInfo("Reloading Chronicle...")
-		return m, loadChronicleDataCmd()
+		return m, m.loadDataCmd(0)
+	case "state":
+		m.setStatusInfo(chronicleStateSummary(m.projection))
 	case "clear":
 		m.clearSearchAndFilters()
 		m.setStatusInfo("Search and filters cleared")
@@ -620,7 +648,7 @@ func (m chronicleModel) finishQuickEntry(execErr error) (tea.Model, tea.Cmd) {
 		m.setStatusSuccess("Entry recorded")
 		m.showQuick = false
 		m.focused = ""
-		return m, loadChronicleDataCmdWithHighlight(result.Event.Seq)
+		return m, m.loadDataCmd(result.Event.Seq)
 	case entryflow.StatusCanceled:
 		m.setStatusWarn("Editor canceled")
 	case entryflow.StatusUnchanged:
@@ -633,7 +661,7 @@ func (m chronicleModel) finishQuickEntry(execErr error) (tea.Model, tea.Cmd) {
 
 	m.showQuick = false
 	m.focused = ""
-	return m, loadChronicleDataCmd()
+	return m, m.loadDataCmd(0)
 }
 
 func (m *chronicleModel) startQuickEntry() (tea.Model, tea.Cmd) {
@@ -769,11 +797,7 @@ func (m *chronicleModel) clearSearchAndFilters() {
 	m.query = ""
 	m.queryInput.SetValue("")
 	m.tagFilter = map[string]bool{}
-	m.kindFilter = map[event.EntryKind]bool{
-		event.RecordKind:   true,
-		event.DecisionKind: true,
-		event.CommitKind:   true,
-	}
+	m.kindFilter = allKinds()
 	m.rebuildRows(0)
 }
 
@@ -796,6 +820,7 @@ func (m *chronicleModel) selectEventBySeq(seq int64) bool {
 }
 
 func (m *chronicleModel) rebuildRows(preferSeq int64) {
+	m.refreshSearchHits()
 	m.rows = buildChronicleRows(filterChronicleEvents(m.events, m.filters()), m.collapsedDays, m.expandedEntries)
 	if len(m.rows) == 0 {
 		m.selectedRow = 0
@@ -840,6 +865,22 @@ func (m *chronicleModel) rebuildRows(preferSeq int64) {
 	m.ensureSelectedVisible()
 }
 
+func (m *chronicleModel) refreshSearchHits() {
+	if m.search == nil || m.query == "" {
+		m.searchQuery, m.searchHits = "", nil
+		return
+	}
+	if m.searchHits != nil && m.searchQuery == m.query {
+		return
+	}
+	hits, err := m.search(m.query)
+	if err != nil {
+		m.searchQuery, m.searchHits = "", nil
+		return
+	}
+	m.searchQuery, m.searchHits = m.query, hits
+}
+
 func (m *chronicleModel) ensureSelectedVisible() {
 	height := m.timelineHeight()
 	if height <= 0 || len(m.rows) == 0 {
@@ -880,12 +921,16 @@ func (m chronicleModel) filters() chronicleFilters {
 		}
 	}
 
-	return chronicleFilters{
+	filters := chronicleFilters{
 		Query:        m.query,
 		Project:      m.selectedProject,
 		EnabledKinds: enabledKinds,
 		EnabledTags:  m.tagFilter,
 	}
+	if m.searchHits != nil && m.searchQuery == m.query {
+		filters.Matches = m.searchHits
+	}
+	return filters
 }
 
 func (m chronicleModel) selected() *chronicleRow {
@@ -929,7 +974,7 @@ func (m chronicleModel) filterItems() []chronicleFilterItem {
 			On:    m.selectedProject == project,
 		})
 	}
-	for _, kind := range []event.EntryKind{event.RecordKind, event.DecisionKind, event.CommitKind} {
+	for _, kind := range event.EntryKinds() {
 		items = append(items, chronicleFilterItem{
 			Group: "Kinds",
 			Label: chronicleKindLabel(kind),
@@ -984,7 +1029,7 @@ func (m chronicleModel) filteredCount() int {
 
 func (m chronicleModel) activeKindLabels() []string {
 	var kinds []string
-	for _, kind := range []event.EntryKind{event.RecordKind, event.DecisionKind, event.CommitKind} {
+	for _, kind := range event.EntryKinds() {
 		if m.kindFilter[kind] {
 			kinds = append(kinds, chronicleKindLabel(kind))
 		}
@@ -1032,17 +1077,32 @@ func (m *chronicleModel) setStatusError(status string) {
 	m.setStatus(status, chronicleStatusError)
 }
 
+func (m chronicleModel) loadDataCmd(highlight int64) tea.Cmd {
+	return loadChronicleDataCmdWithOptions(highlight, m.includeRetracted)
+}
+
 func loadChronicleDataCmd() tea.Cmd {
 	return loadChronicleDataCmdWithHighlight(0)
 }
 
 func loadChronicleDataCmdWithHighlight(highlight int64) tea.Cmd {
+	return loadChronicleDataCmdWithOptions(highlight, false)
+}
+
+func loadChronicleDataCmdWithOptions(highlight int64, includeRetracted bool) tea.Cmd {
 	return func() tea.Msg {
 		s, err := openGlobalStore()
 		if err != nil {
 			return chronicleDataLoadedMsg{err: err}
 		}
-		events, err := s.List()
+		if includeRetracted {
+			s = s.IncludingRetracted()
+		}
+		events, err := store.Collect(s.Query(context.Background(), store.Filter{}))
+		if err != nil {
+			return chronicleDataLoadedMsg{err: err}
+		}
+		links, err := s.Links()
 		if err != nil {
 			return chronicleDataLoadedMsg{err: err}
 		}
@@ -1051,10 +1111,31 @@ func loadChronicleDataCmdWithHighlight(highlight int64) tea.Cmd {
 			return chronicleDataLoadedMsg{err: err}
 		}
 		return chronicleDataLoadedMsg{
-			events:    events,
-			tags:      chronicleUnionTags(configured, events),
-			highlight: highlight,
+			events:     events,
+			projection: state.Build(time.Now(), events, links, state.Options{IncludeRetracted: includeRetracted}),
+			tags:       chronicleUnionTags(configured, events),
+			highlight:  highlight,
+			search:     chronicleStoreSearch(s),
+		}
+	}
+}
+
+// chronicleStoreSearch evaluates a search-bar query against the store across
+// every project; Chronicle applies its own scope and kind filters afterwards.
+func chronicleStoreSearch(s *store.Store) chronicleSearchFunc {
+	return func(raw string) (map[int64]bool, error) {
+		q, err := parseQuery(raw)
+		if err != nil {
+			return nil, err
+		}
+		matches := make(map[int64]bool)
+		for e, err := range s.Query(context.Background(), store.Filter{Match: q}) {
+			if err != nil {
+				return nil, err
+			}
+			matches[e.Seq] = true
 		}
+		return matches, nil
 	}
 }
 
@@ -1064,6 +1145,8 @@ func chronicleKindLabel(kind event.EntryKind) string {
 		return "decision"
 	case event.CommitKind:
 		return "commit"
+	case event.GitKind:
+		return "git"
 	default:
 		return "record"
 	}
@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -117,7 +119,7 @@ func TestUpdateFilterPalette_TogglesScopeAndTags(t *testing.T) {
 		t.Fatalf("expected all-projects toggle to clear selected project, got %q", next.selectedProject)
 	}
 
-	next.filterIndex = 1 + len(next.projects) + 3
+	next.filterIndex = 1 + len(next.projects) + len(event.EntryKinds())
 	next = next.updateFilterPalette(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{' '}})
 	if !next.tagFilter["auth"] {
 		t.Fatalf("expected tag toggle to enable auth")
@@ -158,6 +160,38 @@ func TestUpdateSearch_EnterAndEscape(t *testing.T) {
 	}
 }
 
+func TestRebuildRows_UsesSearchIndexWhenAvailable(t *testing.T) {
+	m := fixtureChronicleModel(100, 24)
+	calls := 0
+	m.search = func(query string) (map[int64]bool, error) {
+		calls++
+		// The index decides what matches; the substring scan is not consulted.
+		return map[int64]bool{3: true}, nil
+	}
+
+	m.query = "layouts"
+	m.rebuildRows(0)
+	got := m.filteredEvents()
+	if len(got) != 1 || got[0].Seq != 3 {
+		t.Fatalf("expected index hits to drive the filter, got %+v", got)
+	}
+
+	m.rebuildRows(0)
+	if calls != 1 {
+		t.Fatalf("expected cached hits for an unchanged query, got %d searches", calls)
+	}
+
+	m.search = func(query string) (map[int64]bool, error) {
+		return nil, errors.New("index unavailable")
+	}
+	m.query = "durability"
+	m.rebuildRows(0)
+	got = m.filteredEvents()
+	if len(got) != 1 || got[0].Seq != 2 {
+		t.Fatalf("expected substring fallback when search fails, got %+v", got)
+	}
+}
+
 func TestBottomInput_SearchAndModeToggle(t *testing.T) {
 	m := fixtureChronicleModel(100, 24)
 
@@ -289,6 +323,24 @@ func TestRenderPreview_DayAndEntry(t *testing.T) {
 	if !strings.Contains(entryPreview, "#alpha") || !strings.Contains(entryPreview, "#zeta") {
 		t.Fatalf("expected sorted tags in entry preview:\n%s", entryPreview)
 	}
+
+	files := make([]string, 8)
+	for i := range files {
+		files[i] = fmt.Sprintf("pkg/file%d.go", i)
+	}
+	m.rows = []chronicleRow{{
+		Kind: chronicleRowEntry,
+		Event: event.Event{
+			Seq:      10,
+			Kind:     event.CommitKind,
+			Title:    "Split the store",
+			Metadata: map[string]string{event.MetaFiles: strings.Join(files, "\n"), "insertions": "40", "deletions": "7"},
+		},
+	}}
+	commitPreview := ansi.Strip(m.renderPreview(theme, 60, 30))
+	if !containsAll(commitPreview, []string{"Files", "8 files · +40 -7", "pkg/file0.go", "pkg/file5.go", "... and 2 more"}) || strings.Contains(commitPreview, "pkg/file6.go") {
+		t.Fatalf("expected the commit's files in the preview:\n%s", commitPreview)
+	}
 }
 
 func TestView_CompactHintsAndOverlay(t *testing.T) {
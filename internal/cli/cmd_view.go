@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"github.com/divijg19/sage/internal/event"
+	"github.com/divijg19/sage/internal/output"
+	"github.com/divijg19/sage/internal/store"
 	"github.com/spf13/cobra"
 )
 
@@ -14,7 +16,8 @@ var viewCmd = &cobra.Command{
 	Use:   "view <id>",
 	Short: "View a past entry by numeric ID",
 	Long: "View the full contents of a past entry using its numeric ID (shown in `sage timeline`).\n\n" +
-		"Entries live in a single global log; IDs are global and numeric.",
+		"Entries live in a single global log; IDs are global and numeric.\n" +
+		"Amended entries show their latest revision; use --revisions to list every version.",
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		id, err := strconv.ParseInt(strings.TrimSpace(args[0]), 10, 64)
@@ -31,15 +34,189 @@ var viewCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		if e == nil {
+		// Tags, revisions, retractions and links are not entries of their own.
+		if e == nil || e.Kind.IsAnnotation() {
 			return fmt.Errorf("no entry with id %d", id)
 		}
 
+		revisions, err := s.Revisions(id)
+		if err != nil {
+			return err
+		}
+
+		format, err := outputFormat()
+		if err != nil {
+			return err
+		}
+
+		if viewRevisions {
+			if format.Structured() {
+				return writeOutput(format, revisionsOutput(revisions))
+			}
+			printRevisions(revisions)
+			return nil
+		}
+
+		links, err := s.LinksFor(e.ID)
+		if err != nil {
+			return err
+		}
+
+		if format.Structured() {
+			out, err := entryLinksOutput(s, e.ID, links)
+			if err != nil {
+				return err
+			}
+			return writeOutput(format, output.NewEntryDetail(*e, out, len(revisions)))
+		}
+
 		printFullEntry(*e)
+		if err := printEntryLinks(s, e.ID, links); err != nil {
+			return err
+		}
+
+		if len(revisions) > 1 {
+			last := revisions[len(revisions)-1]
+			fmt.Println()
+			fmt.Printf("(revision %d of %d, amended %s; see --revisions)\n",
+				len(revisions), len(revisions), last.At.Format("2006-01-02 15:04:05"))
+		}
 		return nil
 	},
 }
 
+var viewRevisions bool
+
+type storeLookup interface {
+	GetByID(id string) (*event.Event, error)
+}
+
+// printEntryLinks lists outgoing and incoming links of the entry with the given ID.
+func printEntryLinks(s storeLookup, id string, links []event.Link) error {
+	if len(links) == 0 {
+		return nil
+	}
+
+	var out, in, implementedBy []string
+	for _, l := range links {
+		if l.From == id {
+			other, err := s.GetByID(l.To)
+			if err != nil {
+				return err
+			}
+			out = append(out, fmt.Sprintf("- %s %s", l.Relation, linkedEntryLabel(other)))
+			continue
+		}
+		other, err := s.GetByID(l.From)
+		if err != nil {
+			return err
+		}
+		if l.Relation == event.Implements {
+			implementedBy = append(implementedBy, "- "+implementingEntryLabel(other))
+			continue
+		}
+		in = append(in, fmt.Sprintf("- %s %s this", linkedEntryLabel(other), l.Relation))
+	}
+
+	fmt.Println()
+	if len(out) > 0 {
+		fmt.Println("Links:")
+		for _, line := range out {
+			fmt.Println(line)
+		}
+	}
+	if len(in) > 0 {
+		fmt.Println("Linked from:")
+		for _, line := range in {
+			fmt.Println(line)
+		}
+	}
+	if len(implementedBy) > 0 {
+		fmt.Println("Implemented by:")
+		for _, line := range implementedBy {
+			fmt.Println(line)
+		}
+	}
+	return nil
+}
+
+// entryLinksOutput is the structured form of printEntryLinks.
+func entryLinksOutput(s storeLookup, id string, links []event.Link) ([]output.Link, error) {
+	out := make([]output.Link, 0, len(links))
+	for _, l := range links {
+		direction, otherID := output.Outgoing, l.To
+		if l.From != id {
+			direction, otherID = output.Incoming, l.From
+		}
+		other, err := s.GetByID(otherID)
+		if err != nil {
+			return nil, err
+		}
+		link := output.Link{Relation: string(l.Relation), Direction: direction, ID: otherID}
+		if other != nil {
+			link.Seq = other.Seq
+			link.Title = other.Title
+			if other.Kind == event.CommitKind {
+				link.SHA = other.Metadata["sha"]
+			}
+		}
+		out = append(out, link)
+	}
+	return out, nil
+}
+
+func linkedEntryLabel(e *event.Event) string {
+	if e == nil {
+		return "(unknown entry)"
+	}
+	title := strings.TrimSpace(e.Title)
+	if title == "" {
+		title = "(untitled)"
+	}
+	if e.Retracted {
+		title = retractedMarker + " " + title
+	}
+	return fmt.Sprintf("[%d] %s", e.Seq, title)
+}
+
+// implementingEntryLabel is linkedEntryLabel with a commit's short SHA.
+func implementingEntryLabel(e *event.Event) string {
+	label := linkedEntryLabel(e)
+	if e == nil || e.Kind != event.CommitKind || e.Metadata["sha"] == "" {
+		return label
+	}
+	return label + " (" + shortSHA(e.Metadata["sha"]) + ")"
+}
+
+func printRevisions(revisions []store.Revision) {
+	for i, r := range revisions {
+		if i > 0 {
+			fmt.Println()
+			fmt.Println("---")
+			fmt.Println()
+		}
+		label := "original"
+		if i > 0 {
+			label = "amended"
+		}
+		fmt.Printf("Revision %d of %d (%s %s)\n", i+1, len(revisions), label, r.At.Format("2006-01-02 15:04:05"))
+		printFullEntry(r.Entry)
+	}
+}
+
+func revisionsOutput(revisions []store.Revision) output.Revisions {
+	out := make([]output.Revision, 0, len(revisions))
+	for i, r := range revisions {
+		out = append(out, output.Revision{
+			Number: i + 1,
+			Seq:    r.Seq,
+			At:     output.FormatTime(r.At),
+			Entry:  output.NewEntry(r.Entry),
+		})
+	}
+	return output.NewRevisions(out)
+}
+
 func printFullEntry(e event.Event) {
 	fmt.Printf("ID: %d\n", e.Seq)
 	fmt.Printf("When: %s\n", e.Timestamp.Format("2006-01-02 15:04:05"))
@@ -60,6 +237,9 @@ func printFullEntry(e event.Event) {
 		title = "(untitled)"
 	}
 	fmt.Printf("Title: %s\n", title)
+	if e.Retracted {
+		fmt.Println("Status: retracted (hidden from timeline, tag, state and Chronicle)")
+	}
 
 	if len(e.Tags) == 0 {
 		fmt.Println("Tags: (none)")
@@ -77,5 +257,6 @@ func printFullEntry(e event.Event) {
 }
 
 func init() {
+	viewCmd.Flags().BoolVar(&viewRevisions, "revisions", false, "list every revision of the entry, oldest first")
 	rootCmd.AddCommand(viewCmd)
 }
@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/divijg19/sage/internal/event"
+)
+
+func TestView_RejectsAnnotationIDs(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("SAGE_PROJECT", "")
+
+	s, err := openGlobalStore()
+	if err != nil {
+		t.Fatalf("openGlobalStore: %v", err)
+	}
+	entry := event.Event{ID: "evt-1", Timestamp: time.Date(2026, 4, 24, 9, 0, 0, 0, time.UTC), Project: "alpha", Kind: event.RecordKind, Title: "Investigate auth cache"}
+	if err := s.Append(entry); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if err := s.Append(newTagEvent(entry, event.TagOpAdd, []string{"auth"})); err != nil {
+		t.Fatalf("Append tag: %v", err)
+	}
+
+	viewCmd.SetContext(context.Background())
+	if got := captureStdout(t, func() error { return viewCmd.RunE(viewCmd, []string{"1"}) }); !strings.Contains(got, "Investigate auth cache") {
+		t.Fatalf("expected the entry, got:\n%s", got)
+	}
+	// Seq 2 is the tag event, which is not an entry of its own.
+	if err := viewCmd.RunE(viewCmd, []string{"2"}); err == nil || err.Error() != "no entry with id 2" {
+		t.Fatalf("expected viewing an annotation to fail, got %v", err)
+	}
+}
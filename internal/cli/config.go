@@ -17,7 +17,6 @@ func configPath() string {
 	if dir == "" {
 		return ""
 	}
-	_ = os.MkdirAll(dir, 0o755)
 	return filepath.Join(dir, "config.json")
 }
 
@@ -53,6 +52,9 @@ func saveConfig(cfg userConfig) error {
 	if err != nil {
 		return err
 	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
 
 	return os.WriteFile(path, b, 0o600)
 }
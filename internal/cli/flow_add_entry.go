@@ -2,8 +2,11 @@ package cli
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"strings"
 
+	"github.com/divijg19/sage/internal/entryflow"
 	"github.com/divijg19/sage/internal/event"
 )
 
@@ -26,10 +29,102 @@ func resolveTitle(arg string, flag string) (string, error) {
 	return title, nil
 }
 
+//
+// Non-interactive content (--body, --body-file, --stdin)
+//
+
+// trimStdinArg strips a trailing "-" argument, which asks for the content on stdin.
+func trimStdinArg(args []string) ([]string, bool) {
+	if len(args) > 0 && args[len(args)-1] == "-" {
+		return args[:len(args)-1], true
+	}
+	return args, false
+}
+
+// readAddBody returns the content supplied instead of the editor, and whether
+// any was. bodySet reports whether --body was given (it may be empty).
+func readAddBody(bodySet bool, fromStdin bool) (string, bool, error) {
+	sources := 0
+	for _, set := range []bool{bodySet, addBodyFile != "", fromStdin} {
+		if set {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return "", false, fmt.Errorf("use only one of --body, --body-file and --stdin (or -)")
+	}
+
+	switch {
+	case fromStdin || addBodyFile == "-":
+		b, err := io.ReadAll(stdinReader)
+		if err != nil {
+			return "", false, fmt.Errorf("read stdin: %w", err)
+		}
+		return string(b), true, nil
+	case addBodyFile != "":
+		b, err := os.ReadFile(addBodyFile)
+		if err != nil {
+			return "", false, err
+		}
+		return string(b), true, nil
+	case bodySet:
+		return addBody, true, nil
+	default:
+		return "", false, nil
+	}
+}
+
+// addDependencies wires Finalize to the terminal, or with --yes answers every
+// question with its default.
+func addDependencies(s entryflow.Store) entryflow.Dependencies {
+	deps := entryflow.Dependencies{
+		Store:       s,
+		EnsureTags:  ensureTagsConfigured,
+		ResolveKind: resolveKind,
+		ConfirmSave: func() bool { return confirm("Save entry? [y/N]: ") },
+	}
+	if addYes {
+		deps.ResolveKind = defaultKind
+		deps.ConfirmSave = nil
+	}
+	return deps
+}
+
+// addStatusReason explains why Finalize did not save an entry.
+func addStatusReason(status entryflow.Status) string {
+	switch status {
+	case entryflow.StatusCanceled:
+		return "no content"
+	case entryflow.StatusUnchanged:
+		return "the content is the unchanged template"
+	case entryflow.StatusEmpty:
+		return "the content has no meaningful text (only headings or punctuation)"
+	case entryflow.StatusDuplicate:
+		return "it repeats the previous entry in this project"
+	default:
+		return string(status)
+	}
+}
+
 //
 // Entry kind resolution (record vs decision)
 //
 
+// defaultKind resolves the kind without asking: the explicit kind, else the
+// template's suggestion, else record.
+func defaultKind(explicit string, suggested string) (event.EntryKind, error) {
+	switch {
+	case explicit == "decision" || explicit == "d":
+		return event.DecisionKind, nil
+	case explicit == "record" || explicit == "r":
+		return event.RecordKind, nil
+	case suggested == "decision":
+		return event.DecisionKind, nil
+	default:
+		return event.RecordKind, nil
+	}
+}
+
 func resolveKind(
 	explicit string,
 	suggested string,
@@ -56,6 +56,24 @@ func gitDirAbs(repo string) (string, error) {
 	return filepath.Join(root, gd), nil
 }
 
+// gitCommonDirAbs is the Git directory shared by all worktrees, which holds
+// .git/hooks.
+func gitCommonDirAbs(repo string) (string, error) {
+	root, err := gitRepoRoot(repo)
+	if err != nil {
+		return "", err
+	}
+
+	gd, err := gitOutput(repo, "rev-parse", "--git-common-dir")
+	if err != nil {
+		return "", err
+	}
+	if filepath.IsAbs(gd) {
+		return gd, nil
+	}
+	return filepath.Join(root, gd), nil
+}
+
 func gitHooksDir(repo string) (string, string, error) {
 	// If core.hooksPath is set, it may be absolute or relative to the repo root.
 	hooksPath, _ := gitOutput(repo, "config", "--get", "core.hooksPath")
@@ -24,10 +24,15 @@ func openGlobalStore() (*store.Store, error) {
 		return nil, fmt.Errorf("could not determine Sage directory")
 	}
 
-	s, err := store.Open(path)
+	s, result, err := store.OpenWithResult(path)
 	if err != nil {
 		return nil, err
 	}
+	// Tell the user where the pre-migration backup went.
+	if result.Backup != "" && len(result.Applied) > 0 {
+		fmt.Fprintf(os.Stderr, "Upgraded the Sage database to schema version %d (backup: %s).\n",
+			result.Applied[len(result.Applied)-1].Version, result.Backup)
+	}
 
 	// Import legacy per-directory stores only if the global DB is empty.
 	// This makes the migration safe and idempotent.
@@ -35,6 +40,12 @@ func openGlobalStore() (*store.Store, error) {
 		return nil, err
 	}
 
+	// Record hook events spooled while the database was unavailable. This is
+	// best-effort: anything that fails to drain stays spooled for next time.
+	if n, err := drainSpool(s); err == nil && n > 0 {
+		fmt.Fprintf(os.Stderr, "Recorded %d spooled hook event(s).\n", n)
+	}
+
 	return s, nil
 }
 
@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/divijg19/sage/internal/event"
+	"github.com/divijg19/sage/internal/store"
+)
+
+// backfillBatch is how many commits backfill records per transaction. Each
+// batch is committed on its own, so an interrupted backfill keeps its progress
+// and the next run picks up where it stopped.
+const backfillBatch = 500
+
+type backfillOptions struct {
+	Since  time.Time
+	Branch string
+	DryRun bool
+	// Progress receives a running count; nil shows none.
+	Progress io.Writer
+}
+
+type backfillResult struct {
+	Root     string
+	Project  string
+	Rev      string
+	Commits  int
+	Recorded int
+}
+
+// runHooksBackfill records the commits reachable from the branch (HEAD by
+// default) that are not in the log yet, oldest first, as post-commit would
+// have recorded them.
+func runHooksBackfill(s *store.Store, repo string, opts backfillOptions) (backfillResult, error) {
+	r, err := resolveHookRepo(repo)
+	if err != nil {
+		return backfillResult{}, err
+	}
+	rev := "HEAD"
+	branch, _ := gitOutput(repo, "rev-parse", "--abbrev-ref", "HEAD")
+	if b := strings.TrimSpace(opts.Branch); b != "" {
+		rev, branch = b, shortRef(b)
+	}
+	if _, err := gitOutput(repo, "rev-parse", "--verify", "--quiet", rev+"^{commit}"); err != nil {
+		return backfillResult{}, fmt.Errorf("no commits on %s", rev)
+	}
+
+	res := backfillResult{Root: r.root, Project: r.project, Rev: rev}
+	args := []string{rev}
+	if !opts.Since.IsZero() {
+		args = append([]string{"--since=" + opts.Since.Format(time.RFC3339)}, args...)
+	}
+	total := 0
+	if n, err := gitOutput(repo, append([]string{"rev-list", "--count"}, args...)...); err == nil {
+		total, _ = strconv.Atoi(n)
+	}
+
+	// Each commit starts with \x1e, so its diff stat stays with it.
+	gitArgs := append([]string{"-C", r.root, "log", "--reverse", "--format=%x1e" + commitFormat}, commitDiffArgs...)
+	cmd := exec.Command("git", append(gitArgs, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return res, err
+	}
+	if err := cmd.Start(); err != nil {
+		return res, err
+	}
+	defer func() {
+		// Stop git if recording failed part way through the log.
+		if cmd.ProcessState == nil {
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+		}
+	}()
+
+	tags := map[string]bool{}
+	var batch []event.Event
+	flush := func() error {
+		n, err := recordBackfillBatch(s, batch, opts.DryRun)
+		res.Recorded += n
+		batch = batch[:0]
+		if opts.Progress != nil {
+			fmt.Fprintf(opts.Progress, "\rBackfilling %s: %d/%d commits", r.project, res.Commits, total)
+		}
+		return err
+	}
+
+	rd := bufio.NewReader(out)
+	for {
+		raw, readErr := rd.ReadString('\x1e')
+		if c, ok := parseGitCommit(strings.TrimSuffix(raw, "\x1e")); ok {
+			e := newCommitEvent(r, c, branch)
+			for _, t := range e.Tags {
+				tags[t] = true
+			}
+			batch = append(batch, e)
+			res.Commits++
+			if len(batch) == backfillBatch {
+				if err := flush(); err != nil {
+					return res, err
+				}
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return res, readErr
+		}
+	}
+	if err := cmd.Wait(); err != nil {
+		return res, fmt.Errorf("git log: %s", strings.TrimSpace(stderr.String()))
+	}
+	if len(batch) > 0 {
+		if err := flush(); err != nil {
+			return res, err
+		}
+	}
+	if opts.Progress != nil && res.Commits > 0 {
+		fmt.Fprintln(opts.Progress)
+	}
+
+	if !opts.DryRun && res.Recorded > 0 {
+		_ = ensureTagsConfigured(tagKeys(tags))
+	}
+	return res, nil
+}
+
+// recordBackfillBatch records the commits in batch that are not in the log
+// yet, with the links their trailers declare, in one transaction. It returns
+// how many commits are (or, in a dry run, would be) new.
+func recordBackfillBatch(s *store.Store, batch []event.Event, dryRun bool) (int, error) {
+	ids := make([]string, len(batch))
+	for i, e := range batch {
+		ids[i] = e.ID
+	}
+	known, err := s.KnownIDs(ids)
+	if err != nil {
+		return 0, err
+	}
+	var fresh []event.Event
+	for _, e := range batch {
+		if !known[e.ID] {
+			fresh = append(fresh, e)
+		}
+	}
+	if dryRun || len(fresh) == 0 {
+		return len(fresh), nil
+	}
+
+	events, err := withTrailerLinks(s, fresh)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := s.ImportEvents(events); err != nil {
+		return 0, err
+	}
+	return len(fresh), nil
+}
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/divijg19/sage/internal/event"
+	"github.com/divijg19/sage/internal/store"
+)
+
+func TestRunHooksBackfill_RecordsHistoryOnce(t *testing.T) {
+	repo := initHookRepo(t)
+	commitFile(t, repo, "b.txt", "b", "second commit")
+	// The hook already saw the latest commit; backfill must not repeat it.
+	commitFile(t, repo, "c.txt", "c", "third commit\n\nSage-Tags: history")
+	_ = runHookPostCommit(repo)
+	base, _ := gitOutput(repo, "rev-parse", "--abbrev-ref", "HEAD")
+	runGit(t, repo, "checkout", "-q", "-b", "feature")
+	commitFile(t, repo, "d.txt", "d", "feature commit")
+	runGit(t, repo, "checkout", "-q", base)
+
+	s, err := openGlobalStore()
+	if err != nil {
+		t.Fatalf("openGlobalStore: %v", err)
+	}
+
+	res, err := runHooksBackfill(s, repo, backfillOptions{DryRun: true})
+	if err != nil || res.Commits != 3 || res.Recorded != 2 {
+		t.Fatalf("dry run = %+v, %v; want 2 of 3 new", res, err)
+	}
+
+	var progress bytes.Buffer
+	res, err = runHooksBackfill(s, repo, backfillOptions{Progress: &progress})
+	if err != nil || res.Commits != 3 || res.Recorded != 2 {
+		t.Fatalf("backfill = %+v, %v; want 2 of 3 recorded", res, err)
+	}
+	if !strings.Contains(progress.String(), "3/3 commits") {
+		t.Fatalf("unexpected progress: %q", progress.String())
+	}
+
+	events, err := store.Collect(s.Query(context.Background(), store.Filter{}))
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	var titles []string
+	for _, e := range events {
+		titles = append(titles, e.Title)
+		if e.Kind != event.CommitKind || e.Metadata["branch"] != base || e.Metadata["author_name"] != "Sage Test" ||
+			!strings.HasSuffix(e.Metadata[event.MetaFiles], ".txt") || e.Metadata["insertions"] != "1" {
+			t.Fatalf("unexpected commit event: %+v", e)
+		}
+	}
+	// The hook's commit keeps seq 1 and the backfilled history follows. Commits
+	// made within the same second are ordered by ID, as every import is.
+	slices.Sort(titles[1:])
+	if got := strings.Join(titles, " | "); got != "third commit | first commit | second commit" {
+		t.Fatalf("unexpected entries: %s", got)
+	}
+
+	res, err = runHooksBackfill(s, repo, backfillOptions{Branch: "feature"})
+	if err != nil || res.Commits != 4 || res.Recorded != 1 {
+		t.Fatalf("feature backfill = %+v, %v; want only the feature commit", res, err)
+	}
+	feature, err := store.Collect(s.Query(context.Background(), store.Filter{MinSeq: 4}))
+	if err != nil || len(feature) != 1 || feature[0].Metadata["branch"] != "feature" {
+		t.Fatalf("expected the feature commit on its branch, got %+v (%v)", feature, err)
+	}
+
+	res, err = runHooksBackfill(s, repo, backfillOptions{Since: time.Now().Add(time.Hour)})
+	if err != nil || res.Commits != 0 {
+		t.Fatalf("--since in the future = %+v, %v; want no commits", res, err)
+	}
+	if _, err := runHooksBackfill(s, repo, backfillOptions{Branch: "no-such-branch"}); err == nil {
+		t.Fatalf("expected an unknown branch to fail")
+	}
+}
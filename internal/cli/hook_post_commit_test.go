@@ -1,12 +1,15 @@
 package cli
 
 import (
+	"context"
+	"database/sql"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/divijg19/sage/internal/event"
+	"github.com/divijg19/sage/internal/store"
 )
 
 func TestRunHookPostCommit_AppendsOnceDeterministic(t *testing.T) {
@@ -38,7 +41,7 @@ func TestRunHookPostCommit_AppendsOnceDeterministic(t *testing.T) {
 		t.Fatalf("openGlobalStore: %v", err)
 	}
 
-	events, err := s.List()
+	events, err := store.Collect(s.Query(context.Background(), store.Filter{}))
 	if err != nil {
 		t.Fatalf("List: %v", err)
 	}
@@ -59,6 +62,115 @@ func TestRunHookPostCommit_AppendsOnceDeterministic(t *testing.T) {
 	}
 }
 
+func TestRunHookPostCommit_SpoolsWhenStoreUnavailable(t *testing.T) {
+	if !hasGit() {
+		t.Skip("git not available")
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("SAGE_PROJECT", "")
+
+	repo := t.TempDir()
+	runGit(t, repo, "init")
+	runGit(t, repo, "config", "user.name", "Sage Test")
+	runGit(t, repo, "config", "user.email", "sage@example.com")
+	if err := os.WriteFile(repo+"/file.txt", []byte("hello"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	runGit(t, repo, "add", ".")
+	runGit(t, repo, "commit", "-m", "spooled commit")
+
+	// A directory where the database should be makes every open fail.
+	dbPath := globalDBPath()
+	if err := os.Mkdir(dbPath, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	_ = runHookPostCommit(repo)
+	_ = runHookPostCommit(repo)
+
+	files, err := readSpool(spoolDir())
+	if err != nil || len(files) != 2 {
+		t.Fatalf("expected both runs spooled, got %d files (%v)", len(files), err)
+	}
+	if got := checkSpool(spoolDir()); got.OK || !strings.Contains(got.Problems[0].Problem, "2 hook event(s) are waiting") {
+		t.Fatalf("doctor should report the backlog: %+v", got)
+	}
+
+	// Once the database opens, the spool drains and the ID dedupes.
+	if err := os.Remove(dbPath); err != nil {
+		t.Fatal(err)
+	}
+	s, err := openGlobalStore()
+	if err != nil {
+		t.Fatalf("openGlobalStore: %v", err)
+	}
+	events, err := store.Collect(s.Query(context.Background(), store.Filter{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Title != "spooled commit" {
+		t.Fatalf("expected the spooled commit once, got %+v", events)
+	}
+	if files, _ := readSpool(spoolDir()); len(files) != 0 {
+		t.Fatalf("spool should be empty after draining, has %d", len(files))
+	}
+
+	// Draining a spooled duplicate later records nothing new.
+	if err := spoolEvent(events[0]); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := drainSpool(s); err != nil || n != 0 {
+		t.Fatalf("drain of a duplicate: %d, %v", n, err)
+	}
+	if files, _ := readSpool(spoolDir()); len(files) != 0 {
+		t.Fatalf("a drained duplicate should leave the spool, has %d", len(files))
+	}
+}
+
+func TestRunHookPostCommit_SpoolsConstraintFailures(t *testing.T) {
+	if !hasGit() {
+		t.Skip("git not available")
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("SAGE_PROJECT", "")
+
+	repo := t.TempDir()
+	runGit(t, repo, "init")
+	runGit(t, repo, "config", "user.name", "Sage Test")
+	runGit(t, repo, "config", "user.email", "sage@example.com")
+	runGit(t, repo, "commit", "--allow-empty", "-m", "rejected commit")
+
+	// A failure that is not a duplicate ID must not be mistaken for one.
+	if _, err := openGlobalStore(); err != nil {
+		t.Fatalf("openGlobalStore: %v", err)
+	}
+	db, err := sql.Open("sqlite", globalDBPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TRIGGER reject BEFORE INSERT ON events BEGIN SELECT RAISE(ABORT, 'rejected'); END;`); err != nil {
+		t.Fatalf("create trigger: %v", err)
+	}
+	_ = runHookPostCommit(repo)
+
+	if files, err := readSpool(spoolDir()); err != nil || len(files) != 1 {
+		t.Fatalf("expected the rejected commit spooled, got %d files (%v)", len(files), err)
+	}
+
+	// Once inserts succeed again, the spooled commit is recorded.
+	if _, err := db.Exec(`DROP TRIGGER reject;`); err != nil {
+		t.Fatal(err)
+	}
+	s, err := openGlobalStore()
+	if err != nil {
+		t.Fatalf("openGlobalStore: %v", err)
+	}
+	events, err := store.Collect(s.Query(context.Background(), store.Filter{}))
+	if err != nil || len(events) != 1 || events[0].Title != "rejected commit" {
+		t.Fatalf("expected the spooled commit recorded, got %+v (%v)", events, err)
+	}
+}
+
 func TestHookScriptInvokesSageWithRepo(t *testing.T) {
 	// Quick sanity: the hook script should pass --repo to avoid ambiguity.
 	dir := t.TempDir()
@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/divijg19/sage/internal/event"
+	"github.com/divijg19/sage/internal/store"
+)
+
+// Hooks must never block Git, so an event a hook cannot record (the database
+// is locked past every retry, mid-migration or unreadable) is spooled to
+// ~/.sage/spool/ as one JSON file and recorded by the next successful open.
+// Hook event IDs are deterministic, so draining twice records nothing twice.
+
+func spoolDir() string {
+	dir := sageDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "spool")
+}
+
+// spoolEvent writes e to the spool. Files are named by spool time so they
+// drain in order, and appear atomically so a drain never reads half a file.
+func spoolEvent(e event.Event) error {
+	dir := spoolDir()
+	if dir == "" {
+		return fmt.Errorf("could not determine Sage directory")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, ".spool-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	sum := sha1.Sum([]byte(e.ID))
+	name := fmt.Sprintf("%020d-%s.json", time.Now().UnixNano(), hex.EncodeToString(sum[:6]))
+	return os.Rename(tmp.Name(), filepath.Join(dir, name))
+}
+
+// spooledFile is one file in the spool, decoded if it could be.
+type spooledFile struct {
+	path  string
+	event event.Event
+	err   error
+}
+
+// readSpool lists the spool in dir oldest first. A missing spool is empty.
+func readSpool(dir string) ([]spooledFile, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var out []spooledFile
+	for _, de := range entries {
+		if de.IsDir() || !strings.HasSuffix(de.Name(), ".json") {
+			continue
+		}
+		f := spooledFile{path: filepath.Join(dir, de.Name())}
+		b, err := os.ReadFile(f.path)
+		if err == nil {
+			err = json.Unmarshal(b, &f.event)
+		}
+		if err == nil && strings.TrimSpace(f.event.ID) == "" {
+			err = errors.New("event has no id")
+		}
+		f.err = err
+		out = append(out, f)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].path < out[j].path })
+	return out, nil
+}
+
+// drainSpool records every readable spooled event in s and removes its file.
+// Files that do not decode stay put for sage doctor to report. Two processes
+// draining at once is harmless: duplicates are skipped and a file already
+// removed is not an error.
+func drainSpool(s *store.Store) (int, error) {
+	files, err := readSpool(spoolDir())
+	if err != nil || len(files) == 0 {
+		return 0, err
+	}
+
+	var events []event.Event
+	var drained []string
+	for _, f := range files {
+		if f.err != nil {
+			continue
+		}
+		events = append(events, f.event)
+		drained = append(drained, f.path)
+	}
+
+	events, err = withTrailerLinks(s, events)
+	if err != nil {
+		return 0, err
+	}
+	inserted, err := s.ImportEvents(events)
+	if err != nil {
+		return inserted, err
+	}
+	for _, path := range drained {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return inserted, err
+		}
+	}
+	return inserted, nil
+}
@@ -0,0 +1,219 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/divijg19/sage/internal/event"
+	"github.com/divijg19/sage/internal/store"
+)
+
+// Commit-message trailers that tie a commit to Sage entries:
+//
+//	Sage-Decision: 42     the commit implements decision 42
+//	Sage-Refs: 17, 18     the commit references entries 17 and 18
+//	Sage-Tags: auth       the commit event is tagged #auth
+//
+// Keys match case-insensitively, as Git's own trailers do.
+const (
+	trailerDecision = "Sage-Decision"
+	trailerRefs     = "Sage-Refs"
+	trailerTags     = "Sage-Tags"
+)
+
+// Commit metadata keys holding the entry IDs named by trailers, so the links
+// can be resolved whenever the commit is recorded (including from the spool).
+const (
+	metaSageDecisions = "sage_decisions"
+	metaSageRefs      = "sage_refs"
+)
+
+type commitTrailers struct {
+	Decisions []int64
+	Refs      []int64
+	Tags      []string
+}
+
+// parseCommitTrailers reads the Sage trailers out of Git's trailer block, one
+// "Key: value" per line as printed by %(trailers:only,unfold). Values that are
+// not entry IDs are ignored: a typo in a commit message must not lose the commit.
+func parseCommitTrailers(raw string) commitTrailers {
+	var t commitTrailers
+	var tags []string
+	for _, line := range strings.Split(raw, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields := strings.FieldsFunc(value, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+		switch key = strings.TrimSpace(key); {
+		case strings.EqualFold(key, trailerDecision):
+			t.Decisions = appendEntryIDs(t.Decisions, fields)
+		case strings.EqualFold(key, trailerRefs):
+			t.Refs = appendEntryIDs(t.Refs, fields)
+		case strings.EqualFold(key, trailerTags):
+			for _, f := range fields {
+				tags = append(tags, strings.TrimPrefix(f, "#"))
+			}
+		}
+	}
+	t.Tags = parseTags(tags)
+	return t
+}
+
+func appendEntryIDs(ids []int64, fields []string) []int64 {
+	for _, f := range fields {
+		seq, err := strconv.ParseInt(strings.Trim(f, "#[]"), 10, 64)
+		if err == nil && seq > 0 {
+			ids = append(ids, seq)
+		}
+	}
+	return ids
+}
+
+// apply adds the trailer tags to e and notes the linked entry IDs in its
+// metadata for trailerLinks.
+func (t commitTrailers) apply(e *event.Event) {
+	if len(t.Tags) > 0 {
+		e.Tags = parseTags(append(e.Tags, t.Tags...))
+	}
+	if len(t.Decisions) > 0 {
+		e.Metadata[metaSageDecisions] = joinEntryIDs(t.Decisions)
+	}
+	if len(t.Refs) > 0 {
+		e.Metadata[metaSageRefs] = joinEntryIDs(t.Refs)
+	}
+}
+
+func joinEntryIDs(ids []int64) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(parts, ",")
+}
+
+type entryBySeq interface {
+	GetBySeq(seq int64) (*event.Event, error)
+}
+
+// trailerLinks returns the link events declared by a commit's trailers.
+// Entry IDs that do not name a live entry are skipped. Link IDs derive from
+// the commit and target, so recording a commit twice links it once.
+func trailerLinks(s entryBySeq, e event.Event) ([]event.Event, error) {
+	if e.Kind != event.CommitKind {
+		return nil, nil
+	}
+	var out []event.Event
+	for _, declared := range []struct {
+		key string
+		rel event.Relation
+	}{{metaSageDecisions, event.Implements}, {metaSageRefs, event.References}} {
+		for _, raw := range strings.Split(e.Metadata[declared.key], ",") {
+			seq, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+			if err != nil || seq <= 0 {
+				continue
+			}
+			target, err := s.GetBySeq(seq)
+			if err != nil {
+				return nil, err
+			}
+			if target == nil || target.Kind.IsAnnotation() || target.Retracted || target.ID == e.ID {
+				continue
+			}
+			id := fmt.Sprintf("%s:%s:%s", e.ID, declared.rel, target.ID)
+			out = append(out, event.NewLinkEvent(id, e.Timestamp, e, declared.rel, *target))
+		}
+	}
+	return out, nil
+}
+
+// withTrailerLinks follows each event with the links its trailers declare.
+func withTrailerLinks(s entryBySeq, events []event.Event) ([]event.Event, error) {
+	out := make([]event.Event, 0, len(events))
+	for _, e := range events {
+		links, err := trailerLinks(s, e)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+		out = append(out, links...)
+	}
+	return out, nil
+}
+
+// runHookPrepareCommitMsg pre-fills a Sage-Decision trailer naming the most
+// recent decision in the active project. Only messages the user is about to
+// write in the editor are touched: -m, merges, squashes and amends are left
+// alone, as is a message that already names a decision.
+func runHookPrepareCommitMsg(repo string, args []string) error {
+	if len(args) < 1 {
+		return nil
+	}
+	if len(args) > 1 && args[1] != "" && args[1] != "template" {
+		return nil
+	}
+	// Git passes the path relative to where it runs the hook, which is
+	// where the hook script runs sage.
+	path := args[0]
+
+	project := activeProjectFromEnv()
+	if project == "" {
+		r, err := resolveHookRepo(repo)
+		if err != nil {
+			return nil
+		}
+		project = r.project
+	}
+	s, err := openGlobalStore()
+	if err != nil {
+		return nil
+	}
+	decision, err := latestDecision(s, project)
+	if err != nil || decision == nil {
+		return nil
+	}
+	return prefillTrailer(repo, path, fmt.Sprintf("%s: %d", trailerDecision, decision.Seq))
+}
+
+func latestDecision(s *store.Store, project string) (*event.Event, error) {
+	found, err := store.Collect(s.Query(context.Background(), store.Filter{
+		Projects: []string{project},
+		Kinds:    []event.EntryKind{event.DecisionKind},
+		Order:    store.Descending,
+		Limit:    1,
+	}))
+	if err != nil || len(found) == 0 {
+		return nil, err
+	}
+	return &found[0], nil
+}
+
+// prefillTrailer adds trailer to the message file at path unless a trailer
+// with the same key is already there. Git places it before the comment lines.
+func prefillTrailer(repo, path, trailer string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if !hasCommitMessage(string(b)) {
+		// With no message yet, leave the subject line and a blank line for
+		// the user so the trailer ends up in its own paragraph.
+		return os.WriteFile(path, []byte("\n\n"+trailer+"\n"+string(b)), 0o644)
+	}
+	_, err = gitOutput(repo, "interpret-trailers", "--in-place", "--if-exists", "doNothing", "--trailer", trailer, path)
+	return err
+}
+
+func hasCommitMessage(msg string) bool {
+	for _, line := range strings.Split(msg, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/divijg19/sage/internal/event"
+)
+
+func TestParseCommitTrailers(t *testing.T) {
+	got := parseCommitTrailers("Sage-Decision: 42\n" +
+		"sage-refs: 17, #18 nope\n" +
+		"Sage-Tags: auth #API\n" +
+		"Signed-off-by: Someone <s@example.com>\n")
+	want := commitTrailers{Decisions: []int64{42}, Refs: []int64{17, 18}, Tags: []string{"auth", "api"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestRunHookPostCommit_LinksTrailers(t *testing.T) {
+	repo := initHookRepo(t)
+	s, err := openGlobalStore()
+	if err != nil {
+		t.Fatalf("openGlobalStore: %v", err)
+	}
+	for _, e := range []event.Event{
+		{ID: "d1", Kind: event.DecisionKind, Title: "Rotate tokens hourly"},
+		{ID: "r1", Kind: event.RecordKind, Title: "Token leak postmortem"},
+	} {
+		e.Timestamp, e.Project = time.Now(), "p"
+		if err := s.Append(e); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(repo, "rotate.go"), []byte("package p"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, repo, "add", ".")
+	runGit(t, repo, "commit", "-m", "Rotate tokens", "-m", "Sage-Decision: 1\nSage-Refs: 2, 99\nSage-Tags: auth")
+	_ = runHookPostCommit(repo)
+	_ = runHookPostCommit(repo)
+
+	commit, err := s.GetBySeq(3)
+	if err != nil || commit == nil {
+		t.Fatalf("expected the commit as entry 3: %v", err)
+	}
+	if !reflect.DeepEqual(commit.Tags, []string{"git", "commit", "auth"}) {
+		t.Fatalf("unexpected tags: %v", commit.Tags)
+	}
+	links, err := s.LinksFor(commit.ID)
+	if err != nil {
+		t.Fatalf("LinksFor: %v", err)
+	}
+	if len(links) != 2 || links[0].Relation != event.Implements || links[0].To != "d1" ||
+		links[1].Relation != event.References || links[1].To != "r1" {
+		t.Fatalf("unexpected links: %+v", links)
+	}
+
+	viewCmd.SetContext(context.Background())
+	got := captureStdout(t, func() error { return viewCmd.RunE(viewCmd, []string{"1"}) })
+	want := "Implemented by:\n- [3] Rotate tokens (" + shortSHA(commit.Metadata["sha"]) + ")\n"
+	if !strings.Contains(got, want) {
+		t.Fatalf("missing %q in:\n%s", want, got)
+	}
+}
+
+func TestRunHookPrepareCommitMsg_PrefillsLatestDecision(t *testing.T) {
+	repo := initHookRepo(t)
+	s, err := openGlobalStore()
+	if err != nil {
+		t.Fatalf("openGlobalStore: %v", err)
+	}
+	project := normalizeProjectName(filepath.Base(repo))
+	for i, e := range []event.Event{
+		{ID: "old", Kind: event.DecisionKind, Project: project, Title: "Older decision"},
+		{ID: "latest", Kind: event.DecisionKind, Project: project, Title: "Latest decision"},
+		{ID: "note", Kind: event.RecordKind, Project: project, Title: "Later note"},
+		{ID: "other", Kind: event.DecisionKind, Project: "elsewhere", Title: "Other project"},
+	} {
+		e.Timestamp = time.Now().Add(time.Duration(i) * time.Second)
+		if err := s.Append(e); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	msg := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	prepare := func(content string, args ...string) string {
+		t.Helper()
+		if err := os.WriteFile(msg, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_ = runHookPrepareCommitMsg(repo, append([]string{msg}, args...))
+		b, err := os.ReadFile(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	comments := "\n# Please enter the commit message for your changes.\n"
+	if got := prepare(comments); got != "\n\nSage-Decision: 2\n"+comments {
+		t.Fatalf("unexpected pre-filled message: %q", got)
+	}
+	if got := prepare("From a template\n", "template"); got != "From a template\n\nSage-Decision: 2\n" {
+		t.Fatalf("unexpected template message: %q", got)
+	}
+	// -m, amends and messages that already name a decision are left alone.
+	if got := prepare("Typed with -m\n", "message"); got != "Typed with -m\n" {
+		t.Fatalf("a -m message was changed: %q", got)
+	}
+	if got := prepare("Subject\n\nSage-Decision: 1\n"); got != "Subject\n\nSage-Decision: 1\n" {
+		t.Fatalf("an existing decision was changed: %q", got)
+	}
+}
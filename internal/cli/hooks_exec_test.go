@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"errors"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -114,3 +115,79 @@ func TestInstalledHook_RespectsLockDir(t *testing.T) {
 		t.Fatalf("did not expect sage to run while lock dir exists")
 	}
 }
+
+func TestInstalledPrePushHook_ForwardsInputAndLegacyStatus(t *testing.T) {
+	dir := t.TempDir()
+	hooksDir := filepath.Join(dir, "hooks")
+	if err := os.MkdirAll(hooksDir, 0o755); err != nil {
+		t.Fatalf("mkdir hooks: %v", err)
+	}
+
+	sageInput := filepath.Join(dir, "sage.input")
+	legacyInput := filepath.Join(dir, "legacy.input")
+
+	// A legacy pre-push hook that refuses the push.
+	legacyScript := "#!/bin/sh\n" +
+		"cat > \"" + legacyInput + "\"\n" +
+		"exit 3\n"
+	if err := os.WriteFile(filepath.Join(hooksDir, "pre-push"), []byte(legacyScript), 0o755); err != nil {
+		t.Fatalf("write legacy hook: %v", err)
+	}
+	if _, err := InstallHook(hooksDir, "pre-push", HookInstallOptions{Sync: true}); err != nil {
+		t.Fatalf("InstallHook: %v", err)
+	}
+
+	binDir := filepath.Join(dir, "bin")
+	if err := os.MkdirAll(binDir, 0o755); err != nil {
+		t.Fatalf("mkdir bin: %v", err)
+	}
+	fakeSageScript := "#!/bin/sh\n" +
+		"echo \"$@\" > \"" + sageInput + "\"\n" +
+		"cat >> \"" + sageInput + "\"\n" +
+		"exit 1\n"
+	if err := os.WriteFile(filepath.Join(binDir, "sage"), []byte(fakeSageScript), 0o755); err != nil {
+		t.Fatalf("write fake sage: %v", err)
+	}
+
+	run := func() int {
+		t.Helper()
+		cmd := exec.Command("sh", filepath.Join(hooksDir, "pre-push"), "origin", "git@example.com:r.git")
+		cmd.Env = append(os.Environ(), "PATH="+binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+		cmd.Dir = dir
+		cmd.Stdin = strings.NewReader("refs/heads/main abc refs/heads/main def\n")
+		err := cmd.Run()
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+		if err != nil {
+			t.Fatalf("hook exec failed: %v", err)
+		}
+		return 0
+	}
+
+	if code := run(); code != 3 {
+		t.Fatalf("expected the legacy hook's exit status 3, got %d", code)
+	}
+	want := "refs/heads/main abc refs/heads/main def\n"
+	if b, _ := os.ReadFile(legacyInput); string(b) != want {
+		t.Fatalf("legacy hook input = %q, want %q", b, want)
+	}
+	if b, _ := os.ReadFile(sageInput); string(b) != "hook pre-push --repo "+dir+" -- origin git@example.com:r.git\n"+want {
+		t.Fatalf("unexpected sage invocation:\n%s", b)
+	}
+
+	// A run in flight skips Sage, but never the legacy hook's checks.
+	if err := os.Remove(sageInput); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(hooksDir, ".sage-pre-push.lock"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if code := run(); code != 3 {
+		t.Fatalf("expected the legacy hook to run under the lock, got %d", code)
+	}
+	if _, err := os.Stat(sageInput); err == nil {
+		t.Fatalf("did not expect sage to run while lock dir exists")
+	}
+}
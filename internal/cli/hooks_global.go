@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Global hooks live in ~/.sage/hooks, which `git config --global
+// core.hooksPath` points every repository at. Git then stops running
+// .git/hooks, so each global hook chains the repository's own hook itself.
+
+func globalHooksDir() string {
+	dir := sageDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "hooks")
+}
+
+// globalCoreHooksPath returns core.hooksPath from the global Git config, with
+// a leading ~/ expanded as Git expands it. Empty means unset.
+func globalCoreHooksPath() string {
+	p, err := gitOutput("", "config", "--global", "--get", "core.hooksPath")
+	if err != nil {
+		return ""
+	}
+	return expandHome(p)
+}
+
+func expandHome(p string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, p[1:])
+		}
+	}
+	return p
+}
+
+// samePath reports whether a and b name the same directory, following
+// symlinks where they exist.
+func samePath(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	resolve := func(p string) string {
+		if r, err := filepath.EvalSymlinks(p); err == nil {
+			return r
+		}
+		return filepath.Clean(p)
+	}
+	return resolve(a) == resolve(b)
+}
+
+type globalInstallResult struct {
+	HookInstallResult
+	HooksDir string
+	// Previous is the core.hooksPath that --force replaced, if any.
+	Previous string
+}
+
+// installGlobalHook writes hook to the global hooks directory and points the
+// global core.hooksPath at it. A core.hooksPath already set to another
+// directory (a team's shared hooks, say) is only replaced with Force.
+func installGlobalHook(hook string, opts HookInstallOptions) (globalInstallResult, error) {
+	dir := globalHooksDir()
+	if dir == "" {
+		return globalInstallResult{}, fmt.Errorf("could not determine Sage directory")
+	}
+	res := globalInstallResult{HooksDir: dir}
+
+	current := globalCoreHooksPath()
+	pointed := samePath(current, dir)
+	if current != "" && !pointed {
+		if !opts.Force {
+			return res, fmt.Errorf("core.hooksPath is already set globally to %s; rerun with --force to replace it with %s", current, dir)
+		}
+		res.Previous = current
+	}
+
+	opts.Global = true
+	installed, err := InstallHook(dir, hook, opts)
+	if err != nil {
+		return res, err
+	}
+	res.HookInstallResult = installed
+
+	if !pointed && !opts.DryRun {
+		if _, err := gitOutput("", "config", "--global", "core.hooksPath", dir); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// uninstallGlobalHook removes hook from the global hooks directory. Once no
+// Sage hook is left there, the global core.hooksPath is unset so repositories
+// run .git/hooks again; the returned flag reports that.
+func uninstallGlobalHook(hook string, opts HookInstallOptions) (string, bool, error) {
+	dir := globalHooksDir()
+	if dir == "" {
+		return "", false, fmt.Errorf("could not determine Sage directory")
+	}
+	msg, err := UninstallHook(dir, hook, opts)
+	if err != nil {
+		return "", false, err
+	}
+	if !samePath(globalCoreHooksPath(), dir) {
+		return msg, false, nil
+	}
+	for _, h := range supportedHooks {
+		if opts.DryRun && h == hook {
+			continue
+		}
+		if ins, err := InspectHook(dir, h); err != nil || ins.Exists {
+			return msg, false, err
+		}
+	}
+	if !opts.DryRun {
+		if _, err := gitOutput("", "config", "--global", "--unset", "core.hooksPath"); err != nil {
+			return msg, false, err
+		}
+	}
+	return msg, true, nil
+}
+
+// repoCoverage is whether the global hook runs in one repository.
+type repoCoverage struct {
+	Root    string
+	Covered bool
+	// Note explains a repository that is not covered, or what the global
+	// hook does there besides recording.
+	Note string
+}
+
+// globalHookCoverage checks each repository in roots. Git only runs the
+// global hooks where no repository-level core.hooksPath overrides them.
+func globalHookCoverage(hook string, roots []string) []repoCoverage {
+	dir := globalHooksDir()
+	out := make([]repoCoverage, 0, len(roots))
+	for _, root := range roots {
+		c := repoCoverage{Root: root}
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			c.Note = "missing"
+			out = append(out, c)
+			continue
+		}
+		hooksDir, coreHooksPath, err := gitHooksDir(root)
+		switch {
+		case err != nil:
+			c.Note = "not a Git repository"
+		case samePath(hooksDir, dir):
+			c.Covered = true
+			c.Note = localHookNote(root, hook)
+		case coreHooksPath != "":
+			c.Note = "core.hooksPath is " + coreHooksPath
+		default:
+			c.Note = "global core.hooksPath is not set"
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// localHookNote describes the repository's own hook, which the global hook
+// chains (or hands over to, when it is a Sage hook).
+func localHookNote(root, hook string) string {
+	common, err := gitCommonDirAbs(root)
+	if err != nil {
+		return ""
+	}
+	ins, err := InspectHook(filepath.Join(common, "hooks"), hook)
+	switch {
+	case err != nil || !ins.Exists:
+		return ""
+	case ins.SageManaged:
+		return "uses its own Sage hook " + ins.HookPath
+	default:
+		return "chains " + ins.HookPath
+	}
+}
+
+// coveredRepoRoots lists the repositories to report on: those Sage has
+// recorded from, plus the current one.
+func coveredRepoRoots(known []string, repo string) []string {
+	roots := append([]string(nil), known...)
+	if root, err := gitRepoRoot(repo); err == nil {
+		found := false
+		for _, r := range roots {
+			found = found || samePath(r, root)
+		}
+		if !found {
+			roots = append(roots, root)
+		}
+	}
+	return roots
+}
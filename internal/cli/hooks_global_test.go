@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// isolateGlobalGit points HOME and the global Git config at a temporary
+// directory, so tests can set core.hooksPath globally.
+func isolateGlobalGit(t *testing.T) string {
+	t.Helper()
+	if !hasGit() {
+		t.Skip("git not available")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(home, ".gitconfig"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("SAGE_PROJECT", "")
+	return home
+}
+
+func initGlobalTestRepo(t *testing.T) string {
+	t.Helper()
+	repo := t.TempDir()
+	runGit(t, repo, "init", "-q")
+	runGit(t, repo, "config", "user.name", "Sage Test")
+	runGit(t, repo, "config", "user.email", "sage@example.com")
+	return repo
+}
+
+// fakeSageOnPath puts a sage that logs each run on PATH and returns the log.
+func fakeSageOnPath(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	log := filepath.Join(dir, "sage.log")
+	script := "#!/bin/sh\necho \"$@\" >> \"" + log + "\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "sage"), []byte(script), 0o755); err != nil {
+		t.Fatalf("write fake sage: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return log
+}
+
+func TestInstallGlobalHook_SetsCoreHooksPath(t *testing.T) {
+	home := isolateGlobalGit(t)
+
+	res, err := installGlobalHook("post-commit", HookInstallOptions{Sync: true})
+	if err != nil {
+		t.Fatalf("installGlobalHook: %v", err)
+	}
+	dir := filepath.Join(home, ".sage", "hooks")
+	if res.HooksDir != dir || res.HookPath != filepath.Join(dir, "post-commit") {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if got := globalCoreHooksPath(); got != dir {
+		t.Fatalf("expected the global core.hooksPath to be %s, got %q", dir, got)
+	}
+	ins, err := InspectHook(dir, "post-commit")
+	if err != nil || !ins.SageManaged || !ins.Global {
+		t.Fatalf("expected a global Sage hook, got %+v (%v)", ins, err)
+	}
+
+	// Someone else's shared hooks are only replaced with --force.
+	runGit(t, home, "config", "--global", "core.hooksPath", "~/team-hooks")
+	if _, err := installGlobalHook("pre-push", HookInstallOptions{}); err == nil || !strings.Contains(err.Error(), filepath.Join(home, "team-hooks")) {
+		t.Fatalf("expected a refusal naming the current core.hooksPath, got %v", err)
+	}
+	res, err = installGlobalHook("pre-push", HookInstallOptions{Force: true})
+	if err != nil || res.Previous != filepath.Join(home, "team-hooks") || globalCoreHooksPath() != dir {
+		t.Fatalf("expected --force to replace core.hooksPath, got %+v (%v)", res, err)
+	}
+
+	// Per-repository installs must not rewrite the shared hooks.
+	repo := initGlobalTestRepo(t)
+	hooksDir, _, err := gitHooksDir(repo)
+	if err != nil {
+		t.Fatalf("gitHooksDir: %v", err)
+	}
+	if err := refuseGlobalHooksDir(hooksDir); err == nil {
+		t.Fatalf("expected per-repository install into %s to be refused", hooksDir)
+	}
+
+	// The global setting goes away with the last global hook.
+	if msg, unset, err := uninstallGlobalHook("post-commit", HookInstallOptions{}); err != nil || unset || msg != "removed Sage hook" {
+		t.Fatalf("first uninstall = %q, %t, %v", msg, unset, err)
+	}
+	if _, unset, err := uninstallGlobalHook("pre-push", HookInstallOptions{}); err != nil || !unset || globalCoreHooksPath() != "" {
+		t.Fatalf("expected the last uninstall to unset core.hooksPath (%t, %v)", unset, err)
+	}
+}
+
+func TestGlobalHook_ChainsEachRepositorysOwnHook(t *testing.T) {
+	isolateGlobalGit(t)
+	log := fakeSageOnPath(t)
+	if _, err := installGlobalHook("post-commit", HookInstallOptions{Sync: true}); err != nil {
+		t.Fatalf("installGlobalHook: %v", err)
+	}
+
+	// A repository with its own hook: Sage records, then that hook runs.
+	chained := initGlobalTestRepo(t)
+	ran := filepath.Join(t.TempDir(), "local.ran")
+	local := "#!/bin/sh\ntouch \"" + ran + "\"\n"
+	if err := os.WriteFile(filepath.Join(chained, ".git", "hooks", "post-commit"), []byte(local), 0o755); err != nil {
+		t.Fatalf("write local hook: %v", err)
+	}
+	runGit(t, chained, "commit", "-q", "--allow-empty", "-m", "chained")
+	if _, err := os.Stat(ran); err != nil {
+		t.Fatalf("expected the repository's own hook to run: %v", err)
+	}
+
+	// A repository with its own Sage hook keeps it, and Sage runs once.
+	own := initGlobalTestRepo(t)
+	if _, err := InstallHook(filepath.Join(own, ".git", "hooks"), "post-commit", HookInstallOptions{Sync: true}); err != nil {
+		t.Fatalf("InstallHook: %v", err)
+	}
+	runGit(t, own, "commit", "-q", "--allow-empty", "-m", "own hook")
+
+	b, _ := os.ReadFile(log)
+	if n := strings.Count(string(b), "hook post-commit"); n != 2 {
+		t.Fatalf("expected sage to run once per commit, ran %d times:\n%s", n, b)
+	}
+
+	// A repository-level core.hooksPath takes precedence over the global one.
+	overridden := initGlobalTestRepo(t)
+	runGit(t, overridden, "config", "core.hooksPath", ".husky")
+
+	repos := globalHookCoverage("post-commit", []string{chained, own, overridden, filepath.Join(chained, "gone")})
+	want := []struct {
+		covered bool
+		note    string
+	}{
+		{true, "chains "},
+		{true, "uses its own Sage hook "},
+		{false, "core.hooksPath is .husky"},
+		{false, "missing"},
+	}
+	for i, w := range want {
+		if repos[i].Covered != w.covered || !strings.HasPrefix(repos[i].Note, w.note) {
+			t.Fatalf("repo %d: got %+v, want covered=%t note %q", i, repos[i], w.covered, w.note)
+		}
+	}
+}
@@ -14,6 +14,9 @@ type HookInstallOptions struct {
 	Force  bool
 	DryRun bool
 	Sync   bool
+	// Global writes a hook for a hooks directory shared through
+	// core.hooksPath, which also runs each repository's own hook.
+	Global bool
 }
 
 type HookInspect struct {
@@ -21,6 +24,9 @@ type HookInspect struct {
 	Exists         bool
 	SageManaged    bool
 	LegacyHookPath string
+	// Global marks a Sage hook written by install --global, which chains
+	// each repository's own hook.
+	Global bool
 }
 
 func InspectHook(hooksDir, hookName string) (HookInspect, error) {
@@ -40,6 +46,7 @@ func InspectHook(hooksDir, hookName string) (HookInspect, error) {
 		Exists:         true,
 		SageManaged:    isSageManagedHook(content, hookName),
 		LegacyHookPath: legacy,
+		Global:         strings.Contains(content, "\nLOCAL_HOOK="),
 	}, nil
 }
 
@@ -90,7 +97,7 @@ func InstallHook(hooksDir, hookName string, opts HookInstallOptions) (HookInstal
 		res.LegacyChained = strings.TrimSpace(legacyPath) != ""
 	}
 
-	script := renderHookScript(hookName, legacyPath, opts.Sync)
+	script := renderHookScript(hookName, legacyPath, opts)
 
 	if !opts.DryRun {
 		if err := os.MkdirAll(hooksDir, 0o755); err != nil {
@@ -162,9 +169,13 @@ func backupHookFile(hookPath, hookName string, dryRun bool) (string, error) {
 	return candidate, nil
 }
 
+// hookMarker is the line that identifies a script as Sage's hook.
+func hookMarker(hookName string) string {
+	return "# sage-hook: " + hookName + " v1"
+}
+
 func isSageManagedHook(content string, hookName string) bool {
-	needle := "# sage-hook: " + hookName + " v1"
-	return strings.Contains(content, needle)
+	return strings.Contains(content, hookMarker(hookName))
 }
 
 var legacyHookRe = regexp.MustCompile(`(?m)^LEGACY_HOOK=(?:"([^"]*)"|'([^']*)'|([^\s#]*))\s*$`)
@@ -182,29 +193,132 @@ func parseLegacyHookPath(content string) string {
 	return ""
 }
 
-func renderHookScript(hookName, legacyHookPath string, sync bool) string {
+// hookSpec describes how Git runs a hook, which decides how the script
+// forwards its input and exit status.
+type hookSpec struct {
+	// Stdin hooks receive their input on standard input. The script reads it
+	// once and hands a copy to Sage and to the chained hook.
+	Stdin bool
+	// Gating hooks can abort the Git command. Sage never does, but the chained
+	// hook's exit status is passed on so its checks still apply.
+	Gates bool
+	// Foreground hooks always run Sage before Git goes on, even without
+	// --sync, because Sage edits a file Git reads next.
+	Foreground bool
+}
+
+var hookSpecs = map[string]hookSpec{
+	"post-commit":        {},
+	"post-checkout":      {},
+	"post-merge":         {},
+	"post-rewrite":       {Stdin: true},
+	"prepare-commit-msg": {Gates: true, Foreground: true},
+	"pre-push":           {Stdin: true, Gates: true},
+}
+
+func renderHookScript(hookName, legacyHookPath string, opts HookInstallOptions) string {
+	spec := hookSpecs[hookName]
+
 	legacyLine := "LEGACY_HOOK=\"\""
 	if strings.TrimSpace(legacyHookPath) != "" {
 		legacyLine = "LEGACY_HOOK=\"" + escapeForDoubleQuotes(legacyHookPath) + "\""
 	}
 
+	sageCmd := "sage hook " + hookName + " --repo \"$REPO_DIR\" -- \"$@\" >/dev/null 2>&1"
+	legacyCmd := "\"${LEGACY_HOOK}\" \"$@\""
+	localCmd := "\"${LOCAL_HOOK}\" \"$@\""
+	input := ""
+	if spec.Stdin {
+		sageCmd = "hook_input | " + sageCmd
+		legacyCmd = "hook_input | " + legacyCmd
+		localCmd = "hook_input | " + localCmd
+		input = `
+# Git passes this hook's input on stdin; keep a copy for each reader.
+HOOK_INPUT="$(cat)"
+hook_input() { [ -z "$HOOK_INPUT" ] || printf '%s\n' "$HOOK_INPUT"; }
+`
+	}
+
 	var sageInvoke string
-	if sync {
-		sageInvoke = "sage hook " + hookName + " --repo \"$REPO_DIR\" >/dev/null 2>&1 || true"
+	if opts.Sync || spec.Foreground {
+		sageInvoke = sageCmd + " || true"
 	} else {
-		sageInvoke = "( sage hook " + hookName + " --repo \"$REPO_DIR\" >/dev/null 2>&1 || true ) &"
+		sageInvoke = "( " + sageCmd + " || true ) &"
 	}
 
+	// A global hook runs for every repository, so it takes the lock inside
+	// the repository instead of the shared hooks directory, and chains the
+	// repository's own hook after any legacy one.
+	local, lockDir, chainLocal := "", "$HOOK_DIR", ""
+	if opts.Global {
+		local = fmt.Sprintf(`
+# Installed in a shared core.hooksPath: the repository's own hook still runs.
+COMMON_DIR="$(git rev-parse --git-common-dir 2>/dev/null)"
+LOCAL_HOOK="${COMMON_DIR:+$COMMON_DIR/hooks/%[1]s}"
+# A repository with its own Sage hook keeps it, so nothing is recorded twice.
+if [ -n "$LOCAL_HOOK" ] && [ -x "$LOCAL_HOOK" ] && grep -q '^%[2]s$' "$LOCAL_HOOK" 2>/dev/null; then
+	exec "$LOCAL_HOOK" "$@"
+fi
+`, hookName, hookMarker(hookName))
+		lockDir = "${COMMON_DIR:-$HOOK_DIR}"
+	}
+
+	if spec.Gates {
+		chainLegacy := legacyCmd + "\n\texit $?"
+		if opts.Global {
+			chainLegacy = legacyCmd + " || exit $?"
+			chainLocal = fmt.Sprintf(`
+# Chain the repository's own hook; it may still stop the Git command.
+if [ -n "${LOCAL_HOOK:-}" ] && [ -x "${LOCAL_HOOK}" ]; then
+	%s
+	exit $?
+fi
+`, localCmd)
+		}
+		return strings.TrimSpace(fmt.Sprintf(`#!/bin/sh
+%s
+
+# Sage never blocks Git here; only the chained hook's exit status counts.
+%s
+%s%s
+# Best-effort reentrancy guard (no env vars). It only ever skips Sage.
+REPO_DIR="$(pwd)"
+HOOK_DIR="$(CDPATH= cd -- "$(dirname -- "$0")" 2>/dev/null && pwd)"
+LOCK_DIR="%s/.sage-%s.lock"
+if mkdir "$LOCK_DIR" 2>/dev/null; then
+	trap 'rmdir "$LOCK_DIR" 2>/dev/null || true' EXIT
+	if command -v sage >/dev/null 2>&1; then
+		%s
+	fi
+fi
+
+# Chain legacy hook if present; it may still stop the Git command.
+if [ -n "${LEGACY_HOOK:-}" ] && [ -x "${LEGACY_HOOK}" ]; then
+	%s
+fi
+%s
+exit 0
+`, hookMarker(hookName), legacyLine, local, input, lockDir, hookName, sageInvoke, chainLegacy, chainLocal)) + "\n"
+	}
+
+	if opts.Global {
+		chainLocal = fmt.Sprintf(`
+# Chain the repository's own hook (best-effort).
+if [ -n "${LOCAL_HOOK:-}" ] && [ -x "${LOCAL_HOOK}" ]; then
+	%s || true
+fi
+`, localCmd)
+	}
 	return strings.TrimSpace(fmt.Sprintf(`#!/bin/sh
-# sage-hook: %s v1
+%s
 
 # Never block commits. If anything fails, exit 0.
 %s
-
+%s%s
 # Best-effort reentrancy guard (no env vars).
 REPO_DIR="$(pwd)"
 HOOK_DIR="$(CDPATH= cd -- "$(dirname -- "$0")" 2>/dev/null && pwd)"
-LOCK_DIR="$HOOK_DIR/.sage-%s.lock"
+LOCK_DIR="%s/.sage-%s.lock"
 if ! mkdir "$LOCK_DIR" 2>/dev/null; then
 	exit 0
 fi
@@ -216,11 +330,11 @@ fi
 
 # Chain legacy hook if present (best-effort).
 if [ -n "${LEGACY_HOOK:-}" ] && [ -x "${LEGACY_HOOK}" ]; then
-	"${LEGACY_HOOK}" "$@" || true
+	%s || true
 fi
-
+%s
 exit 0
-`, hookName, legacyLine, hookName, sageInvoke)) + "\n"
+`, hookMarker(hookName), legacyLine, local, input, lockDir, hookName, sageInvoke, legacyCmd, chainLocal)) + "\n"
 }
 
 func escapeForDoubleQuotes(s string) string {
@@ -9,6 +9,9 @@ import (
 
 var stdinReader = bufio.NewReader(os.Stdin)
 
+// stdinInteractive reports whether prompts can be answered; tests replace it.
+var stdinInteractive = stdinIsTTY
+
 // prompt displays a label and reads a single line of input.
 func prompt(label string) string {
 	fmt.Print(label)
@@ -38,3 +41,21 @@ func confirmDefaultYes(label string) bool {
 	}
 	return !(input == "n" || input == "no")
 }
+
+// stderrIsTTY reports whether progress written to stderr reaches a terminal.
+func stderrIsTTY() bool {
+	fi, err := os.Stderr.Stat()
+	if err != nil {
+		return false
+	}
+	return (fi.Mode() & os.ModeCharDevice) != 0
+}
+
+// stdoutIsTTY reports whether output goes to a terminal rather than a pipe or file.
+func stdoutIsTTY() bool {
+	fi, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return (fi.Mode() & os.ModeCharDevice) != 0
+}
@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// pageOutput runs write against stdout. When stdout is a terminal and the
+// output is taller than it, the output is shown through $PAGER (less by
+// default) instead. Only the first screen is held back to decide; the rest
+// streams into the pager as write produces it.
+func pageOutput(write func(w io.Writer) error) error {
+	if !stdoutIsTTY() {
+		return write(os.Stdout)
+	}
+	_, height, err := term.GetSize(os.Stdout.Fd())
+	if err != nil || height <= 0 {
+		return write(os.Stdout)
+	}
+
+	p := &pagedWriter{height: height, out: os.Stdout, pager: pagerCommand()}
+	err = write(p)
+	if cerr := p.close(); err == nil {
+		err = cerr
+	}
+	if errors.Is(err, errPagerQuit) {
+		// Quitting the pager before the end is not an error.
+		return nil
+	}
+	return err
+}
+
+// errPagerQuit is returned by writes after the pager has exited.
+var errPagerQuit = errors.New("pager exited")
+
+// pagedWriter holds output until it is taller than height lines, then starts
+// the pager and passes everything through to it. Output that never outgrows
+// one screen goes to out when the writer is closed.
+type pagedWriter struct {
+	height int
+	out    io.Writer
+	pager  []string
+
+	buf   bytes.Buffer
+	lines int
+	// dst is where output goes once the pager was started (or could not be).
+	dst   io.Writer
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+}
+
+func (p *pagedWriter) Write(b []byte) (int, error) {
+	if p.dst != nil {
+		n, err := p.dst.Write(b)
+		return n, pagerWriteErr(err)
+	}
+	p.buf.Write(b)
+	p.lines += bytes.Count(b, []byte("\n"))
+	if p.lines < p.height {
+		return len(b), nil
+	}
+	if err := p.start(); err != nil {
+		return 0, err
+	}
+	_, err := p.dst.Write(p.buf.Bytes())
+	p.buf.Reset()
+	if err != nil {
+		return 0, pagerWriteErr(err)
+	}
+	return len(b), nil
+}
+
+// start runs the pager. A missing pager falls back to printing directly.
+func (p *pagedWriter) start() error {
+	cmd := exec.Command(p.pager[0], p.pager[1:]...)
+	cmd.Stdout = p.out
+	cmd.Stderr = os.Stderr
+	if os.Getenv("LESS") == "" {
+		// Quit if one screen, keep ANSI colours, leave the screen as is (like git).
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		stdin.Close()
+		if errors.Is(err, exec.ErrNotFound) || errors.Is(err, fs.ErrNotExist) {
+			p.dst = p.out
+			return nil
+		}
+		return err
+	}
+	p.cmd, p.stdin, p.dst = cmd, stdin, stdin
+	return nil
+}
+
+// close prints output that fit on one screen, or waits for the pager.
+func (p *pagedWriter) close() error {
+	if p.dst == nil {
+		_, err := p.out.Write(p.buf.Bytes())
+		return err
+	}
+	if p.cmd == nil {
+		return nil
+	}
+	p.stdin.Close()
+	if err := p.cmd.Wait(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// pagerWriteErr reports a write to a pager that has already exited as
+// errPagerQuit.
+func pagerWriteErr(err error) error {
+	if errors.Is(err, syscall.EPIPE) || errors.Is(err, os.ErrClosed) {
+		return errPagerQuit
+	}
+	return err
+}
+
+// pagerCommand is $PAGER split into arguments, or less.
+func pagerCommand() []string {
+	pager := strings.TrimSpace(os.Getenv("PAGER"))
+	if pager == "" {
+		pager = "less"
+	}
+	return strings.Fields(pager)
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakePager writes a pager script that copies its input to a file and returns
+// its command and that file.
+func fakePager(t *testing.T) ([]string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	paged := filepath.Join(dir, "paged.txt")
+	script := filepath.Join(dir, "pager")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\ncat > \"$1\"\n"), 0o755); err != nil {
+		t.Fatalf("write fake pager: %v", err)
+	}
+	return []string{script, paged}, paged
+}
+
+func TestPagedWriter_ShortOutputSkipsThePager(t *testing.T) {
+	pager, paged := fakePager(t)
+	var out bytes.Buffer
+	p := &pagedWriter{height: 5, out: &out, pager: pager}
+	fmt.Fprint(p, "one\ntwo\n")
+	if err := p.close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if out.String() != "one\ntwo\n" || p.cmd != nil {
+		t.Fatalf("expected output printed directly, got %q (pager started: %t)", out.String(), p.cmd != nil)
+	}
+	if _, err := os.Stat(paged); err == nil {
+		t.Fatalf("the pager should not have run")
+	}
+}
+
+func TestPagedWriter_StreamsLongOutputIntoThePager(t *testing.T) {
+	pager, paged := fakePager(t)
+	var out bytes.Buffer
+	p := &pagedWriter{height: 3, out: &out, pager: pager}
+
+	var want strings.Builder
+	for i := range 10 {
+		line := fmt.Sprintf("line %d\n", i)
+		want.WriteString(line)
+		if _, err := p.Write([]byte(line)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		// The pager starts once a screen is full, not when output ends.
+		if started := p.cmd != nil; started != (i >= 2) {
+			t.Fatalf("after %d lines: pager started = %t", i+1, started)
+		}
+		if p.cmd != nil && p.buf.Len() != 0 {
+			t.Fatalf("output should stream once the pager runs, %d bytes held", p.buf.Len())
+		}
+	}
+	if err := p.close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	got, err := os.ReadFile(paged)
+	if err != nil || string(got) != want.String() || out.Len() != 0 {
+		t.Fatalf("expected everything paged, got %q (%v), stdout %q", got, err, out.String())
+	}
+}
+
+func TestPagedWriter_MissingPagerPrintsDirectly(t *testing.T) {
+	var out bytes.Buffer
+	p := &pagedWriter{height: 1, out: &out, pager: []string{filepath.Join(t.TempDir(), "no-such-pager")}}
+	if _, err := fmt.Fprint(p, "one\ntwo\n"); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := p.close(); err != nil || out.String() != "one\ntwo\n" {
+		t.Fatalf("expected a direct fallback, got %q (%v)", out.String(), err)
+	}
+}
+
+func TestPagedWriter_PagerQuittingEarlyStopsWrites(t *testing.T) {
+	var out bytes.Buffer
+	p := &pagedWriter{height: 1, out: &out, pager: []string{"true"}}
+	line := []byte(strings.Repeat("x", 1023) + "\n")
+	var err error
+	// Write well past the pipe buffer so a write lands after the pager exits.
+	for i := 0; i < 4096 && err == nil; i++ {
+		_, err = p.Write(line)
+	}
+	if !errors.Is(err, errPagerQuit) {
+		t.Fatalf("expected writes to stop with errPagerQuit, got %v", err)
+	}
+	if err := p.close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/divijg19/sage/internal/output"
+)
+
+var outputFormatFlag string
+
+// outputFormat resolves the global --format flag.
+func outputFormat() (output.Format, error) {
+	f, ok := output.ParseFormat(outputFormatFlag)
+	if !ok {
+		names := make([]string, 0, len(output.Formats()))
+		for _, f := range output.Formats() {
+			names = append(names, string(f))
+		}
+		return "", fmt.Errorf("unknown --format %q (use %s)", outputFormatFlag, strings.Join(names, ", "))
+	}
+	return f, nil
+}
+
+// writeOutput writes doc to stdout in a structured format.
+func writeOutput(f output.Format, doc output.Document) error {
+	return output.Write(os.Stdout, f, doc)
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&outputFormatFlag, "format", string(output.FormatText),
+		"output format for read commands: text, json, jsonl or yaml (see docs/OUTPUT.md)")
+}
@@ -2,7 +2,10 @@ package cli
 
 import (
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/divijg19/sage/internal/event"
 )
 
 func TestNormalizeProjectName(t *testing.T) {
@@ -42,6 +45,40 @@ func TestResolveProjectFilter_Precedence(t *testing.T) {
 	}
 }
 
+func TestScopedQuery_ProjectScope(t *testing.T) {
+	t.Setenv("SAGE_PROJECT", "envproj")
+
+	cases := []struct {
+		raw, explicit string
+		all           bool
+		want          string
+	}{
+		// The active project scopes unless the query asks for a project.
+		{"", "", false, "envproj"},
+		{"project:a", "", false, "a"},
+		{"-project:b", "", false, "envproj"},
+		// An explicit --project always applies, even alongside project terms.
+		{"-project:b", "a", false, "a"},
+		{"project:a,b", "a", false, "a"},
+		{"-project:b", "", true, "a,c,envproj"},
+	}
+	for _, c := range cases {
+		q, err := scopedQuery(c.raw, nil, c.explicit, c.all)
+		if err != nil {
+			t.Fatalf("scopedQuery(%q, %q): %v", c.raw, c.explicit, err)
+		}
+		var got []string
+		for _, p := range []string{"a", "b", "c", "envproj"} {
+			if q.Match(event.Event{Project: p}) {
+				got = append(got, p)
+			}
+		}
+		if strings.Join(got, ",") != c.want {
+			t.Fatalf("scopedQuery(%q, --project %q, --all %t) matches %v, want %s", c.raw, c.explicit, c.all, got, c.want)
+		}
+	}
+}
+
 func TestSuggestedProjectFromRepo_FallsBackToBasename(t *testing.T) {
 	dir := t.TempDir()
 	repo := filepath.Join(dir, "My Repo")
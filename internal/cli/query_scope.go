@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/divijg19/sage/internal/query"
+)
+
+// queryHelpCmd is a help topic (`sage help query`), not a runnable command.
+var queryHelpCmd = &cobra.Command{
+	Use:   "query",
+	Short: "Filter syntax shared by timeline, tag, state, search and Chronicle",
+	Long: "Sage commands that list entries accept the same filter syntax:\n\n" +
+		"  kind:decision         entry kind (record, decision, commit, git; r/d/c/g for short)\n" +
+		"  tag:auth  or  #auth   entry has the tag\n" +
+		"  project:api           entry belongs to the project\n" +
+		"  path:internal/store   commit changed the file, or a file under the directory\n" +
+		"  after:2026-01-01      recorded at or after the time\n" +
+		"  before:2026-02-01     recorded before the time\n" +
+		"  word                  title, content, tags or project contain a word starting with it\n" +
+		"  \"exact phrase\"        ... contain the phrase\n\n" +
+		"Every term must match. Prefix a term with - to negate it (-tag:wip, -draft).\n" +
+		"Commas give alternatives inside a filter (kind:decision,commit or tag:auth,backend);\n" +
+		"quote values with spaces (tag:\"two words\"). Times use the same formats as --at\n" +
+		"(see `sage help time`), e.g. after:yesterday or before:\"last friday 17:00\".\n\n" +
+		"The active project still scopes results unless the query asks for a project\n" +
+		"(project:api, not -project:api) or --all is given; --project always applies.\n" +
+		"Filters describe an entry as it currently reads; with\n" +
+		"`sage state --at` they describe it as it read at that time.",
+	Example: "  sage timeline -q 'kind:decision tag:auth -tag:wip after:2026-01-01'\n" +
+		"  sage search 'project:api \"token expiry\"'\n" +
+		"  sage timeline -q 'kind:commit path:internal/store/store.go'\n" +
+		"  sage state --at 2026-01-09 -q '#auth'\n" +
+		"  sage tag -q kind:decision",
+}
+
+func init() {
+	rootCmd.AddCommand(queryHelpCmd)
+}
+
+// parseQuery parses the shared filter syntax (see internal/query), reading
+// after:/before: values with the same formats as --at.
+func parseQuery(raw string) (query.Query, error) {
+	return query.Parse(raw, query.Options{ParseTime: parseTime})
+}
+
+// scopedQuery parses raw and narrows it the way every read command scopes:
+// --tags adds a tag: term, --project always applies (unless --all is set),
+// and the active project applies unless the query asks for a project itself.
+// A negated -project: term only excludes, so it keeps the active scope.
+func scopedQuery(raw string, rawTags []string, explicitProject string, all bool) (query.Query, error) {
+	q, err := parseQuery(raw)
+	if err != nil {
+		return query.Query{}, err
+	}
+	if tags := parseTags(rawTags); len(tags) > 0 {
+		q = q.With(query.Term{Field: query.FieldTag, Values: tags})
+	}
+	project, filter := resolveProjectFilter(explicitProject, all)
+	explicit := normalizeProjectName(explicitProject) != ""
+	if filter && (explicit || !namesProject(q)) {
+		q = q.With(query.Term{Field: query.FieldProject, Values: []string{project}})
+	}
+	return q, nil
+}
+
+// namesProject reports whether q asks for particular projects with a
+// positive project: term.
+func namesProject(q query.Query) bool {
+	for _, t := range q.Terms {
+		if t.Field == query.FieldProject && !t.Negated {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"errors"
+
 	"github.com/spf13/cobra"
 )
 
@@ -14,14 +16,24 @@ var rootCmd = &cobra.Command{
 		"  sage hooks     Install/manage Git hooks\n" +
 		"  sage projects  Activate/list project scope\n" +
 		"  sage tag       List tags or tag an entry\n" +
+		"  sage amend     Revise a past entry (appends a revision)\n" +
+		"  sage retract   Hide a past entry (appends a tombstone)\n" +
+		"  sage link      Relate two entries (affects, depends_on, supersedes, references, implements)\n" +
 		"  sage tui       Open the Chronicle terminal interface\n" +
 		"  sage timeline  Show timestamp/kind/title summaries\n" +
+		"  sage search    Full-text search with ranked snippets\n" +
 		"  sage view      View a past entry by numeric ID\n" +
-		"  sage state     Reconstruct state at a timestamp\n\n" +
+		"  sage state     Reconstruct state at a timestamp\n" +
+		"  sage graph     Explore the semantic graph derived from entries\n" +
+		"  sage doctor    Check the install for problems and suggest fixes\n\n" +
 		"Storage: ~/.sage/sage.db (global, local-only).\n" +
 		"Editor precedence: ~/.sage/config.json (sage editor) > $SAGE_EDITOR > $EDITOR.",
 }
 
+// ErrReported ends a command that has already told the user what went wrong,
+// so sage exits non-zero without printing anything more.
+var ErrReported = errors.New("reported")
+
 func Execute() error {
 	return rootCmd.Execute()
 }
@@ -0,0 +1,258 @@
+package cli
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/divijg19/sage/internal/event"
+	"github.com/divijg19/sage/internal/output"
+	"github.com/divijg19/sage/internal/query"
+	"github.com/divijg19/sage/internal/state"
+	"github.com/divijg19/sage/internal/store"
+)
+
+// stateSnapshot is the log replayed to one instant: every entry recorded by
+// then (retracted ones included, marked) and the supersessions in force.
+type stateSnapshot struct {
+	byID       map[string]event.Event
+	superseded map[string]event.Event
+}
+
+// loadSnapshot replays s up to at within the project scope of q.
+func loadSnapshot(ctx context.Context, s *store.Store, at time.Time, q query.Query) (stateSnapshot, error) {
+	events, err := store.Collect(s.IncludingRetracted().Query(ctx, store.Filter{AsOf: at, Match: q.Only(query.FieldProject)}))
+	if err != nil {
+		return stateSnapshot{}, err
+	}
+	links, err := s.LinksUntil(at)
+	if err != nil {
+		return stateSnapshot{}, err
+	}
+
+	live := make([]event.Event, 0, len(events))
+	byID := make(map[string]event.Event, len(events))
+	for _, e := range events {
+		byID[e.ID] = e
+		if !e.Retracted {
+			live = append(live, e)
+		}
+	}
+	return stateSnapshot{byID: byID, superseded: state.SupersededBy(live, links)}, nil
+}
+
+// live returns the entry as it read in the snapshot, if it existed then and
+// was not retracted.
+func (s stateSnapshot) live(id string) (event.Event, bool) {
+	e, ok := s.byID[id]
+	return e, ok && !e.Retracted
+}
+
+type supersession struct {
+	entry event.Event
+	by    event.Event
+}
+
+type tagChange struct {
+	entry          event.Event
+	added, removed []string
+}
+
+// stateDiff is what changed between two snapshots, each list in seq order.
+type stateDiff struct {
+	from, to   time.Time
+	added      []event.Event
+	superseded []supersession
+	retracted  []event.Event
+	tags       []tagChange
+	context    []event.Event
+}
+
+func (d stateDiff) empty() bool {
+	return len(d.added)+len(d.superseded)+len(d.retracted)+len(d.tags)+len(d.context) == 0
+}
+
+// diffStates compares two snapshots. An entry is in scope when q matches it
+// as it read at either end, so an entry that gained or lost a filtered tag
+// still shows up.
+func diffStates(before, after stateSnapshot, q query.Query, from, to time.Time) stateDiff {
+	d := stateDiff{from: from, to: to}
+
+	ids := make([]string, 0, len(after.byID))
+	for id := range after.byID {
+		ids = append(ids, id)
+	}
+	slices.SortFunc(ids, func(a, b string) int { return cmp.Compare(after.byID[a].Seq, after.byID[b].Seq) })
+
+	for _, id := range ids {
+		now := after.byID[id]
+		then, existed := before.live(id)
+		if !q.Match(now) && !(existed && q.Match(then)) {
+			continue
+		}
+
+		if now.Retracted {
+			if existed && then.Kind == event.DecisionKind {
+				d.retracted = append(d.retracted, now)
+			}
+			continue
+		}
+
+		switch now.Kind {
+		case event.DecisionKind:
+			if !existed || then.Kind != event.DecisionKind {
+				d.added = append(d.added, now)
+			}
+			if by, ok := after.superseded[id]; ok {
+				if _, was := before.superseded[id]; !was {
+					d.superseded = append(d.superseded, supersession{entry: now, by: by})
+				}
+			}
+		case event.RecordKind:
+			if !existed || then.Kind != event.RecordKind {
+				d.context = append(d.context, now)
+			}
+		}
+
+		if existed {
+			added, removed := tagDelta(then.Tags, now.Tags)
+			if len(added)+len(removed) > 0 {
+				d.tags = append(d.tags, tagChange{entry: now, added: added, removed: removed})
+			}
+		}
+	}
+	return d
+}
+
+// tagDelta returns the tags in now but not then, and in then but not now.
+func tagDelta(then, now []string) (added, removed []string) {
+	for _, t := range now {
+		if !slices.Contains(then, t) {
+			added = append(added, t)
+		}
+	}
+	for _, t := range then {
+		if !slices.Contains(now, t) {
+			removed = append(removed, t)
+		}
+	}
+	slices.Sort(added)
+	slices.Sort(removed)
+	return added, removed
+}
+
+// runStateDiff implements state --from/--to.
+func runStateDiff(ctx context.Context) error {
+	if stateFrom == "" {
+		return fmt.Errorf("--to needs --from")
+	}
+	from, err := parseTime(stateFrom)
+	if err != nil {
+		return invalidTimeFlag("from", stateFrom)
+	}
+	to := time.Now()
+	if stateTo != "" {
+		// Like timeline --until, a named day or week is included whole.
+		bound, err := untilBound(stateTo)
+		if err != nil {
+			return invalidTimeFlag("to", stateTo)
+		}
+		to = bound.Add(-time.Nanosecond)
+	}
+	if to.Before(from) {
+		return fmt.Errorf("--to is before --from")
+	}
+
+	s, err := openGlobalStore()
+	if err != nil {
+		return err
+	}
+	q, err := scopedQuery(stateQuery, stateTags, stateProject, stateAll)
+	if err != nil {
+		return err
+	}
+	before, err := loadSnapshot(ctx, s, from, q)
+	if err != nil {
+		return err
+	}
+	after, err := loadSnapshot(ctx, s, to, q)
+	if err != nil {
+		return err
+	}
+	d := diffStates(before, after, q, from, to)
+
+	format, err := outputFormat()
+	if err != nil {
+		return err
+	}
+	if format.Structured() {
+		return writeOutput(format, stateDiffOutput(d))
+	}
+	printStateDiff(os.Stdout, d)
+	return nil
+}
+
+func printStateDiff(w io.Writer, d stateDiff) {
+	fmt.Fprintf(w, "Changes from %s to %s\n", d.from.Format(time.RFC3339), d.to.Format(time.RFC3339))
+	if d.empty() {
+		fmt.Fprintln(w, "\nNo changes.")
+		return
+	}
+
+	section := func(title string, events []event.Event) {
+		if len(events) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "\n%s:\n", title)
+		for _, e := range events {
+			fmt.Fprintf(w, "- [%d] %s\n", e.Seq, stateTitle(e))
+		}
+	}
+
+	section("Decisions added", d.added)
+	if len(d.superseded) > 0 {
+		fmt.Fprintln(w, "\nDecisions superseded:")
+		for _, s := range d.superseded {
+			fmt.Fprintf(w, "- [%d] %s (superseded by [%d])\n", s.entry.Seq, stateTitle(s.entry), s.by.Seq)
+		}
+	}
+	section("Decisions retracted", d.retracted)
+	if len(d.tags) > 0 {
+		fmt.Fprintln(w, "\nTags changed:")
+		for _, c := range d.tags {
+			fmt.Fprintf(w, "- [%d] %s", c.entry.Seq, stateTitle(c.entry))
+			for _, t := range c.added {
+				fmt.Fprintf(w, " +%s", t)
+			}
+			for _, t := range c.removed {
+				fmt.Fprintf(w, " -%s", t)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+	section("New context", d.context)
+}
+
+// stateDiffOutput is the structured form of printStateDiff.
+func stateDiffOutput(d stateDiff) output.StateDiff {
+	var decisions output.DecisionChanges
+	for _, e := range d.added {
+		decisions.Added = append(decisions.Added, output.NewEntry(e))
+	}
+	for _, s := range d.superseded {
+		seq := s.by.Seq
+		decisions.Superseded = append(decisions.Superseded, output.Decision{Entry: output.NewEntry(s.entry), SupersededBy: &seq})
+	}
+	for _, e := range d.retracted {
+		decisions.Retracted = append(decisions.Retracted, output.NewEntry(e))
+	}
+	var tags []output.TagChange
+	for _, c := range d.tags {
+		tags = append(tags, output.NewTagChange(c.entry, c.added, c.removed))
+	}
+	return output.NewStateDiff(d.from, d.to, decisions, tags, d.context)
+}
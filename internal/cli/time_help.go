@@ -0,0 +1,33 @@
+package cli
+
+import "github.com/spf13/cobra"
+
+// timeHelpCmd is a help topic (`sage help time`), not a runnable command.
+var timeHelpCmd = &cobra.Command{
+	Use:   "time",
+	Short: "Time expressions accepted by --at, --since, --until, --day and after:/before:",
+	Long: "Every time flag and query term reads times the same way, in the local timezone\n" +
+		"unless an offset is given:\n\n" +
+		"  2026-01-09T21:30:00+05:30   an exact instant (RFC3339)\n" +
+		"  2026-01-09T21:30            local date and time\n" +
+		"  2026-01-09  2026-01  2026-W02   a day, a month or an ISO week\n" +
+		"  now  today  yesterday  tomorrow\n" +
+		"  friday  last friday  next monday  this week  last month  next year\n" +
+		"  3d ago  2 weeks ago  an hour ago  3d.ago  @{2.weeks.ago}\n" +
+		"  start of week  end of month  start of last year\n\n" +
+		"Any day may end with a time of day: \"yesterday 17:00\", \"last friday at 5pm\",\n" +
+		"\"2026-01-09 noon\". A bare weekday is the most recent one, today included;\n" +
+		"\"last\" excludes today and \"next\" means after today. Weeks start on Monday.\n" +
+		"Units are s, m(in), h, d, w, mo and y, spelled out or abbreviated.\n\n" +
+		"A period (a day, week, month or year) means its start, except that --until\n" +
+		"includes it whole. Dots and underscores may replace spaces, which helps in\n" +
+		"query terms: after:last.friday or after:\"last friday\".",
+	Example: "  sage state --at \"last friday 17:00\"\n" +
+		"  sage timeline --since \"start of week\" --until yesterday\n" +
+		"  sage timeline -q 'after:3d.ago kind:decision'\n" +
+		"  sage tui --query 'after:@{2.weeks.ago}'",
+}
+
+func init() {
+	rootCmd.AddCommand(timeHelpCmd)
+}
@@ -3,6 +3,7 @@ package entryflow
 import (
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 	"unicode"
@@ -23,11 +24,26 @@ const (
 )
 
 type Store interface {
+	EntryGetter
 	Append(e event.Event) error
+	// ImportEvents records events in one transaction: all of them or none.
+	ImportEvents(events []event.Event) (int, error)
 	Latest() (*event.Event, error)
 	LatestByProject(project string) (*event.Event, error)
 }
 
+// EntryGetter looks entries up by their numeric ID.
+type EntryGetter interface {
+	GetBySeq(seq int64) (*event.Event, error)
+}
+
+// DeclaredLink is a relationship declared in the editor front matter,
+// for example `supersedes: 42`.
+type DeclaredLink struct {
+	Relation event.Relation
+	Seq      int64
+}
+
 type InitialBuffer struct {
 	Body     string
 	SeedKind string
@@ -42,6 +58,10 @@ type FinalizeRequest struct {
 	Edited        string
 	Project       string
 	Tags          []string
+
+	// Amends is the current state of the entry being amended. When set,
+	// Finalize appends a revision event targeting it instead of a new entry.
+	Amends *event.Event
 }
 
 type Dependencies struct {
@@ -82,7 +102,9 @@ func Finalize(req FinalizeRequest, deps Dependencies) (Result, error) {
 		return Result{Status: StatusCanceled}, nil
 	}
 
-	if NormalizeForComparison(req.Edited) == NormalizeForComparison(req.InitialBody) {
+	// Amendments may change only the title, so they are compared against the
+	// target entry once the front matter has been parsed.
+	if req.Amends == nil && NormalizeForComparison(req.Edited) == NormalizeForComparison(req.InitialBody) {
 		return Result{Status: StatusUnchanged}, nil
 	}
 
@@ -95,6 +117,11 @@ func Finalize(req FinalizeRequest, deps Dependencies) (Result, error) {
 		return Result{}, fmt.Errorf("title is required")
 	}
 
+	declared, err := ExtractLinksFromEditor(req.Edited)
+	if err != nil {
+		return Result{}, err
+	}
+
 	explicitKind := req.ExplicitKind
 	if editedKind != "" && !strings.EqualFold(editedKind, req.SeedKind) {
 		explicitKind = editedKind
@@ -128,11 +155,21 @@ func Finalize(req FinalizeRequest, deps Dependencies) (Result, error) {
 		return Result{}, fmt.Errorf("store is required")
 	}
 
+	targets, err := resolveDeclaredLinks(deps.Store, declared)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if req.Amends != nil {
+		return finalizeRevision(*req.Amends, kind, title, content, declared, targets, deps)
+	}
+
 	prev, err := latestForProject(deps.Store, req.Project)
 	if err != nil {
 		return Result{}, err
 	}
 	if prev != nil &&
+		!prev.Retracted &&
 		prev.Kind == kind &&
 		strings.TrimSpace(prev.Title) == title &&
 		NormalizePlainText(prev.Content) == NormalizePlainText(content) &&
@@ -160,7 +197,7 @@ func Finalize(req FinalizeRequest, deps Dependencies) (Result, error) {
 		Tags:      append([]string(nil), req.Tags...),
 	}
 
-	if err := deps.Store.Append(e); err != nil {
+	if err := appendWithLinks(deps, e, declaredLinkEvents(deps, e, declared, targets)); err != nil {
 		return Result{}, err
 	}
 
@@ -174,6 +211,158 @@ func Finalize(req FinalizeRequest, deps Dependencies) (Result, error) {
 	}, nil
 }
 
+func finalizeRevision(target event.Event, kind event.EntryKind, title string, content string, declared []DeclaredLink, targets []event.Event, deps Dependencies) (Result, error) {
+	unchanged := target.Kind == kind &&
+		strings.TrimSpace(target.Title) == title &&
+		NormalizePlainText(target.Content) == NormalizePlainText(content)
+	if unchanged && len(declared) == 0 {
+		return Result{Status: StatusUnchanged}, nil
+	}
+	if unchanged {
+		// Only new links were declared; no revision is needed.
+		if _, err := deps.Store.ImportEvents(declaredLinkEvents(deps, target, declared, targets)); err != nil {
+			return Result{}, err
+		}
+		return Result{Status: StatusSaved, Event: &target}, nil
+	}
+
+	now := time.Now
+	if deps.Now != nil {
+		now = deps.Now
+	}
+
+	newID := uuid.NewString
+	if deps.NewID != nil {
+		newID = deps.NewID
+	}
+
+	e := event.Event{
+		ID:        newID(),
+		Timestamp: now(),
+		Project:   target.Project,
+		Kind:      event.RevisionKind,
+		Title:     title,
+		Content:   content,
+		Metadata: map[string]string{
+			event.MetaTarget: target.ID,
+			event.MetaKind:   string(kind),
+		},
+	}
+
+	if err := appendWithLinks(deps, e, declaredLinkEvents(deps, target, declared, targets)); err != nil {
+		return Result{}, err
+	}
+
+	return Result{
+		Status: StatusSaved,
+		Event:  &e,
+	}, nil
+}
+
+// appendWithLinks records e and the link events declared with it together, so
+// a failure never leaves an entry with only some of its links.
+func appendWithLinks(deps Dependencies, e event.Event, links []event.Event) error {
+	if len(links) == 0 {
+		return deps.Store.Append(e)
+	}
+	_, err := deps.Store.ImportEvents(append([]event.Event{e}, links...))
+	return err
+}
+
+func resolveDeclaredLinks(s Store, declared []DeclaredLink) ([]event.Event, error) {
+	targets := make([]event.Event, 0, len(declared))
+	for _, d := range declared {
+		e, err := LinkableEntry(s, d.Seq)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", d.Relation, err)
+		}
+		targets = append(targets, *e)
+	}
+	return targets, nil
+}
+
+// LinkableEntry returns the entry with the given seq if it can take part in
+// a link: it must exist, be an entry rather than an annotation, and not be
+// retracted.
+func LinkableEntry(s EntryGetter, seq int64) (*event.Event, error) {
+	e, err := s.GetBySeq(seq)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, fmt.Errorf("no entry with id %d", seq)
+	}
+	if e.Kind.IsAnnotation() {
+		return nil, fmt.Errorf("entry %d is a %s event and cannot be linked", seq, e.Kind)
+	}
+	if e.Retracted {
+		return nil, fmt.Errorf("entry %d is retracted", seq)
+	}
+	return e, nil
+}
+
+// declaredLinkEvents builds the link events from declares, skipping any
+// that would link it to itself.
+func declaredLinkEvents(deps Dependencies, from event.Event, declared []DeclaredLink, targets []event.Event) []event.Event {
+	now := time.Now
+	if deps.Now != nil {
+		now = deps.Now
+	}
+
+	newID := uuid.NewString
+	if deps.NewID != nil {
+		newID = deps.NewID
+	}
+
+	var links []event.Event
+	for i, d := range declared {
+		if targets[i].ID == from.ID {
+			continue
+		}
+		links = append(links, event.NewLinkEvent(newID(), now(), from, d.Relation, targets[i]))
+	}
+	return links
+}
+
+// ExtractLinksFromEditor returns the relationships declared in the front
+// matter, such as `supersedes: 42` or `depends_on: 12, 17`.
+func ExtractLinksFromEditor(raw string) ([]DeclaredLink, error) {
+	lines := strings.Split(strings.TrimSpace(raw), "\n")
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return nil, nil
+	}
+
+	var out []DeclaredLink
+	for _, line := range lines[1:] {
+		line = strings.TrimSpace(line)
+		if line == "---" {
+			return out, nil
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		rel, ok := event.ParseRelation(key)
+		if !ok {
+			continue
+		}
+		for _, field := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+			field = strings.Trim(field, `"'[]#`)
+			if field == "" {
+				continue
+			}
+			seq, err := strconv.ParseInt(field, 10, 64)
+			if err != nil || seq <= 0 {
+				return nil, fmt.Errorf("%s: invalid entry id %q", rel, field)
+			}
+			out = append(out, DeclaredLink{Relation: rel, Seq: seq})
+		}
+	}
+
+	// Unterminated front matter declares nothing.
+	return nil, nil
+}
+
 func latestForProject(s Store, project string) (*event.Event, error) {
 	if strings.TrimSpace(project) != "" {
 		return s.LatestByProject(project)
@@ -1,6 +1,8 @@
 package entryflow
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -9,6 +11,8 @@ import (
 
 type stubStore struct {
 	events []event.Event
+	// importErr fails ImportEvents, which then records nothing.
+	importErr error
 }
 
 func (s *stubStore) Append(e event.Event) error {
@@ -17,6 +21,16 @@ func (s *stubStore) Append(e event.Event) error {
 	return nil
 }
 
+func (s *stubStore) ImportEvents(events []event.Event) (int, error) {
+	if s.importErr != nil {
+		return 0, s.importErr
+	}
+	for _, e := range events {
+		_ = s.Append(e)
+	}
+	return len(events), nil
+}
+
 func (s *stubStore) Latest() (*event.Event, error) {
 	if len(s.events) == 0 {
 		return nil, nil
@@ -35,6 +49,15 @@ func (s *stubStore) LatestByProject(project string) (*event.Event, error) {
 	return nil, nil
 }
 
+func (s *stubStore) GetBySeq(seq int64) (*event.Event, error) {
+	for _, e := range s.events {
+		if e.Seq == seq {
+			return &e, nil
+		}
+	}
+	return nil, nil
+}
+
 func TestFinalize_Saved(t *testing.T) {
 	store := &stubStore{}
 	now := time.Date(2026, 4, 22, 12, 0, 0, 0, time.UTC)
@@ -129,3 +152,146 @@ func TestFinalize_UnchangedAndDuplicate(t *testing.T) {
 		t.Fatalf("expected duplicate status, got %s", duplicate.Status)
 	}
 }
+
+func TestFinalize_AmendAppendsRevision(t *testing.T) {
+	target := event.Event{
+		Seq:       1,
+		ID:        "evt-1",
+		Timestamp: time.Date(2026, 4, 22, 12, 0, 0, 0, time.UTC),
+		Project:   "alpha",
+		Kind:      event.DecisionKind,
+		Title:     "Use sqlite",
+		Content:   "Durability first",
+	}
+	store := &stubStore{events: []event.Event{target}}
+	initial := PrepareInitialBuffer(target.Title, string(target.Kind), "", target.Content)
+	deps := Dependencies{
+		Store: store,
+		ResolveKind: func(explicit string, suggested string) (event.EntryKind, error) {
+			return event.EntryKind(explicit), nil
+		},
+		NewID: func() string { return "rev-1" },
+	}
+	req := FinalizeRequest{
+		Title:        target.Title,
+		ExplicitKind: string(target.Kind),
+		SeedKind:     initial.SeedKind,
+		InitialBody:  initial.Body,
+		Project:      target.Project,
+		Amends:       &target,
+	}
+
+	req.Edited = initial.Body
+	unchanged, err := Finalize(req, deps)
+	if err != nil {
+		t.Fatalf("Finalize unchanged: %v", err)
+	}
+	if unchanged.Status != StatusUnchanged {
+		t.Fatalf("expected unchanged status, got %s", unchanged.Status)
+	}
+
+	// A title-only change still counts as an amendment.
+	req.Edited = EnsureFrontMatter(initial.Body, "Use sqlite with WAL", "decision")
+	result, err := Finalize(req, deps)
+	if err != nil {
+		t.Fatalf("Finalize amend: %v", err)
+	}
+	if result.Status != StatusSaved {
+		t.Fatalf("expected saved status, got %s", result.Status)
+	}
+	rev := store.events[len(store.events)-1]
+	if rev.Kind != event.RevisionKind || rev.Target() != "evt-1" || rev.Title != "Use sqlite with WAL" || rev.Project != "alpha" {
+		t.Fatalf("unexpected revision event: %#v", rev)
+	}
+	if rev.Metadata[event.MetaKind] != string(event.DecisionKind) {
+		t.Fatalf("expected revision to keep kind decision, got %q", rev.Metadata[event.MetaKind])
+	}
+}
+
+func TestFinalize_CapturesFrontMatterLinks(t *testing.T) {
+	old := event.Event{Seq: 1, ID: "evt-1", Project: "alpha", Kind: event.DecisionKind, Title: "Use redis", Content: "fast"}
+	store := &stubStore{events: []event.Event{old}}
+	initial := PrepareInitialBuffer("Use postgres", "decision", "", "")
+	edited := strings.Replace(initial.Body, "kind: decision", "kind: decision\nsupersedes: 1", 1) + "\nDurability first.\n"
+
+	ids := 0
+	deps := Dependencies{
+		Store: store,
+		ResolveKind: func(explicit string, suggested string) (event.EntryKind, error) {
+			return event.DecisionKind, nil
+		},
+		NewID: func() string {
+			ids++
+			return fmt.Sprintf("evt-%d", ids+1)
+		},
+	}
+
+	result, err := Finalize(FinalizeRequest{
+		Title:        "Use postgres",
+		ExplicitKind: "decision",
+		SeedKind:     initial.SeedKind,
+		InitialBody:  initial.Body,
+		Edited:       edited,
+		Project:      "alpha",
+	}, deps)
+	if err != nil {
+		t.Fatalf("Finalize: %v", err)
+	}
+	if result.Status != StatusSaved {
+		t.Fatalf("expected saved status, got %s", result.Status)
+	}
+	if len(store.events) != 3 {
+		t.Fatalf("expected entry + link event, got %d events", len(store.events))
+	}
+	link, ok := event.LinkFromEvent(store.events[2])
+	if !ok || link.From != "evt-2" || link.To != "evt-1" || link.Relation != event.Supersedes {
+		t.Fatalf("unexpected link event: %#v", store.events[2])
+	}
+	if strings.Contains(store.events[1].Content, "supersedes") {
+		t.Fatalf("front matter leaked into content: %q", store.events[1].Content)
+	}
+
+	_, err = Finalize(FinalizeRequest{
+		Title:        "Dangling",
+		ExplicitKind: "decision",
+		SeedKind:     initial.SeedKind,
+		InitialBody:  initial.Body,
+		Edited:       strings.Replace(edited, "supersedes: 1", "depends_on: 99", 1),
+		Project:      "alpha",
+	}, deps)
+	if err == nil {
+		t.Fatalf("expected unknown link target to fail")
+	}
+	if len(store.events) != 3 {
+		t.Fatalf("expected nothing saved for a dangling link, got %d events", len(store.events))
+	}
+
+	// A retracted entry cannot be linked, as with `sage link`.
+	store.events[0].Retracted = true
+	_, err = Finalize(FinalizeRequest{
+		Title:        "Retracted target",
+		ExplicitKind: "decision",
+		SeedKind:     initial.SeedKind,
+		InitialBody:  initial.Body,
+		Edited:       edited,
+		Project:      "alpha",
+	}, deps)
+	if err == nil || !strings.Contains(err.Error(), "supersedes: entry 1 is retracted") {
+		t.Fatalf("expected a retracted link target to fail, got %v", err)
+	}
+	store.events[0].Retracted = false
+
+	// The entry and its links are written together or not at all.
+	store.importErr = fmt.Errorf("disk full")
+	_, err = Finalize(FinalizeRequest{
+		Title:        "Half written",
+		ExplicitKind: "decision",
+		SeedKind:     initial.SeedKind,
+		InitialBody:  initial.Body,
+		Edited:       strings.Replace(edited, "supersedes: 1", "supersedes: 1\ndepends_on: 2", 1),
+		Project:      "alpha",
+	}, deps)
+	if err == nil || len(store.events) != 3 {
+		t.Fatalf("expected a failed write to record nothing, got %v and %d events", err, len(store.events))
+	}
+}
@@ -9,11 +9,69 @@ const (
 	RecordKind   EntryKind = "record"
 	DecisionKind EntryKind = "decision"
 	CommitKind   EntryKind = "commit"
+	// GitKind entries record repository activity other than a commit: branch
+	// switches and pushes, told apart by Metadata["git_event"].
+	GitKind EntryKind = "git"
+
+	// TagKind events add or remove tags on an existing entry.
+	// They are folded into their target during replay and never listed on their own.
+	TagKind EntryKind = "tag"
+	// RevisionKind events replace the title, content and (optionally) kind of
+	// an existing entry. The original row is kept for history.
+	RevisionKind EntryKind = "revision"
+	// RetractKind events hide an existing entry from default views while
+	// keeping its original row for auditing. Content holds the optional reason.
+	RetractKind EntryKind = "retract"
+	// LinkKind events declare a typed relationship from Metadata["target"]
+	// to Metadata["to"]. They do not change either entry.
+	LinkKind EntryKind = "link"
+)
+
+// Metadata keys used by annotation events.
+const (
+	MetaTarget   = "target"
+	MetaOp       = "op"
+	MetaKind     = "kind"
+	MetaTo       = "to"
+	MetaRelation = "relation"
+)
+
+// MetaFiles lists the paths a commit changed, one per line, relative to the
+// repository root.
+const MetaFiles = "files"
+
+// Tag operations stored under MetaOp on TagKind events.
+const (
+	TagOpAdd    = "add"
+	TagOpRemove = "remove"
 )
 
+// IsAnnotation reports whether events of this kind modify another entry
+// instead of being entries themselves.
+func (k EntryKind) IsAnnotation() bool {
+	switch k {
+	case TagKind, RevisionKind, RetractKind, LinkKind:
+		return true
+	default:
+		return false
+	}
+}
+
+// EntryKinds lists every kind of entry, in display order.
+func EntryKinds() []EntryKind {
+	return []EntryKind{RecordKind, DecisionKind, CommitKind, GitKind}
+}
+
+// AnnotationKinds lists every kind for which IsAnnotation is true.
+func AnnotationKinds() []EntryKind {
+	return []EntryKind{TagKind, RevisionKind, RetractKind, LinkKind}
+}
+
 // Event represents a single immutable cognitive entry.
 type Event struct {
-	Seq int64 `json:"-"`
+	// Seq and Retracted are derived from the log during replay and never persisted.
+	Seq       int64 `json:"-"`
+	Retracted bool  `json:"-"`
 
 	ID        string    `json:"id"`
 	Timestamp time.Time `json:"timestamp"`
@@ -26,3 +84,11 @@ type Event struct {
 	Tags     []string          `json:"tags,omitempty"`
 	Metadata map[string]string `json:"metadata,omitempty"`
 }
+
+// Target returns the ID of the entry an annotation event applies to.
+func (e Event) Target() string {
+	if e.Metadata == nil {
+		return ""
+	}
+	return e.Metadata[MetaTarget]
+}
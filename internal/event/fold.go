@@ -0,0 +1,108 @@
+package event
+
+import "strings"
+
+// FoldOptions controls how Fold treats retracted entries.
+type FoldOptions struct {
+	// IncludeRetracted keeps retracted entries (marked Retracted) instead of dropping them.
+	IncludeRetracted bool
+}
+
+// Fold replays annotation events onto the entries they target and returns
+// only the entries, in input order. Events must be in seq order; annotations
+// whose target is not part of events are ignored, which is what makes a
+// replay truncated at a timestamp show entries as they were back then.
+// Retracted entries are dropped.
+func Fold(events []Event) []Event {
+	return FoldWith(events, FoldOptions{})
+}
+
+// FoldWith is Fold with explicit options.
+func FoldWith(events []Event, opts FoldOptions) []Event {
+	index := make(map[string]int, len(events))
+	out := make([]Event, 0, len(events))
+
+	for _, e := range events {
+		if !e.Kind.IsAnnotation() {
+			index[e.ID] = len(out)
+			e.Tags = append([]string(nil), e.Tags...)
+			out = append(out, e)
+			continue
+		}
+
+		i, ok := index[e.Target()]
+		if !ok {
+			continue
+		}
+		switch e.Kind {
+		case TagKind:
+			out[i].Tags = applyTagOp(out[i].Tags, e.Metadata[MetaOp], e.Tags)
+		case RevisionKind:
+			applyRevision(&out[i], e)
+		case RetractKind:
+			out[i].Retracted = true
+		}
+	}
+
+	if opts.IncludeRetracted {
+		return out
+	}
+	visible := out[:0]
+	for _, e := range out {
+		if !e.Retracted {
+			visible = append(visible, e)
+		}
+	}
+	return visible
+}
+
+func applyRevision(target *Event, rev Event) {
+	if strings.TrimSpace(rev.Title) != "" {
+		target.Title = rev.Title
+	}
+	target.Content = rev.Content
+	if kind := EntryKind(rev.Metadata[MetaKind]); kind != "" && !kind.IsAnnotation() {
+		target.Kind = kind
+	}
+}
+
+func applyTagOp(current []string, op string, tags []string) []string {
+	if op == TagOpRemove {
+		drop := make(map[string]struct{}, len(tags))
+		for _, t := range tags {
+			drop[normalizeTag(t)] = struct{}{}
+		}
+		kept := current[:0]
+		for _, t := range current {
+			if _, ok := drop[normalizeTag(t)]; ok {
+				continue
+			}
+			kept = append(kept, t)
+		}
+		if len(kept) == 0 {
+			return nil
+		}
+		return kept
+	}
+
+	seen := make(map[string]struct{}, len(current))
+	for _, t := range current {
+		seen[normalizeTag(t)] = struct{}{}
+	}
+	for _, t := range tags {
+		n := normalizeTag(t)
+		if n == "" {
+			continue
+		}
+		if _, ok := seen[n]; ok {
+			continue
+		}
+		seen[n] = struct{}{}
+		current = append(current, n)
+	}
+	return current
+}
+
+func normalizeTag(t string) string {
+	return strings.ToLower(strings.TrimSpace(t))
+}
@@ -0,0 +1,78 @@
+package event
+
+import (
+	"strings"
+	"time"
+)
+
+// Relation is the type of a link between two entries.
+type Relation string
+
+const (
+	Affects    Relation = "affects"
+	DependsOn  Relation = "depends_on"
+	Supersedes Relation = "supersedes"
+	References Relation = "references"
+	// Implements links a commit to the decision it carries out.
+	Implements Relation = "implements"
+)
+
+// Relations lists every supported relation in display order.
+func Relations() []Relation {
+	return []Relation{Affects, DependsOn, Supersedes, References, Implements}
+}
+
+// ParseRelation accepts a relation name case-insensitively, with '-' or '_'.
+func ParseRelation(s string) (Relation, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.ReplaceAll(s, "-", "_")
+	for _, r := range Relations() {
+		if string(r) == s {
+			return r, true
+		}
+	}
+	return "", false
+}
+
+// Link is a typed relationship declared by a LinkKind event.
+type Link struct {
+	Seq       int64
+	Timestamp time.Time
+	From      string
+	To        string
+	Relation  Relation
+}
+
+// LinkFromEvent extracts the link declared by e. It reports false if e is not a valid link event.
+func LinkFromEvent(e Event) (Link, bool) {
+	if e.Kind != LinkKind || e.Metadata == nil {
+		return Link{}, false
+	}
+	rel, ok := ParseRelation(e.Metadata[MetaRelation])
+	if !ok {
+		return Link{}, false
+	}
+	from := e.Metadata[MetaTarget]
+	to := e.Metadata[MetaTo]
+	if from == "" || to == "" {
+		return Link{}, false
+	}
+	return Link{Seq: e.Seq, Timestamp: e.Timestamp, From: from, To: to, Relation: rel}, true
+}
+
+// NewLinkEvent builds the event declaring that from has relation rel to to.
+// The event belongs to from's project so project-scoped replays see it.
+func NewLinkEvent(id string, at time.Time, from Event, rel Relation, to Event) Event {
+	return Event{
+		ID:        id,
+		Timestamp: at,
+		Project:   from.Project,
+		Kind:      LinkKind,
+		Title:     string(rel),
+		Metadata: map[string]string{
+			MetaTarget:   from.ID,
+			MetaTo:       to.ID,
+			MetaRelation: string(rel),
+		},
+	}
+}
@@ -0,0 +1,224 @@
+package graph
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Format is a graph export format.
+type Format string
+
+const (
+	FormatDOT     Format = "dot"
+	FormatMermaid Format = "mermaid"
+	FormatGraphML Format = "graphml"
+	FormatJSON    Format = "json"
+)
+
+// Formats lists every supported export format.
+func Formats() []Format {
+	return []Format{FormatDOT, FormatMermaid, FormatGraphML, FormatJSON}
+}
+
+// ParseFormat accepts a format name case-insensitively.
+func ParseFormat(s string) (Format, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for _, f := range Formats() {
+		if string(f) == s {
+			return f, true
+		}
+	}
+	return "", false
+}
+
+// Export writes g to w in the given format. Output is deterministic.
+func Export(w io.Writer, g *Graph, format Format) error {
+	switch format {
+	case FormatDOT:
+		return writeDOT(w, g)
+	case FormatMermaid:
+		return writeMermaid(w, g)
+	case FormatGraphML:
+		return writeGraphML(w, g)
+	case FormatJSON:
+		return writeJSON(w, g)
+	default:
+		return fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+func writeDOT(w io.Writer, g *Graph) error {
+	var b strings.Builder
+	b.WriteString("digraph sage {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [fontname=\"Helvetica\"];\n")
+	for _, n := range g.Nodes() {
+		attrs := []string{
+			"label=" + dotQuote(displayLabel(n)),
+			"kind=" + dotQuote(string(n.Kind)),
+			"shape=" + dotShape(n.Kind),
+		}
+		if n.Seq != 0 {
+			attrs = append(attrs, "seq="+strconv.FormatInt(n.Seq, 10))
+		}
+		if n.Project != "" {
+			attrs = append(attrs, "project="+dotQuote(n.Project))
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", dotQuote(n.ID), strings.Join(attrs, ", "))
+	}
+	for _, e := range g.Edges() {
+		fmt.Fprintf(&b, "  %s -> %s [label=%s];\n", dotQuote(e.From), dotQuote(e.To), dotQuote(e.Relation))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "\"", "\\\"")
+	s = strings.ReplaceAll(s, "\n", "\\n")
+	return "\"" + s + "\""
+}
+
+func dotShape(kind NodeKind) string {
+	switch kind {
+	case DecisionNode:
+		return "hexagon"
+	case ConceptNode:
+		return "ellipse"
+	case CommitNode:
+		return "parallelogram"
+	case RepoNode:
+		return "cylinder"
+	case FileNode:
+		return "note"
+	default:
+		return "box"
+	}
+}
+
+func writeMermaid(w io.Writer, g *Graph) error {
+	ids := make(map[string]string, len(g.order))
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for i, n := range g.Nodes() {
+		id := "n" + strconv.Itoa(i)
+		ids[n.ID] = id
+		open, close := mermaidShape(n.Kind)
+		fmt.Fprintf(&b, "  %s%s\"%s\"%s\n", id, open, mermaidEscape(displayLabel(n)), close)
+	}
+	for _, e := range g.Edges() {
+		fmt.Fprintf(&b, "  %s -->|%s| %s\n", ids[e.From], mermaidEscape(e.Relation), ids[e.To])
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func mermaidShape(kind NodeKind) (string, string) {
+	switch kind {
+	case DecisionNode:
+		return "{{", "}}"
+	case ConceptNode:
+		return "((", "))"
+	case CommitNode:
+		return "[/", "/]"
+	case RepoNode:
+		return "[(", ")]"
+	case FileNode:
+		return ">", "]"
+	default:
+		return "[", "]"
+	}
+}
+
+// mermaidEscape makes s safe inside a quoted Mermaid label.
+func mermaidEscape(s string) string {
+	s = strings.ReplaceAll(s, "\"", "#quot;")
+	s = strings.ReplaceAll(s, "|", "#124;")
+	s = strings.ReplaceAll(s, "\n", " ")
+	return s
+}
+
+func writeGraphML(w io.Writer, g *Graph) error {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">` + "\n")
+	b.WriteString(`  <key id="kind" for="node" attr.name="kind" attr.type="string"/>` + "\n")
+	b.WriteString(`  <key id="label" for="node" attr.name="label" attr.type="string"/>` + "\n")
+	b.WriteString(`  <key id="seq" for="node" attr.name="seq" attr.type="long"/>` + "\n")
+	b.WriteString(`  <key id="project" for="node" attr.name="project" attr.type="string"/>` + "\n")
+	b.WriteString(`  <key id="relation" for="edge" attr.name="relation" attr.type="string"/>` + "\n")
+	b.WriteString(`  <graph id="sage" edgedefault="directed">` + "\n")
+	for _, n := range g.Nodes() {
+		fmt.Fprintf(&b, "    <node id=\"%s\">\n", xmlEscape(n.ID))
+		fmt.Fprintf(&b, "      <data key=\"kind\">%s</data>\n", xmlEscape(string(n.Kind)))
+		fmt.Fprintf(&b, "      <data key=\"label\">%s</data>\n", xmlEscape(n.Label))
+		if n.Seq != 0 {
+			fmt.Fprintf(&b, "      <data key=\"seq\">%d</data>\n", n.Seq)
+		}
+		if n.Project != "" {
+			fmt.Fprintf(&b, "      <data key=\"project\">%s</data>\n", xmlEscape(n.Project))
+		}
+		b.WriteString("    </node>\n")
+	}
+	for i, e := range g.Edges() {
+		fmt.Fprintf(&b, "    <edge id=\"e%d\" source=\"%s\" target=\"%s\">\n", i, xmlEscape(e.From), xmlEscape(e.To))
+		fmt.Fprintf(&b, "      <data key=\"relation\">%s</data>\n", xmlEscape(e.Relation))
+		b.WriteString("    </edge>\n")
+	}
+	b.WriteString("  </graph>\n")
+	b.WriteString("</graphml>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+type jsonGraph struct {
+	Nodes []jsonNode `json:"nodes"`
+	Edges []jsonEdge `json:"edges"`
+}
+
+type jsonNode struct {
+	ID      string   `json:"id"`
+	Kind    NodeKind `json:"kind"`
+	Label   string   `json:"label"`
+	Seq     int64    `json:"seq,omitempty"`
+	Project string   `json:"project,omitempty"`
+}
+
+type jsonEdge struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Relation string `json:"relation"`
+}
+
+func writeJSON(w io.Writer, g *Graph) error {
+	out := jsonGraph{Nodes: []jsonNode{}, Edges: []jsonEdge{}}
+	for _, n := range g.Nodes() {
+		out.Nodes = append(out.Nodes, jsonNode{ID: n.ID, Kind: n.Kind, Label: n.Label, Seq: n.Seq, Project: n.Project})
+	}
+	for _, e := range g.Edges() {
+		out.Edges = append(out.Edges, jsonEdge{From: e.From, To: e.To, Relation: e.Relation})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// displayLabel prefixes entries with their numeric ID, as in the CLI.
+func displayLabel(n Node) string {
+	if n.Seq != 0 {
+		return fmt.Sprintf("[%d] %s", n.Seq, n.Label)
+	}
+	return n.Label
+}
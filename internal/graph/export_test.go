@@ -0,0 +1,78 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestExport_AllFormats(t *testing.T) {
+	entries, links := fixtureEntries()
+	entries[0].Title = `Use "JWT" | tokens`
+	entries[0].Project = "api"
+	g := Build(entries, links)
+
+	var dot bytes.Buffer
+	if err := Export(&dot, g, FormatDOT); err != nil {
+		t.Fatalf("dot: %v", err)
+	}
+	if !strings.Contains(dot.String(), `"entry:d1" [label="[1] Use \"JWT\" | tokens", kind="decision", shape=hexagon, seq=1, project="api"];`) {
+		t.Fatalf("unexpected dot output:\n%s", dot.String())
+	}
+	if !strings.Contains(dot.String(), `"entry:r1" -> "concept:auth" [label="tagged"];`) {
+		t.Fatalf("missing tagged edge in dot output:\n%s", dot.String())
+	}
+
+	var mermaid bytes.Buffer
+	if err := Export(&mermaid, g, FormatMermaid); err != nil {
+		t.Fatalf("mermaid: %v", err)
+	}
+	lines := strings.Split(mermaid.String(), "\n")
+	if lines[0] != "flowchart LR" || lines[1] != `  n0{{"[1] Use #quot;JWT#quot; #124; tokens"}}` {
+		t.Fatalf("unexpected mermaid output:\n%s", mermaid.String())
+	}
+	if !strings.Contains(mermaid.String(), "-->|tagged|") {
+		t.Fatalf("missing mermaid edge:\n%s", mermaid.String())
+	}
+
+	var graphml bytes.Buffer
+	if err := Export(&graphml, g, FormatGraphML); err != nil {
+		t.Fatalf("graphml: %v", err)
+	}
+	dec := xml.NewDecoder(&graphml)
+	for {
+		if _, err := dec.Token(); err != nil {
+			if err == io.EOF {
+				break
+			}
+			t.Fatalf("graphml is not well-formed: %v", err)
+		}
+	}
+
+	var raw bytes.Buffer
+	if err := Export(&raw, g, FormatJSON); err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	var decoded jsonGraph
+	if err := json.Unmarshal(raw.Bytes(), &decoded); err != nil {
+		t.Fatalf("decode json: %v", err)
+	}
+	if len(decoded.Nodes) != len(g.Nodes()) || len(decoded.Edges) != len(g.Edges()) {
+		t.Fatalf("json lost nodes or edges: %d/%d", len(decoded.Nodes), len(decoded.Edges))
+	}
+	if n := decoded.Nodes[0]; n.ID != "entry:d1" || n.Seq != 1 || n.Kind != DecisionNode || n.Project != "api" {
+		t.Fatalf("unexpected json node: %+v", n)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, ok := ParseFormat(" Mermaid "); !ok || f != FormatMermaid {
+		t.Fatalf("expected mermaid, got %q %v", f, ok)
+	}
+	if _, ok := ParseFormat("svg"); ok {
+		t.Fatalf("expected svg to be rejected")
+	}
+}
@@ -0,0 +1,346 @@
+// Package graph builds the semantic graph projection of the event log.
+//
+// The graph is never stored: it is rebuilt from folded entries and links,
+// so replaying the log up to a timestamp yields the graph as it was then.
+package graph
+
+import (
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/divijg19/sage/internal/event"
+)
+
+// NodeKind is the type of a graph node.
+type NodeKind string
+
+const (
+	RecordNode   NodeKind = "record"
+	DecisionNode NodeKind = "decision"
+	CommitNode   NodeKind = "commit"
+	ConceptNode  NodeKind = "concept"
+	RepoNode     NodeKind = "repo"
+	FileNode     NodeKind = "file"
+)
+
+// Derived relations, in addition to the typed relations declared by links.
+const (
+	TaggedRelation  = "tagged"
+	InRepoRelation  = "in_repo"
+	TouchesRelation = "touches"
+)
+
+// Metadata keys read from entries to derive artifacts.
+const (
+	metaRepoID   = "repo_id"
+	metaRepoRoot = "repo_root"
+	metaSHA      = "sha"
+)
+
+// Node is a concept, entry or artifact.
+type Node struct {
+	ID    string
+	Kind  NodeKind
+	Label string
+	// Seq is the entry's numeric ID; zero for concepts and artifacts.
+	Seq int64
+	// Project is the entry's project; empty for concepts and artifacts.
+	Project string
+}
+
+// Edge is a directed relationship between two nodes.
+type Edge struct {
+	From     string
+	To       string
+	Relation string
+}
+
+// Graph is an in-memory, directed multigraph with stable iteration order.
+type Graph struct {
+	nodes map[string]Node
+	order []string
+	edges []Edge
+	seen  map[Edge]struct{}
+	adj   map[string][]int
+	bySeq map[int64]string
+}
+
+func newGraph() *Graph {
+	return &Graph{
+		nodes: map[string]Node{},
+		seen:  map[Edge]struct{}{},
+		adj:   map[string][]int{},
+		bySeq: map[int64]string{},
+	}
+}
+
+// Build projects entries (already folded) and links into a graph.
+// Links whose endpoints are not among entries are ignored.
+func Build(entries []event.Event, links []event.Link) *Graph {
+	g := newGraph()
+	entryNode := make(map[string]string, len(entries))
+
+	for _, e := range entries {
+		// Branch switches and pushes are activity, not artifacts to relate.
+		if e.Kind.IsAnnotation() || e.Kind == event.GitKind {
+			continue
+		}
+		id := g.addEntry(e)
+		entryNode[e.ID] = id
+
+		for _, tag := range e.Tags {
+			tag = strings.ToLower(strings.TrimSpace(tag))
+			if tag == "" {
+				continue
+			}
+			g.addEdge(id, g.addNode(Node{ID: ConceptID(tag), Kind: ConceptNode, Label: "#" + tag}), TaggedRelation)
+		}
+
+		repo := ""
+		if root := e.Metadata[metaRepoRoot]; root != "" {
+			repoKey := e.Metadata[metaRepoID]
+			if repoKey == "" {
+				repoKey = root
+			}
+			repo = g.addNode(Node{ID: "repo:" + repoKey, Kind: RepoNode, Label: path.Base(root)})
+			if id != repo {
+				g.addEdge(id, repo, InRepoRelation)
+			}
+		}
+
+		for _, f := range splitLines(e.Metadata[event.MetaFiles]) {
+			scope := e.Metadata[metaRepoID]
+			file := g.addNode(Node{ID: "file:" + scope + ":" + f, Kind: FileNode, Label: f})
+			g.addEdge(id, file, TouchesRelation)
+			if repo != "" {
+				g.addEdge(file, repo, InRepoRelation)
+			}
+		}
+	}
+
+	for _, l := range links {
+		from, ok := entryNode[l.From]
+		if !ok {
+			continue
+		}
+		to, ok := entryNode[l.To]
+		if !ok {
+			continue
+		}
+		g.addEdge(from, to, string(l.Relation))
+	}
+
+	return g
+}
+
+// ConceptID returns the node ID for a tag.
+func ConceptID(tag string) string {
+	return "concept:" + strings.ToLower(strings.TrimSpace(strings.TrimPrefix(tag, "#")))
+}
+
+func (g *Graph) addEntry(e event.Event) string {
+	n := Node{Kind: RecordNode, Label: strings.TrimSpace(e.Title), Seq: e.Seq, Project: e.Project}
+	switch e.Kind {
+	case event.DecisionKind:
+		n.Kind = DecisionNode
+	case event.CommitKind:
+		n.Kind = CommitNode
+	}
+	if n.Label == "" {
+		n.Label = "(untitled)"
+	}
+
+	// Commit entries are the commit artifact, so anything else that
+	// references the same SHA lands on the same node.
+	if sha := e.Metadata[metaSHA]; n.Kind == CommitNode && sha != "" {
+		n.ID = "commit:" + sha
+	} else {
+		n.ID = "entry:" + e.ID
+	}
+
+	id := g.addNode(n)
+	g.bySeq[e.Seq] = id
+	return id
+}
+
+func (g *Graph) addNode(n Node) string {
+	if _, ok := g.nodes[n.ID]; ok {
+		return n.ID
+	}
+	g.nodes[n.ID] = n
+	g.order = append(g.order, n.ID)
+	return n.ID
+}
+
+func (g *Graph) addEdge(from, to, relation string) {
+	e := Edge{From: from, To: to, Relation: relation}
+	if _, ok := g.seen[e]; ok {
+		return
+	}
+	g.seen[e] = struct{}{}
+	g.edges = append(g.edges, e)
+	g.adj[from] = append(g.adj[from], len(g.edges)-1)
+	g.adj[to] = append(g.adj[to], len(g.edges)-1)
+}
+
+// Node returns the node with the given ID.
+func (g *Graph) Node(id string) (Node, bool) {
+	n, ok := g.nodes[id]
+	return n, ok
+}
+
+// Nodes returns all nodes in insertion order.
+func (g *Graph) Nodes() []Node {
+	out := make([]Node, 0, len(g.order))
+	for _, id := range g.order {
+		out = append(out, g.nodes[id])
+	}
+	return out
+}
+
+// Edges returns all edges in insertion order.
+func (g *Graph) Edges() []Edge {
+	return append([]Edge(nil), g.edges...)
+}
+
+// EdgesOf returns the edges touching the node with the given ID.
+func (g *Graph) EdgesOf(id string) []Edge {
+	idx := g.adj[id]
+	out := make([]Edge, 0, len(idx))
+	for _, i := range idx {
+		out = append(out, g.edges[i])
+	}
+	return out
+}
+
+// Resolve turns a user reference into a node ID. It accepts "#tag",
+// a numeric entry ID, or a node ID such as "concept:auth" or "commit:<sha>".
+// Commit SHAs may be abbreviated.
+func (g *Graph) Resolve(ref string) (string, bool) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return "", false
+	}
+	if strings.HasPrefix(ref, "#") {
+		id := ConceptID(ref)
+		_, ok := g.nodes[id]
+		return id, ok
+	}
+	if seq, err := strconv.ParseInt(ref, 10, 64); err == nil {
+		id, ok := g.bySeq[seq]
+		return id, ok
+	}
+	if _, ok := g.nodes[ref]; ok {
+		return ref, true
+	}
+	if sha, ok := strings.CutPrefix(ref, "commit:"); ok && len(sha) >= 4 {
+		for _, id := range g.order {
+			if strings.HasPrefix(id, "commit:"+sha) {
+				return id, true
+			}
+		}
+	}
+	if name, ok := strings.CutPrefix(ref, "repo:"); ok {
+		for _, id := range g.order {
+			if n := g.nodes[id]; n.Kind == RepoNode && n.Label == name {
+				return id, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Hop is a node reached by a neighbourhood query.
+type Hop struct {
+	Node     Node
+	Distance int
+}
+
+// Neighborhood returns every node within hops of start, ignoring edge
+// direction, ordered by distance and then by insertion order, together
+// with the subgraph they induce.
+func (g *Graph) Neighborhood(start string, hops int) ([]Hop, *Graph) {
+	if _, ok := g.nodes[start]; !ok {
+		return nil, newGraph()
+	}
+
+	dist := map[string]int{start: 0}
+	frontier := []string{start}
+	for d := 1; d <= hops && len(frontier) > 0; d++ {
+		var next []string
+		for _, id := range frontier {
+			for _, i := range g.adj[id] {
+				e := g.edges[i]
+				other := e.To
+				if other == id {
+					other = e.From
+				}
+				if _, ok := dist[other]; ok {
+					continue
+				}
+				dist[other] = d
+				next = append(next, other)
+			}
+		}
+		frontier = next
+	}
+
+	position := make(map[string]int, len(g.order))
+	for i, id := range g.order {
+		position[id] = i
+	}
+
+	reached := make([]Hop, 0, len(dist))
+	for id, d := range dist {
+		reached = append(reached, Hop{Node: g.nodes[id], Distance: d})
+	}
+	sort.Slice(reached, func(i, j int) bool {
+		if reached[i].Distance != reached[j].Distance {
+			return reached[i].Distance < reached[j].Distance
+		}
+		return position[reached[i].Node.ID] < position[reached[j].Node.ID]
+	})
+
+	sub := g.Subgraph(func(n Node) bool {
+		_, ok := dist[n.ID]
+		return ok
+	})
+	return reached, sub
+}
+
+// Subgraph returns the nodes matching keep and the edges between them.
+func (g *Graph) Subgraph(keep func(Node) bool) *Graph {
+	sub := newGraph()
+	for _, id := range g.order {
+		if n := g.nodes[id]; keep(n) {
+			sub.addNode(n)
+			if n.Seq != 0 {
+				sub.bySeq[n.Seq] = id
+			}
+		}
+	}
+	for _, e := range g.edges {
+		if _, ok := sub.nodes[e.From]; !ok {
+			continue
+		}
+		if _, ok := sub.nodes[e.To]; !ok {
+			continue
+		}
+		sub.addEdge(e.From, e.To, e.Relation)
+	}
+	return sub
+}
+
+// splitLines splits a one-per-line metadata value such as event.MetaFiles.
+// Paths may contain commas and spaces, so only newlines separate them.
+func splitLines(s string) []string {
+	var out []string
+	for _, line := range strings.Split(s, "\n") {
+		if line != "" {
+			out = append(out, line)
+		}
+	}
+	return out
+}
@@ -0,0 +1,124 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/divijg19/sage/internal/event"
+)
+
+func fixtureEntries() ([]event.Event, []event.Link) {
+	base := time.Date(2026, 1, 9, 10, 0, 0, 0, time.UTC)
+	entries := []event.Event{
+		{Seq: 1, ID: "d1", Timestamp: base, Kind: event.DecisionKind, Title: "Use JWT", Tags: []string{"auth"}},
+		{Seq: 2, ID: "r1", Timestamp: base.Add(time.Minute), Kind: event.RecordKind, Title: "Token expiry bug", Tags: []string{"auth", "bug"}},
+		{Seq: 3, ID: "git:abc:1234567", Timestamp: base.Add(2 * time.Minute), Kind: event.CommitKind, Title: "Fix expiry",
+			Tags: []string{"git"},
+			Metadata: map[string]string{
+				"repo_root": "/src/api",
+				"repo_id":   "abc",
+				"sha":       "1234567",
+				"files":     "auth/token.go\nauth/token_test.go\ndocs/auth, tokens.md",
+			}},
+		{Seq: 4, ID: "r2", Timestamp: base.Add(3 * time.Minute), Kind: event.RecordKind, Title: "Unrelated", Tags: []string{"ops"}},
+	}
+	links := []event.Link{
+		{From: "git:abc:1234567", To: "r1", Relation: event.References},
+		{From: "r1", To: "missing", Relation: event.Affects},
+	}
+	return entries, links
+}
+
+func TestBuild_DerivesConceptsArtifactsAndLinks(t *testing.T) {
+	entries, links := fixtureEntries()
+	g := Build(entries, links)
+
+	for _, id := range []string{"entry:d1", "entry:r1", "commit:1234567", "concept:auth", "repo:abc", "file:abc:auth/token.go"} {
+		if _, ok := g.Node(id); !ok {
+			t.Fatalf("expected node %s", id)
+		}
+	}
+	// Files are one per line; a comma is part of the path.
+	if _, ok := g.Node("file:abc:docs/auth, tokens.md"); !ok {
+		t.Fatalf("expected a file path containing a comma to stay whole")
+	}
+	if _, ok := g.Node("file:abc:docs/auth"); ok {
+		t.Fatalf("a comma should not split a file path")
+	}
+	if n, _ := g.Node("entry:d1"); n.Kind != DecisionNode || n.Seq != 1 || n.Label != "Use JWT" {
+		t.Fatalf("unexpected decision node: %+v", n)
+	}
+
+	var relations []string
+	for _, e := range g.EdgesOf("entry:r1") {
+		relations = append(relations, e.Relation)
+	}
+	if strings.Join(relations, ",") != "tagged,tagged,references" {
+		t.Fatalf("unexpected edges of r1: %v", relations)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	entries, links := fixtureEntries()
+	g := Build(entries, links)
+
+	cases := map[string]string{
+		"#Auth":         "concept:auth",
+		"2":             "entry:r1",
+		"3":             "commit:1234567",
+		"commit:1234":   "commit:1234567",
+		"repo:api":      "repo:abc",
+		"concept:ops":   "concept:ops",
+		"entry:r2":      "entry:r2",
+		"#missing":      "",
+		"99":            "",
+		"commit:12":     "",
+		"not-a-node-id": "",
+	}
+	for ref, want := range cases {
+		got, ok := g.Resolve(ref)
+		if want == "" {
+			if ok {
+				t.Fatalf("Resolve(%q): expected no match, got %q", ref, got)
+			}
+			continue
+		}
+		if !ok || got != want {
+			t.Fatalf("Resolve(%q): expected %q, got %q (%t)", ref, want, got, ok)
+		}
+	}
+}
+
+func TestNeighborhood_HopsAndInducedSubgraph(t *testing.T) {
+	entries, links := fixtureEntries()
+	g := Build(entries, links)
+
+	one, _ := g.Neighborhood("concept:auth", 1)
+	if len(one) != 3 || one[0].Node.ID != "concept:auth" || one[1].Node.ID != "entry:d1" || one[2].Node.ID != "entry:r1" {
+		t.Fatalf("unexpected 1-hop neighbourhood: %+v", one)
+	}
+
+	two, sub := g.Neighborhood("concept:auth", 2)
+	got := map[string]int{}
+	for _, h := range two {
+		got[h.Node.ID] = h.Distance
+	}
+	if got["concept:bug"] != 2 || got["commit:1234567"] != 2 {
+		t.Fatalf("expected bug concept and commit at 2 hops, got %v", got)
+	}
+	if _, ok := got["entry:r2"]; ok {
+		t.Fatalf("unrelated entry should not be reachable")
+	}
+	if _, ok := got["repo:abc"]; ok {
+		t.Fatalf("repo is 3 hops away and should not be reachable")
+	}
+	for _, e := range sub.Edges() {
+		if _, ok := got[e.From]; !ok {
+			t.Fatalf("subgraph edge leaves neighbourhood: %+v", e)
+		}
+		if _, ok := got[e.To]; !ok {
+			t.Fatalf("subgraph edge leaves neighbourhood: %+v", e)
+		}
+	}
+}
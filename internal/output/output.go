@@ -0,0 +1,519 @@
+// Package output defines the machine-readable form of Sage's read commands
+// (--format json|jsonl|yaml).
+//
+// Every document starts with a header naming the schema version and the
+// document type. Within a schema version fields are only ever added, never
+// renamed, removed or retyped; anything else bumps SchemaVersion. The schema
+// is documented in docs/OUTPUT.md.
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/divijg19/sage/internal/event"
+	"github.com/divijg19/sage/internal/state"
+)
+
+// SchemaVersion is the version of every document this package writes.
+const SchemaVersion = 1
+
+// Format is an output format for read commands.
+type Format string
+
+const (
+	FormatText  Format = "text"
+	FormatJSON  Format = "json"
+	FormatJSONL Format = "jsonl"
+	FormatYAML  Format = "yaml"
+)
+
+// Formats lists the supported formats, text first.
+func Formats() []Format {
+	return []Format{FormatText, FormatJSON, FormatJSONL, FormatYAML}
+}
+
+// ParseFormat resolves a --format value (case-insensitive; empty means text).
+func ParseFormat(s string) (Format, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return FormatText, true
+	}
+	for _, f := range Formats() {
+		if string(f) == s {
+			return f, true
+		}
+	}
+	return "", false
+}
+
+// Structured reports whether f is a machine-readable format.
+func (f Format) Structured() bool {
+	return f != FormatText && f != ""
+}
+
+// Document is a top-level payload. JSON and YAML write the whole document;
+// JSONL writes one line per item of list documents (entries, tags, ...) and
+// the whole document on one line otherwise.
+type Document interface {
+	items() []any
+}
+
+// Header opens every document.
+type Header struct {
+	Schema int    `json:"schema" yaml:"schema"`
+	Type   string `json:"type" yaml:"type"`
+}
+
+func header(docType string) Header {
+	return Header{Schema: SchemaVersion, Type: docType}
+}
+
+// Write encodes doc to w in format f, which must be structured.
+func Write(w io.Writer, f Format, doc Document) error {
+	switch f {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(doc)
+	case FormatJSONL:
+		items := doc.items()
+		if items == nil {
+			return WriteLine(w, doc)
+		}
+		for _, item := range items {
+			if err := WriteLine(w, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FormatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(doc); err != nil {
+			return err
+		}
+		return enc.Close()
+	default:
+		return fmt.Errorf("output: %q is not a structured format", f)
+	}
+}
+
+// WriteLine writes v as a single JSONL line, for commands that stream items
+// instead of building a whole document.
+func WriteLine(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
+}
+
+// Entry is one entry as it currently reads (or as it read at a replayed
+// instant). Tags are sorted; Tags and Metadata are never null.
+type Entry struct {
+	Seq       int64             `json:"seq" yaml:"seq"`
+	ID        string            `json:"id" yaml:"id"`
+	Timestamp string            `json:"timestamp" yaml:"timestamp"`
+	Project   string            `json:"project" yaml:"project"`
+	Kind      string            `json:"kind" yaml:"kind"`
+	Title     string            `json:"title" yaml:"title"`
+	Content   string            `json:"content" yaml:"content"`
+	Tags      []string          `json:"tags" yaml:"tags"`
+	Metadata  map[string]string `json:"metadata" yaml:"metadata"`
+	Retracted bool              `json:"retracted" yaml:"retracted"`
+}
+
+// NewEntry converts a folded event. Timestamps keep their recorded offset
+// and full precision (RFC3339Nano).
+func NewEntry(e event.Event) Entry {
+	kind := e.Kind
+	if kind == "" {
+		kind = event.RecordKind
+	}
+	tags := sortedStrings(e.Tags)
+	metadata := make(map[string]string, len(e.Metadata))
+	for k, v := range e.Metadata {
+		metadata[k] = v
+	}
+	return Entry{
+		Seq:       e.Seq,
+		ID:        e.ID,
+		Timestamp: FormatTime(e.Timestamp),
+		Project:   e.Project,
+		Kind:      string(kind),
+		Title:     e.Title,
+		Content:   e.Content,
+		Tags:      tags,
+		Metadata:  metadata,
+		Retracted: e.Retracted,
+	}
+}
+
+// FormatTime renders instants the way every document does.
+func FormatTime(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+// Entries is a list of entries (timeline, tag <name>).
+type Entries struct {
+	Header  `yaml:",inline"`
+	Entries []Entry `json:"entries" yaml:"entries"`
+}
+
+// NewEntries builds an Entries document.
+func NewEntries(events []event.Event) Entries {
+	entries := make([]Entry, 0, len(events))
+	for _, e := range events {
+		entries = append(entries, NewEntry(e))
+	}
+	return Entries{Header: header("entries"), Entries: entries}
+}
+
+func (d Entries) items() []any { return anySlice(d.Entries) }
+
+// Link is a relationship of a viewed entry. Direction is "outgoing" (this
+// entry relates to the other) or "incoming" (the other relates to this one).
+// Seq is zero when the other entry is unknown; SHA is set when it is a commit.
+type Link struct {
+	Relation  string `json:"relation" yaml:"relation"`
+	Direction string `json:"direction" yaml:"direction"`
+	Seq       int64  `json:"seq" yaml:"seq"`
+	ID        string `json:"id" yaml:"id"`
+	Title     string `json:"title" yaml:"title"`
+	SHA       string `json:"sha,omitempty" yaml:"sha,omitempty"`
+}
+
+// Link directions.
+const (
+	Outgoing = "outgoing"
+	Incoming = "incoming"
+)
+
+// EntryDetail is a single entry with its links (view).
+type EntryDetail struct {
+	Header    `yaml:",inline"`
+	Entry     Entry  `json:"entry" yaml:"entry"`
+	Links     []Link `json:"links" yaml:"links"`
+	Revisions int    `json:"revisions" yaml:"revisions"`
+}
+
+// NewEntryDetail builds an EntryDetail document.
+func NewEntryDetail(e event.Event, links []Link, revisions int) EntryDetail {
+	if links == nil {
+		links = []Link{}
+	}
+	return EntryDetail{Header: header("entry"), Entry: NewEntry(e), Links: links, Revisions: revisions}
+}
+
+func (d EntryDetail) items() []any { return nil }
+
+// Revision is one version of an entry, oldest first (Number 1 is the original).
+type Revision struct {
+	Number int    `json:"number" yaml:"number"`
+	Seq    int64  `json:"seq" yaml:"seq"`
+	At     string `json:"at" yaml:"at"`
+	Entry  Entry  `json:"entry" yaml:"entry"`
+}
+
+// Revisions lists every version of an entry (view --revisions).
+type Revisions struct {
+	Header    `yaml:",inline"`
+	Revisions []Revision `json:"revisions" yaml:"revisions"`
+}
+
+// NewRevisions builds a Revisions document.
+func NewRevisions(revisions []Revision) Revisions {
+	if revisions == nil {
+		revisions = []Revision{}
+	}
+	return Revisions{Header: header("revisions"), Revisions: revisions}
+}
+
+func (d Revisions) items() []any { return anySlice(d.Revisions) }
+
+// Decision is a decision in a replayed state. SupersededBy is the seq of the
+// entry that superseded it, or null.
+type Decision struct {
+	Entry        `yaml:",inline"`
+	SupersededBy *int64 `json:"superseded_by" yaml:"superseded_by"`
+}
+
+// StateDecision is a decision in a replayed state. Status is active,
+// superseded or reverted; Context is the most recent related record, or null.
+type StateDecision struct {
+	Decision `yaml:",inline"`
+	Status   string `json:"status" yaml:"status"`
+	Context  *Entry `json:"context" yaml:"context"`
+}
+
+// CommitGroup summarises the commits on one project and branch.
+type CommitGroup struct {
+	Project string `json:"project" yaml:"project"`
+	Branch  string `json:"branch" yaml:"branch"`
+	Count   int    `json:"count" yaml:"count"`
+	Latest  Entry  `json:"latest" yaml:"latest"`
+}
+
+// State is the log replayed to an instant (state --at).
+type State struct {
+	Header    `yaml:",inline"`
+	At        string          `json:"at" yaml:"at"`
+	Decisions []StateDecision `json:"decisions" yaml:"decisions"`
+	Context   []Entry         `json:"context" yaml:"context"`
+	Commits   []CommitGroup   `json:"commits" yaml:"commits"`
+}
+
+// NewState builds a State document from a projection.
+func NewState(st state.State) State {
+	decisions := make([]StateDecision, 0, len(st.Decisions))
+	for _, d := range st.Decisions {
+		out := StateDecision{Decision: Decision{Entry: NewEntry(d.Entry)}, Status: string(d.Status)}
+		if d.SupersededBy != nil {
+			seq := d.SupersededBy.Seq
+			out.SupersededBy = &seq
+		}
+		if d.Context != nil {
+			context := NewEntry(*d.Context)
+			out.Context = &context
+		}
+		decisions = append(decisions, out)
+	}
+	records := make([]Entry, 0, len(st.Context))
+	for _, e := range st.Context {
+		records = append(records, NewEntry(e))
+	}
+	commits := make([]CommitGroup, 0, len(st.Commits))
+	for _, g := range st.Commits {
+		commits = append(commits, CommitGroup{Project: g.Project, Branch: g.Branch, Count: g.Count, Latest: NewEntry(g.Latest)})
+	}
+	return State{Header: header("state"), At: FormatTime(st.At), Decisions: decisions, Context: records, Commits: commits}
+}
+
+func (d State) items() []any { return nil }
+
+// TagChange is an entry whose tags changed between two instants.
+type TagChange struct {
+	Entry   Entry    `json:"entry" yaml:"entry"`
+	Added   []string `json:"added" yaml:"added"`
+	Removed []string `json:"removed" yaml:"removed"`
+}
+
+// NewTagChange builds a TagChange with sorted, non-null tag lists.
+func NewTagChange(e event.Event, added, removed []string) TagChange {
+	return TagChange{Entry: NewEntry(e), Added: sortedStrings(added), Removed: sortedStrings(removed)}
+}
+
+// DecisionChanges groups the decisions that changed between two instants.
+type DecisionChanges struct {
+	Added      []Entry    `json:"added" yaml:"added"`
+	Superseded []Decision `json:"superseded" yaml:"superseded"`
+	Retracted  []Entry    `json:"retracted" yaml:"retracted"`
+}
+
+// StateDiff is what changed in the replayed state between two instants
+// (state --from/--to).
+type StateDiff struct {
+	Header    `yaml:",inline"`
+	From      string          `json:"from" yaml:"from"`
+	To        string          `json:"to" yaml:"to"`
+	Decisions DecisionChanges `json:"decisions" yaml:"decisions"`
+	Tags      []TagChange     `json:"tags" yaml:"tags"`
+	Context   []Entry         `json:"context" yaml:"context"`
+}
+
+// NewStateDiff builds a StateDiff document.
+func NewStateDiff(from, to time.Time, decisions DecisionChanges, tags []TagChange, context []event.Event) StateDiff {
+	if decisions.Added == nil {
+		decisions.Added = []Entry{}
+	}
+	if decisions.Superseded == nil {
+		decisions.Superseded = []Decision{}
+	}
+	if decisions.Retracted == nil {
+		decisions.Retracted = []Entry{}
+	}
+	if tags == nil {
+		tags = []TagChange{}
+	}
+	records := make([]Entry, 0, len(context))
+	for _, e := range context {
+		records = append(records, NewEntry(e))
+	}
+	return StateDiff{Header: header("state_diff"), From: FormatTime(from), To: FormatTime(to), Decisions: decisions, Tags: tags, Context: records}
+}
+
+func (d StateDiff) items() []any { return nil }
+
+// TagCount is a tag and the number of in-scope entries carrying it.
+type TagCount struct {
+	Name  string `json:"name" yaml:"name"`
+	Count int    `json:"count" yaml:"count"`
+}
+
+// Tags lists known tags (tag).
+type Tags struct {
+	Header `yaml:",inline"`
+	Tags   []TagCount `json:"tags" yaml:"tags"`
+}
+
+// NewTags builds a Tags document.
+func NewTags(tags []TagCount) Tags {
+	if tags == nil {
+		tags = []TagCount{}
+	}
+	return Tags{Header: header("tags"), Tags: tags}
+}
+
+func (d Tags) items() []any { return anySlice(d.Tags) }
+
+// SearchResult is one ranked hit. Snippet highlights are plain "**" markers.
+type SearchResult struct {
+	Entry   Entry   `json:"entry" yaml:"entry"`
+	Snippet string  `json:"snippet" yaml:"snippet"`
+	Score   float64 `json:"score" yaml:"score"`
+}
+
+// Search lists hits best-first (search).
+type Search struct {
+	Header  `yaml:",inline"`
+	Query   string         `json:"query" yaml:"query"`
+	Results []SearchResult `json:"results" yaml:"results"`
+}
+
+// NewSearch builds a Search document.
+func NewSearch(query string, results []SearchResult) Search {
+	if results == nil {
+		results = []SearchResult{}
+	}
+	return Search{Header: header("search"), Query: query, Results: results}
+}
+
+func (d Search) items() []any { return anySlice(d.Results) }
+
+// Project is a known project.
+type Project struct {
+	Name   string `json:"name" yaml:"name"`
+	Active bool   `json:"active" yaml:"active"`
+}
+
+// Projects lists known projects (projects list).
+type Projects struct {
+	Header   `yaml:",inline"`
+	Projects []Project `json:"projects" yaml:"projects"`
+}
+
+// NewProjects builds a Projects document.
+func NewProjects(projects []Project) Projects {
+	if projects == nil {
+		projects = []Project{}
+	}
+	return Projects{Header: header("projects"), Projects: projects}
+}
+
+func (d Projects) items() []any { return anySlice(d.Projects) }
+
+// GraphNode is a node of the semantic graph. Seq is set for entry nodes.
+type GraphNode struct {
+	ID       string `json:"id" yaml:"id"`
+	Kind     string `json:"kind" yaml:"kind"`
+	Label    string `json:"label" yaml:"label"`
+	Seq      int64  `json:"seq" yaml:"seq"`
+	Project  string `json:"project" yaml:"project"`
+	Distance int    `json:"distance" yaml:"distance"`
+}
+
+// GraphEdge is a typed relationship between two node IDs.
+type GraphEdge struct {
+	From     string `json:"from" yaml:"from"`
+	To       string `json:"to" yaml:"to"`
+	Relation string `json:"relation" yaml:"relation"`
+}
+
+// Graph is a graph summary (graph) or a node's neighbourhood (graph <node>).
+// Counts maps node kinds to how many nodes of that kind are in the graph (or
+// the neighbourhood). Nodes and Edges are only filled for a neighbourhood,
+// with Distance counting hops from Start. At is the replayed instant, or omitted for the current graph.
+type Graph struct {
+	Header `yaml:",inline"`
+	At     string         `json:"at,omitempty" yaml:"at,omitempty"`
+	Start  string         `json:"start,omitempty" yaml:"start,omitempty"`
+	Counts map[string]int `json:"counts" yaml:"counts"`
+	Nodes  []GraphNode    `json:"nodes" yaml:"nodes"`
+	Edges  []GraphEdge    `json:"edges" yaml:"edges"`
+}
+
+// NewGraph builds a Graph document.
+func NewGraph(at, start string, counts map[string]int, nodes []GraphNode, edges []GraphEdge) Graph {
+	if counts == nil {
+		counts = map[string]int{}
+	}
+	if nodes == nil {
+		nodes = []GraphNode{}
+	}
+	if edges == nil {
+		edges = []GraphEdge{}
+	}
+	return Graph{Header: header("graph"), At: at, Start: start, Counts: counts, Nodes: nodes, Edges: edges}
+}
+
+func (d Graph) items() []any { return nil }
+
+// DoctorProblem is something a health check found wrong and how to fix it.
+type DoctorProblem struct {
+	Problem string `json:"problem" yaml:"problem"`
+	Fix     string `json:"fix" yaml:"fix"`
+}
+
+// DoctorCheck is one health check. Detail says what was checked or found
+// when nothing is wrong.
+type DoctorCheck struct {
+	Name     string          `json:"name" yaml:"name"`
+	OK       bool            `json:"ok" yaml:"ok"`
+	Detail   string          `json:"detail" yaml:"detail"`
+	Problems []DoctorProblem `json:"problems" yaml:"problems"`
+}
+
+// Doctor is the result of every health check (doctor).
+type Doctor struct {
+	Header `yaml:",inline"`
+	OK     bool          `json:"ok" yaml:"ok"`
+	Checks []DoctorCheck `json:"checks" yaml:"checks"`
+}
+
+// NewDoctor builds a Doctor document; it is OK when every check is.
+func NewDoctor(checks []DoctorCheck) Doctor {
+	ok := true
+	for i := range checks {
+		if checks[i].Problems == nil {
+			checks[i].Problems = []DoctorProblem{}
+		}
+		ok = ok && checks[i].OK
+	}
+	if checks == nil {
+		checks = []DoctorCheck{}
+	}
+	return Doctor{Header: header("doctor"), OK: ok, Checks: checks}
+}
+
+func (d Doctor) items() []any { return anySlice(d.Checks) }
+
+// sortedStrings returns a sorted, non-null copy of values.
+func sortedStrings(values []string) []string {
+	out := append([]string{}, values...)
+	sort.Strings(out)
+	return out
+}
+
+func anySlice[T any](values []T) []any {
+	out := make([]any, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
@@ -0,0 +1,162 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/divijg19/sage/internal/event"
+	"github.com/divijg19/sage/internal/state"
+)
+
+func fixtureEvents() []event.Event {
+	at := time.Date(2026, 1, 9, 21, 30, 0, 123, time.FixedZone("IST", 5*3600+1800))
+	return []event.Event{
+		{Seq: 7, ID: "d1", Timestamp: at, Project: "api", Kind: event.DecisionKind, Title: "Use JWT", Content: "a <b> & c", Tags: []string{"backend", "auth"}},
+		{Seq: 9, ID: "r1", Timestamp: at.Add(time.Hour), Project: "api", Title: "Notes", Retracted: true},
+	}
+}
+
+func TestWrite_JSONIncludesHeaderAndSeq(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatJSON, NewEntries(fixtureEvents())); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Schema  int              `json:"schema"`
+		Type    string           `json:"type"`
+		Entries []map[string]any `json:"entries"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, buf.String())
+	}
+	if doc.Schema != SchemaVersion || doc.Type != "entries" || len(doc.Entries) != 2 {
+		t.Fatalf("unexpected document: %+v", doc)
+	}
+
+	first := doc.Entries[0]
+	if first["seq"] != float64(7) || first["kind"] != "decision" || first["content"] != "a <b> & c" {
+		t.Fatalf("unexpected entry: %v", first)
+	}
+	if first["timestamp"] != "2026-01-09T21:30:00.000000123+05:30" {
+		t.Fatalf("timestamp should keep offset and precision, got %v", first["timestamp"])
+	}
+	if tags := first["tags"].([]any); tags[0] != "auth" || tags[1] != "backend" {
+		t.Fatalf("tags should be sorted, got %v", tags)
+	}
+
+	second := doc.Entries[1]
+	if second["kind"] != "record" || second["retracted"] != true {
+		t.Fatalf("unexpected entry: %v", second)
+	}
+	if _, ok := second["tags"].([]any); !ok {
+		t.Fatalf("tags should be an empty array, got %v", second["tags"])
+	}
+	if _, ok := second["metadata"].(map[string]any); !ok {
+		t.Fatalf("metadata should be an empty object, got %v", second["metadata"])
+	}
+}
+
+func TestWrite_JSONLWritesOneLinePerItem(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatJSONL, NewEntries(fixtureEvents())); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", buf.String())
+	}
+	var e Entry
+	if err := json.Unmarshal([]byte(lines[1]), &e); err != nil || e.Seq != 9 || e.ID != "r1" {
+		t.Fatalf("unexpected line %q (%v)", lines[1], err)
+	}
+
+	// Documents that are not lists stay whole.
+	buf.Reset()
+	if err := Write(&buf, FormatJSONL, NewEntryDetail(fixtureEvents()[0], nil, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(buf.String(), "\n") != 1 || !strings.HasPrefix(buf.String(), `{"schema":1,"type":"entry",`) {
+		t.Fatalf("unexpected jsonl document: %q", buf.String())
+	}
+
+	// An empty list writes nothing.
+	buf.Reset()
+	if err := Write(&buf, FormatJSONL, NewEntries(nil)); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("expected no output, got %q", buf.String())
+	}
+}
+
+func TestWrite_YAMLMatchesJSONFields(t *testing.T) {
+	events := fixtureEvents()
+	superseder := events[1]
+	superseder.Seq = 9
+	doc := NewState(state.State{
+		At:        events[0].Timestamp,
+		Decisions: []state.Decision{{Entry: events[0], Status: state.Superseded, SupersededBy: &superseder}},
+		Context:   events[1:],
+		Commits:   []state.CommitGroup{{Project: "api", Branch: "main", Count: 2, Latest: events[1]}},
+	})
+
+	var jsonBuf, yamlBuf bytes.Buffer
+	if err := Write(&jsonBuf, FormatJSON, doc); err != nil {
+		t.Fatal(err)
+	}
+	if err := Write(&yamlBuf, FormatYAML, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	var fromJSON, fromYAML map[string]any
+	if err := json.Unmarshal(jsonBuf.Bytes(), &fromJSON); err != nil {
+		t.Fatal(err)
+	}
+	if err := yaml.Unmarshal(yamlBuf.Bytes(), &fromYAML); err != nil {
+		t.Fatalf("invalid yaml: %v\n%s", err, yamlBuf.String())
+	}
+	// Round-trip both through JSON so number types compare equal.
+	normalize := func(v map[string]any) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	if normalize(fromJSON) != normalize(fromYAML) {
+		t.Fatalf("yaml and json differ:\njson: %s\nyaml: %s", normalize(fromJSON), normalize(fromYAML))
+	}
+
+	decision := fromJSON["decisions"].([]any)[0].(map[string]any)
+	if decision["seq"] != float64(7) || decision["superseded_by"] != float64(9) || decision["status"] != "superseded" {
+		t.Fatalf("decision should inline the entry and carry superseded_by and status: %v", decision)
+	}
+	if _, ok := decision["context"]; !ok || decision["context"] != nil {
+		t.Fatalf("missing related context should be null: %v", decision)
+	}
+	commit := fromJSON["commits"].([]any)[0].(map[string]any)
+	if commit["branch"] != "main" || commit["count"] != float64(2) {
+		t.Fatalf("unexpected commit group: %v", commit)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for in, want := range map[string]Format{"": FormatText, "JSON": FormatJSON, " jsonl ": FormatJSONL, "yaml": FormatYAML, "text": FormatText} {
+		got, ok := ParseFormat(in)
+		if !ok || got != want {
+			t.Fatalf("ParseFormat(%q) = %q, %v", in, got, ok)
+		}
+	}
+	if _, ok := ParseFormat("xml"); ok {
+		t.Fatal("xml should not parse")
+	}
+	if FormatText.Structured() || !FormatYAML.Structured() {
+		t.Fatal("Structured is wrong")
+	}
+}
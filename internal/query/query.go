@@ -0,0 +1,360 @@
+// Package query parses Sage's filter syntax, e.g.
+//
+//	kind:decision tag:auth -tag:wip project:api path:internal/store after:2026-01-01 "exact phrase"
+//
+// A parsed Query is evaluated in two places: internal/store compiles it to SQL
+// against the current state of the log, and Match evaluates it against a
+// single folded entry (used when replaying the past, where the current state
+// does not apply). Both give the same answer for the same entry.
+package query
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/divijg19/sage/internal/event"
+	"github.com/divijg19/sage/internal/timeexpr"
+)
+
+// Field names a filter. The empty field is free text.
+type Field string
+
+const (
+	FieldText    Field = ""
+	FieldKind    Field = "kind"
+	FieldTag     Field = "tag"
+	FieldProject Field = "project"
+	FieldPath    Field = "path"
+	FieldAfter   Field = "after"
+	FieldBefore  Field = "before"
+)
+
+// Fields lists every named filter, in the order they are documented.
+func Fields() []Field {
+	return []Field{FieldKind, FieldTag, FieldProject, FieldPath, FieldAfter, FieldBefore}
+}
+
+// Term is one filter. A query matches an entry when every term does.
+type Term struct {
+	Field   Field
+	Negated bool
+	// Values are alternatives: the term matches when any of them does
+	// (tag:auth,backend). Text terms have exactly one value.
+	Values []string
+	// Phrase marks quoted text, matched as an exact phrase. Unquoted words
+	// match as prefixes.
+	Phrase bool
+	// Time is the bound for after: (inclusive) and before: (exclusive).
+	Time time.Time
+}
+
+// Query is a parsed filter expression.
+type Query struct {
+	Terms []Term
+}
+
+// Options controls parsing.
+type Options struct {
+	// ParseTime parses after:/before: values. Nil means timeexpr.Parse.
+	ParseTime func(string) (time.Time, error)
+}
+
+// Parse parses input into a Query. Terms are separated by whitespace; a
+// leading '-' negates a term, "double quotes" group a phrase (or a filter
+// value containing spaces, as in tag:"two words"), commas separate
+// alternatives inside kind:, tag:, project: and path:, and #name is short for tag:name.
+func Parse(input string, opts Options) (Query, error) {
+	parseTime := opts.ParseTime
+	if parseTime == nil {
+		parseTime = timeexpr.Parse
+	}
+
+	var q Query
+	s := input
+	for {
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+		if s == "" {
+			return q, nil
+		}
+
+		negated := false
+		if len(s) > 1 && s[0] == '-' && !unicode.IsSpace(rune(s[1])) {
+			negated = true
+			s = s[1:]
+		}
+
+		if s[0] == '"' {
+			var phrase string
+			phrase, s = readQuoted(s[1:])
+			q.addText(Term{Field: FieldText, Negated: negated, Values: []string{phrase}, Phrase: true})
+			continue
+		}
+
+		end := strings.IndexFunc(s, unicode.IsSpace)
+		if end < 0 {
+			end = len(s)
+		}
+		word := s[:end]
+		if len(word) > 1 && word[0] == '#' {
+			s = s[end:]
+			term, err := parseFilter(FieldTag, word[1:], parseTime)
+			if err != nil {
+				return Query{}, err
+			}
+			term.Negated = negated
+			q.Terms = append(q.Terms, term)
+			continue
+		}
+		colon := strings.IndexByte(word, ':')
+		if colon <= 0 {
+			s = s[end:]
+			q.addText(Term{Field: FieldText, Negated: negated, Values: []string{word}})
+			continue
+		}
+
+		name := strings.ToLower(word[:colon])
+		var value string
+		if colon+1 < len(s) && s[colon+1] == '"' {
+			value, s = readQuoted(s[colon+2:])
+		} else {
+			value, s = word[colon+1:], s[end:]
+		}
+
+		term, err := parseFilter(Field(name), value, parseTime)
+		if err != nil {
+			return Query{}, err
+		}
+		term.Negated = negated
+		q.Terms = append(q.Terms, term)
+	}
+}
+
+// addText keeps text terms that contain at least one word; punctuation alone
+// can never match, so it is dropped rather than matching nothing.
+func (q *Query) addText(t Term) {
+	if len(Tokens(t.Values[0])) > 0 {
+		q.Terms = append(q.Terms, t)
+	}
+}
+
+// readQuoted returns the text up to the next '"' and the input after it.
+// An unterminated quote runs to the end of the input.
+func readQuoted(s string) (string, string) {
+	end := strings.IndexByte(s, '"')
+	if end < 0 {
+		return s, ""
+	}
+	return s[:end], s[end+1:]
+}
+
+func parseFilter(field Field, value string, parseTime func(string) (time.Time, error)) (Term, error) {
+	value = strings.TrimSpace(value)
+	switch field {
+	case FieldKind, FieldTag, FieldProject:
+		var values []string
+		for _, v := range strings.Split(value, ",") {
+			v = strings.ToLower(strings.TrimSpace(v))
+			if field == FieldTag {
+				v = strings.TrimPrefix(v, "#")
+			}
+			if v == "" {
+				continue
+			}
+			if field == FieldKind {
+				kind, ok := ParseKind(v)
+				if !ok {
+					return Term{}, fmt.Errorf("unknown kind %q in kind: (use record, decision, commit or git)", v)
+				}
+				v = string(kind)
+			}
+			values = append(values, v)
+		}
+		if len(values) == 0 {
+			return Term{}, fmt.Errorf("%s: needs a value", field)
+		}
+		return Term{Field: field, Values: values}, nil
+
+	case FieldPath:
+		var values []string
+		for _, v := range strings.Split(value, ",") {
+			if v = CleanPath(v); v != "" {
+				values = append(values, v)
+			}
+		}
+		if len(values) == 0 {
+			return Term{}, fmt.Errorf("%s: needs a value", field)
+		}
+		return Term{Field: field, Values: values}, nil
+
+	case FieldAfter, FieldBefore:
+		if value == "" {
+			return Term{}, fmt.Errorf("%s: needs a time", field)
+		}
+		t, err := parseTime(value)
+		if err != nil {
+			return Term{}, fmt.Errorf("invalid time in %s:%s", field, value)
+		}
+		return Term{Field: field, Values: []string{value}, Time: t}, nil
+
+	default:
+		names := make([]string, 0, len(Fields()))
+		for _, f := range Fields() {
+			names = append(names, string(f))
+		}
+		return Term{}, fmt.Errorf("unknown filter %q (use %s; quote text to search for it literally)", string(field)+":", strings.Join(names, ", "))
+	}
+}
+
+// CleanPath normalises a path: value to the form commits record files in:
+// relative to the repository root, with no leading "./" or trailing "/".
+// Paths are case-sensitive, as Git's are.
+func CleanPath(p string) string {
+	p = strings.TrimSpace(p)
+	for strings.HasPrefix(p, "./") {
+		p = p[2:]
+	}
+	return strings.TrimRight(p, "/")
+}
+
+// PathMatches reports whether file is path or lies under it as a directory.
+func PathMatches(file, path string) bool {
+	return file == path || strings.HasPrefix(file, path+"/")
+}
+
+// ParseKind resolves an entry kind name or its one-letter alias (r, d, c, g).
+func ParseKind(s string) (event.EntryKind, bool) {
+	switch s {
+	case "record", "r":
+		return event.RecordKind, true
+	case "decision", "d":
+		return event.DecisionKind, true
+	case "commit", "c":
+		return event.CommitKind, true
+	case "git", "g":
+		return event.GitKind, true
+	}
+	return "", false
+}
+
+// Empty reports whether the query has no terms and so matches everything.
+func (q Query) Empty() bool {
+	return len(q.Terms) == 0
+}
+
+// Has reports whether any term filters on field.
+func (q Query) Has(field Field) bool {
+	for _, t := range q.Terms {
+		if t.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// With returns a copy of q with extra terms ANDed on.
+func (q Query) With(terms ...Term) Query {
+	out := Query{Terms: make([]Term, 0, len(q.Terms)+len(terms))}
+	out.Terms = append(out.Terms, q.Terms...)
+	out.Terms = append(out.Terms, terms...)
+	return out
+}
+
+// Only returns the terms of q that filter on one of fields.
+func (q Query) Only(fields ...Field) Query {
+	var out Query
+	for _, t := range q.Terms {
+		for _, f := range fields {
+			if t.Field == f {
+				out.Terms = append(out.Terms, t)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// Match reports whether the folded entry e satisfies every term.
+func (q Query) Match(e event.Event) bool {
+	for _, t := range q.Terms {
+		if t.match(e) == t.Negated {
+			return false
+		}
+	}
+	return true
+}
+
+func (t Term) match(e event.Event) bool {
+	switch t.Field {
+	case FieldKind:
+		kind := e.Kind
+		if kind == "" {
+			kind = event.RecordKind
+		}
+		return containsFold(t.Values, string(kind))
+	case FieldTag:
+		for _, tag := range e.Tags {
+			if containsFold(t.Values, strings.TrimSpace(tag)) {
+				return true
+			}
+		}
+		return false
+	case FieldProject:
+		return containsFold(t.Values, e.Project)
+	case FieldPath:
+		for _, file := range strings.Split(e.Metadata[event.MetaFiles], "\n") {
+			for _, v := range t.Values {
+				if file != "" && PathMatches(file, v) {
+					return true
+				}
+			}
+		}
+		return false
+	case FieldAfter:
+		return !e.Timestamp.Before(t.Time)
+	case FieldBefore:
+		return e.Timestamp.Before(t.Time)
+	default:
+		needle := Tokens(t.Values[0])
+		for _, field := range []string{e.Title, e.Content, strings.Join(e.Tags, " "), e.Project} {
+			if containsTokens(Tokens(field), needle, !t.Phrase) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func containsFold(values []string, s string) bool {
+	s = strings.ToLower(s)
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// containsTokens reports whether needle appears as a run inside hay. With
+// prefixLast, the final needle token only has to prefix its counterpart.
+func containsTokens(hay []string, needle []string, prefixLast bool) bool {
+	for i := 0; i+len(needle) <= len(hay); i++ {
+		ok := true
+		for j, n := range needle {
+			h := hay[i+j]
+			if j == len(needle)-1 && prefixLast {
+				ok = strings.HasPrefix(h, n)
+			} else {
+				ok = h == n
+			}
+			if !ok {
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package query
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/divijg19/sage/internal/event"
+)
+
+func TestParse(t *testing.T) {
+	q, err := Parse(`kind:decision tag:auth -tag:wip project:api after:2026-01-01 "exact phrase" #Backend,ops tok tag:"two words"`, Options{})
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	want := []Term{
+		{Field: FieldKind, Values: []string{"decision"}},
+		{Field: FieldTag, Values: []string{"auth"}},
+		{Field: FieldTag, Negated: true, Values: []string{"wip"}},
+		{Field: FieldProject, Values: []string{"api"}},
+		{Field: FieldAfter, Values: []string{"2026-01-01"}, Time: time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local)},
+		{Field: FieldText, Values: []string{"exact phrase"}, Phrase: true},
+		{Field: FieldTag, Values: []string{"backend", "ops"}},
+		{Field: FieldText, Values: []string{"tok"}},
+		{Field: FieldTag, Values: []string{"two words"}},
+	}
+	if !reflect.DeepEqual(q.Terms, want) {
+		t.Fatalf("unexpected terms:\n got %+v\nwant %+v", q.Terms, want)
+	}
+}
+
+func TestParse_EdgeCases(t *testing.T) {
+	empty, err := Parse(`  - ( "" `, Options{})
+	if err != nil || !empty.Empty() {
+		t.Fatalf("expected punctuation-only input to parse as empty, got %+v (%v)", empty, err)
+	}
+
+	if q, err := Parse("path:./internal/store/,README.md", Options{}); err != nil || !reflect.DeepEqual(q.Terms[0].Values, []string{"internal/store", "README.md"}) {
+		t.Fatalf("expected paths to be cleaned and keep their case, got %+v (%v)", q, err)
+	}
+
+	if q, err := Parse("kind:r,d", Options{}); err != nil || !reflect.DeepEqual(q.Terms[0].Values, []string{"record", "decision"}) {
+		t.Fatalf("expected kind aliases to expand, got %+v (%v)", q, err)
+	}
+
+	for raw, wantErr := range map[string]string{
+		"kind:note":         "unknown kind",
+		"tag:":              "needs a value",
+		"path:./":           "needs a value",
+		"after:someday":     "invalid time",
+		"https://example":   "unknown filter",
+		`status:"done now"`: "unknown filter",
+	} {
+		if _, err := Parse(raw, Options{}); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Fatalf("Parse(%q): expected error containing %q, got %v", raw, wantErr, err)
+		}
+	}
+
+	calls := 0
+	custom := Options{ParseTime: func(s string) (time.Time, error) {
+		calls++
+		return time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), nil
+	}}
+	if q, err := Parse("before:yesterday", custom); err != nil || calls != 1 || !q.Terms[0].Time.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected custom time parser to be used, got %+v (%v)", q, err)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	e := event.Event{
+		Timestamp: time.Date(2026, 1, 9, 10, 0, 0, 0, time.UTC),
+		Project:   "api",
+		Title:     "Résumé upload fails",
+		Content:   "Token-expiry handling is wrong.",
+		Tags:      []string{"Auth"},
+		Metadata:  map[string]string{event.MetaFiles: "internal/store/store.go\ndocs/CLI.md"},
+	}
+
+	cases := map[string]bool{
+		"":                            true,
+		"kind:record":                 true,
+		"kind:decision":               false,
+		"tag:auth project:api":        true,
+		"-tag:auth":                   false,
+		"resume":                      true,
+		"upl":                         true,
+		"ploa":                        false,
+		`"token expiry"`:              true,
+		`"token exp"`:                 false,
+		"token-exp":                   true,
+		"after:2026-01-09T10:00:00Z":  true,
+		"before:2026-01-09T10:00:00Z": false,
+		"path:internal/store":         true,
+		"path:docs/CLI.md":            true,
+		"path:docs/CLI":               false,
+		"-path:internal":              false,
+	}
+	for raw, want := range cases {
+		q, err := Parse(raw, Options{})
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", raw, err)
+		}
+		if got := q.Match(e); got != want {
+			t.Fatalf("%q: Match = %v, want %v", raw, got, want)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package query
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// foldDiacritics strips combining marks so "résumé" and "resume" compare equal,
+// mirroring the search index's remove_diacritics tokenizer option.
+var foldDiacritics = transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+// Tokens splits s into lowercase words the way the search index does: runs of
+// letters and digits, with diacritics removed.
+func Tokens(s string) []string {
+	if folded, _, err := transform.String(foldDiacritics, s); err == nil {
+		s = folded
+	}
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
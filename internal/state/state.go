@@ -0,0 +1,222 @@
+// Package state projects the replayed log into what was true at an instant:
+// which decisions were in force, which had been superseded or reverted, the
+// context recorded around them and the commits made per project and branch.
+//
+// The projection is derived entirely from entries and links, so it can be
+// rebuilt for any instant and rendered by the CLI and Chronicle alike.
+package state
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/divijg19/sage/internal/event"
+	"github.com/divijg19/sage/internal/query"
+)
+
+// Status is whether a decision was in force.
+type Status string
+
+const (
+	// Active decisions were in force.
+	Active Status = "active"
+	// Superseded decisions were replaced by a live entry that supersedes them.
+	Superseded Status = "superseded"
+	// Reverted decisions were retracted. They are only listed with
+	// Options.IncludeRetracted.
+	Reverted Status = "reverted"
+)
+
+// Statuses lists every status in display order.
+func Statuses() []Status {
+	return []Status{Active, Superseded, Reverted}
+}
+
+// Decision is a decision and where it stood.
+type Decision struct {
+	Entry  event.Event
+	Status Status
+	// SupersededBy is the entry that superseded it; set only when Superseded.
+	SupersededBy *event.Event
+	// Context is the most recent live record linked to the decision, or
+	// failing that sharing a tag with it; nil when there is none.
+	Context *event.Event
+}
+
+// CommitGroup summarises the commits recorded for one project and branch.
+type CommitGroup struct {
+	Project string
+	Branch  string
+	Count   int
+	// Latest is the most recently recorded commit in the group.
+	Latest event.Event
+}
+
+// State is the projection at one instant.
+type State struct {
+	At        time.Time
+	Decisions []Decision
+	Context   []event.Event
+	Commits   []CommitGroup
+}
+
+// Options controls what Build lists.
+type Options struct {
+	// Match narrows what is listed. Supersession and related context are
+	// still judged across every entry given to Build.
+	Match query.Query
+	// IncludeRetracted keeps retracted entries (marked); retracted
+	// decisions are listed as Reverted.
+	IncludeRetracted bool
+}
+
+// Build projects entries (as replayed to at, retracted ones marked) and the
+// links declared by then. Decisions and context keep the order of entries;
+// commit groups are sorted by project and branch.
+func Build(at time.Time, entries []event.Event, links []event.Link, opts Options) State {
+	live := make([]event.Event, 0, len(entries))
+	for _, e := range entries {
+		if !e.Retracted {
+			live = append(live, e)
+		}
+	}
+	superseded := SupersededBy(live, links)
+	related := relatedContext(live, links)
+
+	st := State{At: at}
+	groups := make(map[[2]string]*CommitGroup)
+	for _, e := range entries {
+		if !opts.Match.Empty() && !opts.Match.Match(e) {
+			continue
+		}
+		if e.Retracted && !opts.IncludeRetracted {
+			continue
+		}
+		switch e.Kind {
+		case event.DecisionKind:
+			d := Decision{Entry: e, Status: Active}
+			if by, ok := superseded[e.ID]; ok {
+				d.Status, d.SupersededBy = Superseded, &by
+			}
+			if e.Retracted {
+				d.Status, d.SupersededBy = Reverted, nil
+			}
+			d.Context = related(e)
+			st.Decisions = append(st.Decisions, d)
+		case event.RecordKind:
+			st.Context = append(st.Context, e)
+		case event.CommitKind:
+			key := [2]string{e.Project, e.Metadata["branch"]}
+			g, ok := groups[key]
+			if !ok {
+				g = &CommitGroup{Project: key[0], Branch: key[1]}
+				groups[key] = g
+			}
+			g.Count++
+			if g.Count == 1 || later(e, g.Latest) {
+				g.Latest = e
+			}
+		}
+	}
+
+	for _, g := range groups {
+		st.Commits = append(st.Commits, *g)
+	}
+	slices.SortFunc(st.Commits, func(a, b CommitGroup) int {
+		return cmp.Or(cmp.Compare(a.Project, b.Project), cmp.Compare(a.Branch, b.Branch))
+	})
+	return st
+}
+
+// WithStatus returns the decisions with the given status, in order.
+func (s State) WithStatus(status Status) []Decision {
+	var out []Decision
+	for _, d := range s.Decisions {
+		if d.Status == status {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// Decision returns the projected decision with the given entry ID.
+func (s State) Decision(id string) (Decision, bool) {
+	for _, d := range s.Decisions {
+		if d.Entry.ID == id {
+			return d, true
+		}
+	}
+	return Decision{}, false
+}
+
+// SupersededBy maps the ID of each superseded entry to the entry that
+// superseded it. Only links whose source is among entries count, so retracted
+// or out-of-scope entries never supersede anything.
+func SupersededBy(entries []event.Event, links []event.Link) map[string]event.Event {
+	byID := make(map[string]event.Event, len(entries))
+	for _, e := range entries {
+		byID[e.ID] = e
+	}
+
+	out := make(map[string]event.Event)
+	for _, l := range links {
+		if l.Relation != event.Supersedes {
+			continue
+		}
+		if from, ok := byID[l.From]; ok {
+			out[l.To] = from
+		}
+	}
+	return out
+}
+
+// relatedContext returns a lookup of the most recent record related to a
+// decision: linked to it in either direction, or else sharing a tag.
+func relatedContext(live []event.Event, links []event.Link) func(event.Event) *event.Event {
+	records := make(map[string]event.Event)
+	byTag := make(map[string][]event.Event)
+	for _, e := range live {
+		if e.Kind != event.RecordKind {
+			continue
+		}
+		records[e.ID] = e
+		for _, t := range e.Tags {
+			byTag[t] = append(byTag[t], e)
+		}
+	}
+
+	linked := make(map[string][]event.Event)
+	for _, l := range links {
+		if r, ok := records[l.To]; ok {
+			linked[l.From] = append(linked[l.From], r)
+		}
+		if r, ok := records[l.From]; ok {
+			linked[l.To] = append(linked[l.To], r)
+		}
+	}
+
+	return func(d event.Event) *event.Event {
+		candidates := linked[d.ID]
+		if len(candidates) == 0 {
+			for _, t := range d.Tags {
+				candidates = append(candidates, byTag[t]...)
+			}
+		}
+		var best *event.Event
+		for _, c := range candidates {
+			if best == nil || later(c, *best) {
+				best = &c
+			}
+		}
+		return best
+	}
+}
+
+// later reports whether a was recorded after b, breaking ties by seq.
+func later(a, b event.Event) bool {
+	if !a.Timestamp.Equal(b.Timestamp) {
+		return a.Timestamp.After(b.Timestamp)
+	}
+	return a.Seq > b.Seq
+}
@@ -0,0 +1,157 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/divijg19/sage/internal/event"
+	"github.com/divijg19/sage/internal/query"
+)
+
+var base = time.Date(2026, 1, 9, 9, 0, 0, 0, time.UTC)
+
+func entry(seq int64, id string, kind event.EntryKind, tags ...string) event.Event {
+	return event.Event{Seq: seq, ID: id, Timestamp: base.Add(time.Duration(seq) * time.Hour), Project: "api", Kind: kind, Title: id, Tags: tags}
+}
+
+func commit(seq int64, id, project, branch string) event.Event {
+	e := entry(seq, id, event.CommitKind)
+	e.Project = project
+	e.Metadata = map[string]string{"branch": branch}
+	return e
+}
+
+func link(from, rel, to string) event.Link {
+	return event.Link{From: from, To: to, Relation: event.Relation(rel)}
+}
+
+func fixture() ([]event.Event, []event.Link) {
+	reverted := entry(3, "cookies", event.DecisionKind)
+	reverted.Retracted = true
+	retractedNote := entry(8, "scratch", event.RecordKind, "auth")
+	retractedNote.Retracted = true
+
+	entries := []event.Event{
+		entry(1, "sessions", event.DecisionKind, "auth"),
+		entry(2, "jwt", event.DecisionKind, "auth"),
+		reverted,
+		entry(4, "cache", event.DecisionKind, "storage"),
+		entry(5, "auth-notes", event.RecordKind, "auth"),
+		entry(6, "rollout", event.RecordKind),
+		commit(7, "c1", "api", "main"),
+		retractedNote,
+		commit(9, "c2", "api", "main"),
+		commit(10, "c3", "api", "feature"),
+		commit(11, "c4", "web", "main"),
+		entry(12, "later-auth", event.RecordKind, "auth"),
+	}
+	links := []event.Link{
+		link("jwt", "supersedes", "sessions"),
+		// A retracted entry supersedes nothing.
+		link("cookies", "supersedes", "cache"),
+		link("rollout", "references", "jwt"),
+	}
+	return entries, links
+}
+
+func TestBuild_GroupsDecisions(t *testing.T) {
+	entries, links := fixture()
+	st := Build(base, entries, links, Options{})
+
+	want := map[string]Status{"sessions": Superseded, "jwt": Active, "cache": Active}
+	if len(st.Decisions) != len(want) {
+		t.Fatalf("expected %d decisions, got %+v", len(want), st.Decisions)
+	}
+	for _, d := range st.Decisions {
+		if d.Status != want[d.Entry.ID] {
+			t.Errorf("%s: status %s, want %s", d.Entry.ID, d.Status, want[d.Entry.ID])
+		}
+	}
+	if got := Build(base, entries, links, Options{IncludeRetracted: true}).WithStatus(Reverted); len(got) != 1 || got[0].Entry.ID != "cookies" {
+		t.Fatalf("IncludeRetracted should list the retracted decision as reverted: %+v", got)
+	}
+
+	sessions, _ := st.Decision("sessions")
+	if sessions.SupersededBy == nil || sessions.SupersededBy.ID != "jwt" {
+		t.Fatalf("sessions should be superseded by jwt: %+v", sessions)
+	}
+	if got := st.WithStatus(Active); len(got) != 2 || got[0].Entry.ID != "jwt" || got[1].Entry.ID != "cache" {
+		t.Fatalf("active decisions should keep entry order: %+v", got)
+	}
+}
+
+func TestBuild_RelatedContext(t *testing.T) {
+	entries, links := fixture()
+	st := Build(base, entries, links, Options{})
+
+	tests := []struct {
+		decision string
+		want     string
+	}{
+		// A linked record wins over newer records that only share a tag.
+		{"jwt", "rollout"},
+		// Otherwise the most recent live record sharing a tag; the retracted
+		// one is skipped.
+		{"sessions", "later-auth"},
+		{"cache", ""},
+	}
+	for _, tt := range tests {
+		d, ok := st.Decision(tt.decision)
+		if !ok {
+			t.Fatalf("missing decision %s", tt.decision)
+		}
+		got := ""
+		if d.Context != nil {
+			got = d.Context.ID
+		}
+		if got != tt.want {
+			t.Errorf("%s: context %q, want %q", tt.decision, got, tt.want)
+		}
+	}
+}
+
+func TestBuild_CommitsAndContext(t *testing.T) {
+	entries, links := fixture()
+	st := Build(base, entries, links, Options{})
+
+	want := []CommitGroup{
+		{Project: "api", Branch: "feature", Count: 1},
+		{Project: "api", Branch: "main", Count: 2},
+		{Project: "web", Branch: "main", Count: 1},
+	}
+	if len(st.Commits) != len(want) {
+		t.Fatalf("unexpected commit groups: %+v", st.Commits)
+	}
+	for i, g := range st.Commits {
+		if g.Project != want[i].Project || g.Branch != want[i].Branch || g.Count != want[i].Count {
+			t.Fatalf("group %d: got %+v, want %+v", i, g, want[i])
+		}
+	}
+	if st.Commits[1].Latest.ID != "c2" {
+		t.Fatalf("latest commit on api/main should be c2, got %s", st.Commits[1].Latest.ID)
+	}
+
+	if len(st.Context) != 3 {
+		t.Fatalf("retracted records should be left out by default: %+v", st.Context)
+	}
+	if st = Build(base, entries, links, Options{IncludeRetracted: true}); len(st.Context) != 4 {
+		t.Fatalf("IncludeRetracted should keep retracted records: %+v", st.Context)
+	}
+}
+
+func TestBuild_MatchNarrowsListingOnly(t *testing.T) {
+	entries, links := fixture()
+	q, err := query.Parse("kind:decision sessions", query.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := Build(base, entries, links, Options{Match: q})
+
+	if len(st.Decisions) != 1 || len(st.Context) != 0 || len(st.Commits) != 0 {
+		t.Fatalf("unexpected listing: %+v", st)
+	}
+	// jwt is filtered out, yet it still supersedes sessions.
+	if d, _ := st.Decision("sessions"); d.Status != Superseded || d.SupersededBy.ID != "jwt" {
+		t.Fatalf("supersession should be judged across every entry: %+v", d)
+	}
+}
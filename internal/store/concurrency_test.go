@@ -0,0 +1,202 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/divijg19/sage/internal/event"
+)
+
+const (
+	hookWriterDB  = "SAGE_TEST_HOOK_WRITER_DB"
+	hookWriterID  = "SAGE_TEST_HOOK_WRITER_ID"
+	hookProcesses = 12
+	hookCommits   = 15
+)
+
+// TestHookWriterProcess is not a test on its own: the concurrency test runs
+// the test binary as a child process with the environment set, playing one
+// background post-commit hook that opens the store and records commits.
+func TestHookWriterProcess(t *testing.T) {
+	path := os.Getenv(hookWriterDB)
+	if path == "" {
+		t.Skip("helper process for TestConcurrentHooksAndStreamingReader")
+	}
+	writer := os.Getenv(hookWriterID)
+	for i := range hookCommits {
+		// Each commit is its own hook run, so each opens the store afresh.
+		s, err := Open(path)
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		e := event.Event{
+			ID:        fmt.Sprintf("git:test:%s-%d", writer, i),
+			Timestamp: time.Now(),
+			Project:   "repo-" + writer,
+			Kind:      event.CommitKind,
+			Title:     "commit " + strconv.Itoa(i),
+			Metadata:  map[string]string{"branch": "main"},
+		}
+		if err := s.Append(e); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+		if err := s.db.Close(); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+	}
+}
+
+func TestConcurrentHooksAndStreamingReader(t *testing.T) {
+	if testing.Short() {
+		t.Skip("spawns processes")
+	}
+	dbPath := filepath.Join(t.TempDir(), "sage.db")
+	s, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	var mode string
+	if err := s.db.QueryRow(`PRAGMA journal_mode;`).Scan(&mode); err != nil || mode != "wal" {
+		t.Fatalf("journal_mode = %q (%v), want wal", mode, err)
+	}
+
+	// Stream the log over and over while the hooks write, as the TUI and
+	// timeline do.
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	var readErr error
+	reads := 0
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for ctx.Err() == nil {
+			var last int64
+			for e, err := range s.Query(ctx, Filter{}) {
+				if err != nil {
+					readErr = err
+					return
+				}
+				if e.Seq <= last {
+					readErr = fmt.Errorf("seq %d streamed after %d", e.Seq, last)
+					return
+				}
+				last = e.Seq
+			}
+			reads++
+		}
+	}()
+
+	procs := make([]*exec.Cmd, hookProcesses)
+	outputs := make([][]byte, hookProcesses)
+	errs := make([]error, hookProcesses)
+	var writers sync.WaitGroup
+	for i := range procs {
+		procs[i] = exec.Command(os.Args[0], "-test.run=^TestHookWriterProcess$", "-test.count=1")
+		procs[i].Env = append(os.Environ(), hookWriterDB+"="+dbPath, hookWriterID+"="+strconv.Itoa(i))
+		writers.Add(1)
+		go func() {
+			defer writers.Done()
+			outputs[i], errs[i] = procs[i].CombinedOutput()
+		}()
+	}
+	writers.Wait()
+	cancel()
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("hook process %d: %v\n%s", i, err, outputs[i])
+		}
+	}
+	if readErr != nil && !errors.Is(readErr, context.Canceled) {
+		t.Fatalf("streaming reader: %v", readErr)
+	}
+	if reads == 0 {
+		t.Fatalf("the reader never finished a pass")
+	}
+
+	want := hookProcesses * hookCommits
+	all, err := Collect(s.Query(context.Background(), Filter{}))
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(all) != want {
+		t.Fatalf("expected %d commits, got %d", want, len(all))
+	}
+	h, err := Inspect(dbPath)
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	if h.Events != want || len(h.SeqGaps)+len(h.DuplicateIDs)+len(h.BadRows)+len(h.Integrity) != 0 {
+		t.Fatalf("unhealthy log after concurrent writes: %+v", h)
+	}
+}
+
+func TestConcurrentAnnotationsOnOneEntry(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "sage.db")
+	s, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	base := time.Date(2026, 1, 9, 10, 0, 0, 0, time.UTC)
+	if err := s.Append(event.Event{ID: "a", Timestamp: base, Project: "p", Kind: event.RecordKind, Title: "Shared"}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	// Several writers, each with its own handle as hook processes would
+	// have, tag the same entry at the same time.
+	const writers, tags = 8, 20
+	errs := make([]error, writers)
+	var wg sync.WaitGroup
+	for w := range writers {
+		ws, err := Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open writer %d: %v", w, err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range tags {
+				errs[w] = ws.Append(event.Event{
+					ID:        fmt.Sprintf("t%d-%d", w, i),
+					Timestamp: base.Add(time.Minute),
+					Project:   "p",
+					Kind:      event.TagKind,
+					Tags:      []string{fmt.Sprintf("w%d-%d", w, i)},
+					Metadata:  map[string]string{event.MetaTarget: "a", event.MetaOp: event.TagOpAdd},
+				})
+				if errs[w] != nil {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	for w, err := range errs {
+		if err != nil {
+			t.Fatalf("writer %d: %v", w, err)
+		}
+	}
+
+	// The projection must hold every tag the log does: none lost to a
+	// writer that folded the entry before another's tag committed.
+	var n int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM entry_tags et JOIN entries en ON en.seq = et.seq WHERE en.id = 'a';`).Scan(&n); err != nil {
+		t.Fatalf("count tags: %v", err)
+	}
+	if n != writers*tags {
+		t.Fatalf("expected %d projected tags, got %d", writers*tags, n)
+	}
+	e, err := s.GetByID("a")
+	if err != nil || e == nil || len(e.Tags) != writers*tags {
+		t.Fatalf("expected the folded entry to carry %d tags, got %+v (%v)", writers*tags, e, err)
+	}
+}
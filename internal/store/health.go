@@ -0,0 +1,147 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/divijg19/sage/internal/event"
+)
+
+// Health is what Inspect found in a database. The zero value of each list
+// means nothing was wrong.
+type Health struct {
+	// Integrity holds the messages of PRAGMA integrity_check other than "ok".
+	Integrity []string
+	Version   int
+	// Pending lists the migrations the next open would apply.
+	Pending []Migration
+	Events  int
+	BadRows []BadRow
+	// DuplicateIDs lists event IDs stored under more than one seq.
+	DuplicateIDs []DuplicateID
+	// SeqGaps lists runs of seqs missing from the append-only log.
+	SeqGaps []SeqGap
+}
+
+// BadRow is an event whose stored JSON does not decode.
+type BadRow struct {
+	Seq int64
+	ID  string
+	Err error
+}
+
+// DuplicateID is an event ID stored more than once.
+type DuplicateID struct {
+	ID   string
+	Seqs []int64
+}
+
+// SeqGap is a run of missing seqs, First to Last inclusive.
+type SeqGap struct {
+	First, Last int64
+}
+
+// Inspect checks the database at path without changing or creating it. A
+// database too new for this binary still gets its integrity checked; the
+// returned error is then a *SchemaTooNewError.
+func Inspect(path string) (Health, error) {
+	if _, err := os.Stat(path); err != nil {
+		return Health{}, err
+	}
+	db, err := sql.Open("sqlite", dsn(path, true))
+	if err != nil {
+		return Health{}, err
+	}
+	defer db.Close()
+
+	var h Health
+	if h.Integrity, err = integrityCheck(db); err != nil {
+		return h, err
+	}
+	if h.Version, err = schemaVersion(db); err != nil {
+		return h, err
+	}
+	if h.Version > LatestVersion() {
+		return h, &SchemaTooNewError{Path: path, Version: h.Version, Supported: LatestVersion()}
+	}
+	h.Pending = pendingAfter(h.Version)
+
+	// The log checks need seq, which v1 logs predate; migrating adds it.
+	if ok, err := hasColumn(db, "events", "seq"); err != nil || !ok {
+		return h, err
+	}
+	if err := inspectEvents(db, &h); err != nil {
+		return h, err
+	}
+	return h, nil
+}
+
+func integrityCheck(db *sql.DB) ([]string, error) {
+	rows, err := db.Query(`PRAGMA integrity_check;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			return nil, err
+		}
+		if msg != "ok" {
+			problems = append(problems, msg)
+		}
+	}
+	return problems, rows.Err()
+}
+
+// inspectEvents walks the log once in seq order, decoding every row.
+func inspectEvents(db *sql.DB, h *Health) error {
+	rows, err := db.Query(`SELECT seq, id, data FROM events ORDER BY seq ASC;`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	seqsByID := make(map[string][]int64)
+	var ids []string
+	var prev int64
+	for rows.Next() {
+		var seq int64
+		var id, raw string
+		if err := rows.Scan(&seq, &id, &raw); err != nil {
+			return err
+		}
+		h.Events++
+
+		if seq > prev+1 {
+			h.SeqGaps = append(h.SeqGaps, SeqGap{First: prev + 1, Last: seq - 1})
+		}
+		prev = seq
+
+		var e event.Event
+		if err := json.Unmarshal([]byte(raw), &e); err != nil {
+			h.BadRows = append(h.BadRows, BadRow{Seq: seq, ID: id, Err: err})
+		} else if e.ID != "" && e.ID != id {
+			h.BadRows = append(h.BadRows, BadRow{Seq: seq, ID: id, Err: fmt.Errorf("JSON id %q does not match the row", e.ID)})
+		}
+
+		if _, seen := seqsByID[id]; !seen {
+			ids = append(ids, id)
+		}
+		seqsByID[id] = append(seqsByID[id], seq)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if seqs := seqsByID[id]; len(seqs) > 1 {
+			h.DuplicateIDs = append(h.DuplicateIDs, DuplicateID{ID: id, Seqs: seqs})
+		}
+	}
+	return nil
+}
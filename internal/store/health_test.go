@@ -0,0 +1,77 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/divijg19/sage/internal/event"
+)
+
+func TestInspect_HealthyDatabase(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "sage.db")
+	s, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	for i := range 3 {
+		e := event.Event{ID: fmt.Sprintf("e%d", i), Timestamp: time.Now(), Project: "p", Kind: event.RecordKind, Title: "t"}
+		if err := s.Append(e); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	h, err := Inspect(dbPath)
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	if h.Events != 3 || h.Version != LatestVersion() || len(h.Pending) != 0 {
+		t.Fatalf("unexpected health: %+v", h)
+	}
+	if len(h.Integrity)+len(h.BadRows)+len(h.DuplicateIDs)+len(h.SeqGaps) != 0 {
+		t.Fatalf("expected no problems: %+v", h)
+	}
+}
+
+func TestInspect_FindsDamagedRows(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "sage.db")
+	s, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	for i := 1; i <= 6; i++ {
+		e := event.Event{ID: fmt.Sprintf("e%d", i), Timestamp: time.Now(), Project: "p", Kind: event.RecordKind, Title: "t"}
+		if err := s.Append(e); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	// Damage the log the way a hand edit or a foreign tool might.
+	if _, err := s.db.Exec(`
+	DELETE FROM events WHERE seq IN (3, 4);
+	UPDATE events SET data = '{not json' WHERE seq = 2;
+	DROP INDEX idx_events_id;
+	CREATE TABLE events_copy AS SELECT * FROM events;
+	DROP TABLE events;
+	CREATE TABLE events (seq INTEGER PRIMARY KEY AUTOINCREMENT, id TEXT NOT NULL, timestamp TEXT NOT NULL, type TEXT NOT NULL, project TEXT NOT NULL, data TEXT NOT NULL, time_utc TEXT NOT NULL DEFAULT '', target TEXT, link_to TEXT);
+	INSERT INTO events SELECT * FROM events_copy;
+	DROP TABLE events_copy;
+	INSERT INTO events (id, timestamp, time_utc, type, project, data) SELECT id, timestamp, time_utc, type, project, data FROM events WHERE seq = 5;`); err != nil {
+		t.Fatalf("damage: %v", err)
+	}
+
+	h, err := Inspect(dbPath)
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	if len(h.BadRows) != 1 || h.BadRows[0].Seq != 2 || h.BadRows[0].ID != "e2" {
+		t.Fatalf("expected seq 2 to fail to decode: %+v", h.BadRows)
+	}
+	if len(h.SeqGaps) != 1 || h.SeqGaps[0] != (SeqGap{First: 3, Last: 4}) {
+		t.Fatalf("expected a gap at 3-4: %+v", h.SeqGaps)
+	}
+	if len(h.DuplicateIDs) != 1 || h.DuplicateIDs[0].ID != "e5" || len(h.DuplicateIDs[0].Seqs) != 2 {
+		t.Fatalf("expected e5 twice: %+v", h.DuplicateIDs)
+	}
+}
@@ -0,0 +1,434 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/divijg19/sage/internal/event"
+)
+
+// Migration is one numbered schema change. Open applies every migration newer
+// than the database's schema_version in order, each in its own transaction
+// together with the schema_version row that records it.
+type Migration struct {
+	Version int
+	Name    string
+
+	apply func(tx *sql.Tx) error
+}
+
+// migrations is the schema history. Append to it; never renumber, edit or
+// remove a migration that has shipped.
+var migrations = []Migration{
+	{Version: 1, Name: "create the event log with numeric ids (copying a v1 log)", apply: createEventLog},
+	{Version: 2, Name: "add UTC nanosecond timestamps (time_utc)", apply: addUTCTime},
+	{Version: 3, Name: "index events by UTC time, id and project", apply: indexEvents},
+	{Version: 4, Name: "build entry projections and the search index", apply: createProjectionTables},
+	{Version: 5, Name: "index annotations and links by the entries they name", apply: addTargets},
+}
+
+// LatestVersion is the schema version this binary migrates databases to.
+func LatestVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// SchemaTooNewError reports a database migrated by a newer binary. Opening it
+// could misread or corrupt data, so the store refuses.
+type SchemaTooNewError struct {
+	Path      string
+	Version   int
+	Supported int
+}
+
+func (e *SchemaTooNewError) Error() string {
+	return fmt.Sprintf("%s is at schema version %d, but this sage only knows up to version %d; it was migrated by a newer sage, so upgrade sage to use it", e.Path, e.Version, e.Supported)
+}
+
+// MigrationResult is what Migrate did.
+type MigrationResult struct {
+	From    int
+	Applied []Migration
+	// Backup is the copy of the database taken before migrating, or "" when
+	// nothing was applied or there was nothing to back up.
+	Backup string
+}
+
+// Pending reports the schema version of the database at path and the
+// migrations Open would apply, without changing or creating anything.
+func Pending(path string) (int, []Migration, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return 0, migrations, nil
+	}
+	db, err := sql.Open("sqlite", dsn(path, true))
+	if err != nil {
+		return 0, nil, err
+	}
+	defer db.Close()
+
+	version, err := schemaVersion(db)
+	if err != nil {
+		return 0, nil, err
+	}
+	if version > LatestVersion() {
+		return version, nil, &SchemaTooNewError{Path: path, Version: version, Supported: LatestVersion()}
+	}
+	return version, pendingAfter(version), nil
+}
+
+// Migrate brings the database at path up to LatestVersion, backing it up
+// first when it already holds a schema.
+func Migrate(path string) (MigrationResult, error) {
+	db, err := sql.Open("sqlite", dsn(path, false))
+	if err != nil {
+		return MigrationResult{}, err
+	}
+	defer db.Close()
+	return migrate(db, path)
+}
+
+func migrate(db *sql.DB, path string) (MigrationResult, error) {
+	// Only write when needed: every command opens the store, often alongside
+	// a hook that is appending.
+	if ok, err := tableExists(db, "schema_version"); err != nil {
+		return MigrationResult{}, err
+	} else if !ok {
+		if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_version (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TEXT NOT NULL
+		);`); err != nil {
+			return MigrationResult{}, err
+		}
+	}
+
+	version, err := schemaVersion(db)
+	if err != nil {
+		return MigrationResult{}, err
+	}
+	result := MigrationResult{From: version}
+	if version > LatestVersion() {
+		return result, &SchemaTooNewError{Path: path, Version: version, Supported: LatestVersion()}
+	}
+
+	if err := recordBaseline(db, version); err != nil {
+		return result, err
+	}
+	pending := pendingAfter(version)
+	if len(pending) > 0 {
+		if result.Backup, err = backup(db, path, version); err != nil {
+			return result, fmt.Errorf("back up before migrating: %w", err)
+		}
+	}
+	for _, m := range pending {
+		var applied bool
+		err := retryBusy(func() (err error) {
+			applied, err = applyMigration(db, m)
+			return err
+		})
+		if err != nil {
+			return result, fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+		}
+		if applied {
+			result.Applied = append(result.Applied, m)
+		}
+	}
+
+	// Projections are derived data; rebuild any that went missing since.
+	if err := ensureProjections(db); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// applyMigration runs m unless another process got there first.
+func applyMigration(db *sql.DB, m Migration) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// Take the write lock before reading the version, so a concurrent open
+	// waits here and then finds the migration applied.
+	if _, err := tx.Exec(`DELETE FROM schema_version WHERE 0;`); err != nil {
+		return false, err
+	}
+	if version, err := schemaVersion(tx); err != nil || version >= m.Version {
+		return false, err
+	}
+
+	if err := m.apply(tx); err != nil {
+		return false, err
+	}
+	if err := recordVersion(tx, m.Version, m.Name); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func recordVersion(x execer, version int, name string) error {
+	_, err := x.Exec(`INSERT INTO schema_version (version, name, applied_at) VALUES (?, ?, ?);`,
+		version, name, time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+// recordBaseline records the inferred version of a database written before
+// schema_version existed, so later opens read it instead of probing.
+func recordBaseline(db *sql.DB, version int) error {
+	if version == 0 {
+		return nil
+	}
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM schema_version;`).Scan(&n); err != nil || n > 0 {
+		return err
+	}
+	return recordVersion(db, version, "baseline (inferred from an unversioned database)")
+}
+
+func pendingAfter(version int) []Migration {
+	var out []Migration
+	for _, m := range migrations {
+		if m.Version > version {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// schemaVersion reads the recorded version. Databases written before
+// schema_version existed are dated by the tables and columns they have.
+func schemaVersion(db queryer) (int, error) {
+	if ok, err := tableExists(db, "schema_version"); err != nil {
+		return 0, err
+	} else if ok {
+		var version sql.NullInt64
+		if err := db.QueryRow(`SELECT MAX(version) FROM schema_version;`).Scan(&version); err != nil {
+			return 0, err
+		}
+		if version.Valid {
+			return int(version.Int64), nil
+		}
+	}
+	return inferVersion(db)
+}
+
+func inferVersion(db queryer) (int, error) {
+	probes := []func() (bool, error){
+		func() (bool, error) { return hasColumn(db, "events", "seq") },
+		func() (bool, error) { return hasColumn(db, "events", "time_utc") },
+		func() (bool, error) { return indexCovers(db, "idx_events_time", "time_utc") },
+		func() (bool, error) { return tableExists(db, "entries") },
+	}
+	version := 0
+	for _, probe := range probes {
+		ok, err := probe()
+		if err != nil || !ok {
+			return version, err
+		}
+		version++
+	}
+	return version, nil
+}
+
+// backup copies the database next to itself as <path>.v<version>.bak before
+// it is migrated. A database with no event log yet has nothing to keep.
+func backup(db *sql.DB, path string, version int) (string, error) {
+	if path == "" || strings.HasPrefix(path, ":memory:") {
+		return "", nil
+	}
+	if ok, err := tableExists(db, "events"); err != nil || !ok {
+		return "", err
+	}
+	dest := fmt.Sprintf("%s.v%d.bak", path, version)
+	if err := os.Remove(dest); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	if _, err := db.Exec(`VACUUM INTO ?;`, dest); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// createEventLog creates the events table. v1 logs (no seq column) are
+// copied into it in timestamp order, so seq follows recording order.
+func createEventLog(tx *sql.Tx) error {
+	if ok, err := hasColumn(tx, "events", "seq"); err != nil || ok {
+		return err
+	}
+
+	if _, err := tx.Exec(`
+	CREATE TABLE events_v2 (
+		seq INTEGER PRIMARY KEY AUTOINCREMENT,
+		id TEXT NOT NULL UNIQUE,
+		timestamp TEXT NOT NULL,
+		type TEXT NOT NULL,
+		project TEXT NOT NULL,
+		data TEXT NOT NULL
+	);`); err != nil {
+		return err
+	}
+	if ok, err := tableExists(tx, "events"); err != nil {
+		return err
+	} else if ok {
+		if _, err := tx.Exec(`
+		INSERT INTO events_v2 (id, timestamp, type, project, data)
+		SELECT id, timestamp, type, project, data
+		FROM events
+		ORDER BY timestamp ASC, id ASC;`); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DROP TABLE events;`); err != nil {
+			return err
+		}
+	}
+	_, err := tx.Exec(`ALTER TABLE events_v2 RENAME TO events;`)
+	return err
+}
+
+// addUTCTime adds time_utc, the event time as sortableTime. The timestamp
+// column keeps the original offset for display, but its strings only compare
+// correctly within one offset and to the second, so every time query uses
+// time_utc instead.
+func addUTCTime(tx *sql.Tx) error {
+	if _, err := tx.Exec(`ALTER TABLE events ADD COLUMN time_utc TEXT NOT NULL DEFAULT '';`); err != nil {
+		return err
+	}
+	return backfillUTCTime(tx)
+}
+
+// backfillUTCTime fills time_utc from each event's JSON timestamp, which
+// keeps the full precision the timestamp column may have dropped.
+func backfillUTCTime(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT seq, data FROM events WHERE time_utc = '';`)
+	if err != nil {
+		return err
+	}
+	times := make(map[int64]string)
+	for rows.Next() {
+		var seq int64
+		var raw string
+		if err := rows.Scan(&seq, &raw); err != nil {
+			rows.Close()
+			return err
+		}
+		var e event.Event
+		if err := json.Unmarshal([]byte(raw), &e); err != nil {
+			rows.Close()
+			return fmt.Errorf("event %d: %w", seq, err)
+		}
+		times[seq] = sortableTime(e.Timestamp)
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for seq, t := range times {
+		if _, err := tx.Exec(`UPDATE events SET time_utc = ? WHERE seq = ?;`, t, seq); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func indexEvents(tx *sql.Tx) error {
+	// Older logs indexed the offset-dependent timestamp column.
+	if _, err := tx.Exec(`DROP INDEX IF EXISTS idx_events_time;`); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`
+	CREATE INDEX idx_events_time ON events(time_utc);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_events_id ON events(id);
+	CREATE INDEX IF NOT EXISTS idx_events_project ON events(project);
+	CREATE INDEX IF NOT EXISTS idx_events_project_seq ON events(project, seq);`)
+	return err
+}
+
+// addTargets copies the entry IDs that annotations and links name out of the
+// JSON into indexed columns. Folding an entry looks up its annotations on
+// every read and append, which would otherwise scan the whole log. Rows whose
+// JSON does not parse are left NULL, so a damaged log still migrates.
+func addTargets(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+	ALTER TABLE events ADD COLUMN target TEXT;
+	ALTER TABLE events ADD COLUMN link_to TEXT;
+	UPDATE events SET
+		target = NULLIF(json_extract(data, '$.metadata.target'), ''),
+		link_to = NULLIF(json_extract(data, '$.metadata.to'), '')
+	WHERE json_valid(data);
+	CREATE INDEX idx_events_target ON events(target);
+	CREATE INDEX idx_events_link_to ON events(link_to);`)
+	return err
+}
+
+func createProjectionTables(tx *sql.Tx) error {
+	if err := dropProjections(tx); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(createProjections); err != nil {
+		return err
+	}
+	return rebuildProjections(tx)
+}
+
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+func tableExists(db queryer, table string) (bool, error) {
+	row := db.QueryRow(`SELECT 1 FROM sqlite_master WHERE type='table' AND name = ? LIMIT 1;`, table)
+	var one int
+	if err := row.Scan(&one); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func hasColumn(db queryer, table string, column string) (bool, error) {
+	q := fmt.Sprintf("PRAGMA table_info(%s);", table)
+	rows, err := db.Query(q)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid int
+		var name string
+		var typ string
+		var notnull int
+		var dflt sql.NullString
+		var pk int
+		if err := rows.Scan(&cid, &name, &typ, &notnull, &dflt, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
+func indexCovers(db queryer, index string, column string) (bool, error) {
+	var def sql.NullString
+	err := db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'index' AND name = ?;`, index).Scan(&def)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return strings.Contains(def.String, "("+column+")"), nil
+}
@@ -0,0 +1,244 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/divijg19/sage/internal/event"
+)
+
+func TestMigrate_FreshDatabaseIsCurrentWithoutBackup(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "sage.db")
+
+	version, pending, err := Pending(dbPath)
+	if err != nil || version != 0 || len(pending) != len(migrations) {
+		t.Fatalf("Pending on a missing file: %d, %d pending, %v", version, len(pending), err)
+	}
+	if _, err := os.Stat(dbPath); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Pending should not create the database")
+	}
+
+	if _, err := Open(dbPath); err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	version, pending, err = Pending(dbPath)
+	if err != nil || version != LatestVersion() || len(pending) != 0 {
+		t.Fatalf("after Open: version %d, %d pending, %v", version, len(pending), err)
+	}
+	if matches, _ := filepath.Glob(dbPath + ".v*.bak"); len(matches) != 0 {
+		t.Fatalf("a new database needs no backup, got %v", matches)
+	}
+}
+
+// writeUnversionedV2 creates a database as written before schema_version and
+// time_utc existed, holding one event.
+func writeUnversionedV2(t *testing.T, dbPath string) {
+	t.Helper()
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`
+	CREATE TABLE events (
+		seq INTEGER PRIMARY KEY AUTOINCREMENT,
+		id TEXT NOT NULL UNIQUE,
+		timestamp TEXT NOT NULL,
+		type TEXT NOT NULL,
+		project TEXT NOT NULL,
+		data TEXT NOT NULL
+	);
+	CREATE INDEX idx_events_time ON events(timestamp);`); err != nil {
+		t.Fatalf("create v2: %v", err)
+	}
+	e := event.Event{ID: "a", Timestamp: time.Date(2026, 1, 9, 10, 0, 0, 0, time.UTC), Project: "p", Kind: event.RecordKind, Title: "t"}
+	if _, err := db.Exec(`INSERT INTO events (id, timestamp, type, project, data) VALUES (?, ?, ?, ?, ?);`,
+		e.ID, e.Timestamp.Format(time.RFC3339), e.Kind, e.Project, mustJSON(t, e)); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+}
+
+func TestMigrate_UnversionedDatabaseBacksUpAndRecordsSteps(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "sage.db")
+	writeUnversionedV2(t, dbPath)
+
+	// A dry run infers the version and changes nothing.
+	version, pending, err := Pending(dbPath)
+	if err != nil {
+		t.Fatalf("Pending: %v", err)
+	}
+	if version != 1 || len(pending) != 4 || pending[0].Version != 2 {
+		t.Fatalf("expected version 1 with migrations 2-5 pending, got %d %+v", version, pending)
+	}
+	if version, _, _ := Pending(dbPath); version != 1 {
+		t.Fatalf("Pending should not migrate, version now %d", version)
+	}
+
+	result, err := Migrate(dbPath)
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if result.From != 1 || len(result.Applied) != 4 || result.Backup != dbPath+".v1.bak" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	// The backup is the database as it was.
+	bak, err := sql.Open("sqlite", result.Backup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bak.Close()
+	if ok, err := hasColumn(bak, "events", "time_utc"); err != nil || ok {
+		t.Fatalf("backup should predate the migration (time_utc present: %v, %v)", ok, err)
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var versions []int
+	rows, err := db.Query(`SELECT version FROM schema_version ORDER BY version;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			t.Fatal(err)
+		}
+		versions = append(versions, v)
+	}
+	rows.Close()
+	if len(versions) != 5 || versions[0] != 1 || versions[4] != 5 {
+		t.Fatalf("expected the baseline and each step recorded, got %v", versions)
+	}
+
+	// Nothing left to do, and opening reports as much.
+	if result, err := Migrate(dbPath); err != nil || len(result.Applied) != 0 || result.Backup != "" {
+		t.Fatalf("second Migrate: %+v, %v", result, err)
+	}
+	s, result, err := OpenWithResult(dbPath)
+	if err != nil || result.From != LatestVersion() || len(result.Applied) != 0 {
+		t.Fatalf("OpenWithResult: %+v, %v", result, err)
+	}
+	s.db.Close()
+}
+
+func TestMigrate_RefusesDatabaseFromNewerBinary(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "sage.db")
+	s, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if err := recordVersion(s.db, LatestVersion()+1, "from the future"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Open(dbPath)
+	var tooNew *SchemaTooNewError
+	if !errors.As(err, &tooNew) || tooNew.Version != LatestVersion()+1 || !strings.Contains(err.Error(), "newer sage") {
+		t.Fatalf("expected SchemaTooNewError, got %v", err)
+	}
+	if _, _, err := Pending(dbPath); !errors.As(err, &tooNew) {
+		t.Fatalf("Pending should report the newer schema too, got %v", err)
+	}
+}
+
+func TestMigrate_FailedStepRollsBack(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "sage.db")
+	if _, err := Open(dbPath); err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	saved := migrations
+	t.Cleanup(func() { migrations = saved })
+	migrations = append(append([]Migration(nil), saved...), Migration{
+		Version: LatestVersion() + 1,
+		Name:    "half done",
+		apply: func(tx *sql.Tx) error {
+			if _, err := tx.Exec(`CREATE TABLE half_done (x INTEGER);`); err != nil {
+				return err
+			}
+			return errors.New("boom")
+		},
+	})
+
+	if _, err := Open(dbPath); err == nil || !strings.Contains(err.Error(), "half done") {
+		t.Fatalf("expected the failing migration to be reported, got %v", err)
+	}
+	migrations = saved
+	version, _, err := Pending(dbPath)
+	if err != nil || version != LatestVersion() {
+		t.Fatalf("version should be unchanged, got %d (%v)", version, err)
+	}
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if ok, err := tableExists(db, "half_done"); err != nil || ok {
+		t.Fatalf("a failed migration should leave nothing behind")
+	}
+}
+
+func TestMigrate_TargetsAreIndexed(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "sage.db")
+	s, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	base := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	for _, e := range []event.Event{
+		{ID: "a", Timestamp: base, Project: "p", Kind: event.RecordKind, Title: "a"},
+		{ID: "t", Timestamp: base.Add(time.Minute), Project: "p", Kind: event.TagKind, Tags: []string{"x"},
+			Metadata: map[string]string{event.MetaTarget: "a", event.MetaOp: event.TagOpAdd}},
+	} {
+		if err := s.Append(e); err != nil {
+			t.Fatalf("Append %s: %v", e.ID, err)
+		}
+	}
+
+	// Take the log back to version 4, with a row whose JSON does not parse.
+	if _, err := s.db.Exec(`
+	DROP INDEX idx_events_target;
+	DROP INDEX idx_events_link_to;
+	ALTER TABLE events DROP COLUMN target;
+	ALTER TABLE events DROP COLUMN link_to;
+	DELETE FROM schema_version WHERE version = 5;
+	INSERT INTO events (id, timestamp, time_utc, type, project, data) VALUES ('bad', '', '', 'record', 'p', '{not json');`); err != nil {
+		t.Fatalf("downgrade: %v", err)
+	}
+
+	s, err = Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open after downgrade: %v", err)
+	}
+	anns, err := s.Annotations("a")
+	if err != nil || len(anns) != 1 || anns[0].ID != "t" {
+		t.Fatalf("expected the backfilled tag to be found, got %+v (%v)", anns, err)
+	}
+
+	var plan strings.Builder
+	rows, err := s.db.Query(`EXPLAIN QUERY PLAN SELECT seq, data FROM events WHERE target = ? ORDER BY seq ASC`, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, parent, notused int
+		var detail string
+		if err := rows.Scan(&id, &parent, &notused, &detail); err != nil {
+			t.Fatal(err)
+		}
+		plan.WriteString(detail + "\n")
+	}
+	if !strings.Contains(plan.String(), "idx_events_target") {
+		t.Fatalf("expected annotation lookups to use the index, plan:\n%s", plan.String())
+	}
+}
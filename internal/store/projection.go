@@ -0,0 +1,188 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/divijg19/sage/internal/event"
+)
+
+// The projection tables hold every entry as it currently reads, so filters
+// and search run in SQL instead of replaying the log:
+//
+//	entries     one row per entry (seq = the entry's own seq) with the folded
+//	            event, its kind, project, sortable time and retraction flag
+//	entry_tags  one row per (entry, tag)
+//	events_fts  FTS5 index over title, content, tags and project (rowid = seq)
+//
+// They are derived data: Append keeps them in step and ensureProjections
+// rebuilds them from the log when any is missing.
+const createProjections = `
+CREATE TABLE IF NOT EXISTS entries (
+	seq INTEGER PRIMARY KEY,
+	id TEXT NOT NULL UNIQUE,
+	time TEXT NOT NULL,
+	kind TEXT NOT NULL,
+	project TEXT NOT NULL,
+	retracted INTEGER NOT NULL DEFAULT 0,
+	data TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_entries_time ON entries(time);
+CREATE INDEX IF NOT EXISTS idx_entries_project_seq ON entries(project, seq);
+CREATE TABLE IF NOT EXISTS entry_tags (
+	seq INTEGER NOT NULL,
+	tag TEXT NOT NULL,
+	PRIMARY KEY (seq, tag)
+);
+CREATE INDEX IF NOT EXISTS idx_entry_tags_tag ON entry_tags(tag);
+CREATE VIRTUAL TABLE IF NOT EXISTS events_fts USING fts5(
+	title,
+	content,
+	tags,
+	project,
+	tokenize = 'unicode61 remove_diacritics 2'
+);
+`
+
+var projectionTables = []string{"entries", "entry_tags", "events_fts"}
+
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// sortableTime formats t in UTC with fixed-width nanoseconds, so string
+// comparison orders instants correctly regardless of the original offset.
+func sortableTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000000000Z")
+}
+
+// ensureProjections creates the projection tables and backfills them from
+// the log the first time a database is opened by a binary that knows about
+// them (or after one was dropped).
+func ensureProjections(db *sql.DB) error {
+	complete := true
+	for _, table := range projectionTables {
+		ok, err := tableExists(db, table)
+		if err != nil {
+			return err
+		}
+		complete = complete && ok
+	}
+	if complete {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := createProjectionTables(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func dropProjections(tx *sql.Tx) error {
+	for _, table := range projectionTables {
+		if _, err := tx.Exec(`DROP TABLE IF EXISTS ` + table + `;`); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rebuildProjections replaces every projection row with the folded state of the log.
+func rebuildProjections(tx *sql.Tx) error {
+	for _, table := range projectionTables {
+		if _, err := tx.Exec(`DELETE FROM ` + table + `;`); err != nil {
+			return err
+		}
+	}
+
+	rows, err := tx.Query(`SELECT seq, data FROM events ORDER BY seq ASC;`)
+	if err != nil {
+		return err
+	}
+	var events []event.Event
+	for rows.Next() {
+		var seq int64
+		var raw string
+		if err := rows.Scan(&seq, &raw); err != nil {
+			rows.Close()
+			return err
+		}
+		var e event.Event
+		if err := json.Unmarshal([]byte(raw), &e); err != nil {
+			rows.Close()
+			return err
+		}
+		e.Seq = seq
+		events = append(events, e)
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, e := range event.FoldWith(events, event.FoldOptions{IncludeRetracted: true}) {
+		if err := projectEntry(tx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// projectEntry writes (or rewrites) the projection rows for a folded entry.
+func projectEntry(x execer, e event.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	kind := e.Kind
+	if kind == "" {
+		kind = event.RecordKind
+	}
+	retracted := 0
+	if e.Retracted {
+		retracted = 1
+	}
+
+	if _, err := x.Exec(
+		`INSERT OR REPLACE INTO entries (seq, id, time, kind, project, retracted, data) VALUES (?, ?, ?, ?, ?, ?, ?);`,
+		e.Seq, e.ID, sortableTime(e.Timestamp), kind, e.Project, retracted, string(data),
+	); err != nil {
+		return err
+	}
+
+	if _, err := x.Exec(`DELETE FROM entry_tags WHERE seq = ?;`, e.Seq); err != nil {
+		return err
+	}
+	for _, tag := range e.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+		if _, err := x.Exec(`INSERT OR IGNORE INTO entry_tags (seq, tag) VALUES (?, ?);`, e.Seq, tag); err != nil {
+			return err
+		}
+	}
+
+	if _, err := x.Exec(`DELETE FROM events_fts WHERE rowid = ?;`, e.Seq); err != nil {
+		return err
+	}
+	_, err = x.Exec(
+		`INSERT INTO events_fts (rowid, title, content, tags, project) VALUES (?, ?, ?, ?, ?);`,
+		e.Seq,
+		e.Title,
+		e.Content,
+		strings.Join(e.Tags, " "),
+		e.Project,
+	)
+	return err
+}
@@ -0,0 +1,376 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"iter"
+	"strings"
+	"time"
+
+	"github.com/divijg19/sage/internal/event"
+	"github.com/divijg19/sage/internal/query"
+)
+
+// Order is the seq order of query results.
+type Order int
+
+const (
+	Ascending Order = iota
+	Descending
+)
+
+// Filter selects entries. Zero values mean "no constraint"; all set fields
+// must hold.
+type Filter struct {
+	// Projects keeps entries in any of these projects.
+	Projects []string
+	// Kinds keeps entries of any of these kinds.
+	Kinds []event.EntryKind
+	// AnyTags keeps entries with at least one of these tags; AllTags keeps
+	// entries with every one of them.
+	AnyTags []string
+	AllTags []string
+	// Paths keeps commits that changed any of these files, or a file under
+	// any of these directories (see query.FieldPath).
+	Paths []string
+	// After (inclusive) and Before (exclusive) bound when entries were recorded.
+	After  time.Time
+	Before time.Time
+	// MinSeq and MaxSeq bound entry IDs, inclusively.
+	MinSeq int64
+	MaxSeq int64
+	// Match is a parsed query (see internal/query), ANDed with the fields above.
+	Match query.Query
+
+	// AsOf replays the log as it stood at that instant: entries recorded
+	// later are left out, and only annotations (tags, revisions, retractions)
+	// recorded by then apply. Every constraint is evaluated against the
+	// replayed entry. Zero means the current state.
+	AsOf time.Time
+
+	Order  Order
+	Limit  int
+	Offset int
+}
+
+// query folds the structured fields into one query, so both evaluation paths
+// (SQL and replay) share a single definition of each constraint.
+func (f Filter) query() query.Query {
+	q := f.Match
+	if len(f.Projects) > 0 {
+		q = q.With(query.Term{Field: query.FieldProject, Values: lowered(f.Projects)})
+	}
+	if len(f.Kinds) > 0 {
+		kinds := make([]string, 0, len(f.Kinds))
+		for _, k := range f.Kinds {
+			kinds = append(kinds, string(k))
+		}
+		q = q.With(query.Term{Field: query.FieldKind, Values: kinds})
+	}
+	if len(f.AnyTags) > 0 {
+		q = q.With(query.Term{Field: query.FieldTag, Values: lowered(f.AnyTags)})
+	}
+	for _, tag := range lowered(f.AllTags) {
+		q = q.With(query.Term{Field: query.FieldTag, Values: []string{tag}})
+	}
+	if paths := cleanPaths(f.Paths); len(paths) > 0 {
+		q = q.With(query.Term{Field: query.FieldPath, Values: paths})
+	}
+	if !f.After.IsZero() {
+		q = q.With(query.Term{Field: query.FieldAfter, Time: f.After})
+	}
+	if !f.Before.IsZero() {
+		q = q.With(query.Term{Field: query.FieldBefore, Time: f.Before})
+	}
+	return q
+}
+
+func lowered(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func cleanPaths(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = query.CleanPath(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Query streams the entries selected by f. Without AsOf the filter compiles
+// to SQL over the current-state projection; with AsOf entries are replayed
+// one at a time and filtered as they read at that instant. Retracted entries
+// are left out unless the store includes them. Iteration stops at the first
+// error, which is yielded with a zero event.
+func (s *Store) Query(ctx context.Context, f Filter) iter.Seq2[event.Event, error] {
+	if !f.AsOf.IsZero() {
+		return s.queryAsOf(ctx, f)
+	}
+
+	return func(yield func(event.Event, error) bool) {
+		where, args := s.where(f.query())
+		clauses, seqArgs := seqRange(f)
+		where = strings.Join(append([]string{where}, clauses...), " AND ")
+		args = append(args, seqArgs...)
+
+		sqlQuery := `
+		SELECT en.seq, en.retracted, en.data
+		FROM entries en
+		WHERE ` + where + `
+		ORDER BY en.seq ` + f.Order.sql() + `
+		LIMIT ? OFFSET ?`
+		args = append(args, f.limit(), f.Offset)
+
+		rows, err := s.db.QueryContext(ctx, sqlQuery, args...)
+		if err != nil {
+			yield(event.Event{}, err)
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			e, err := scanEntry(rows)
+			if err != nil {
+				yield(event.Event{}, err)
+				return
+			}
+			if !yield(e, nil) {
+				return
+			}
+		}
+		if err := rows.Err(); err != nil {
+			yield(event.Event{}, err)
+		}
+	}
+}
+
+func (s *Store) queryAsOf(ctx context.Context, f Filter) iter.Seq2[event.Event, error] {
+	return func(yield func(event.Event, error) bool) {
+		annotations, err := s.annotationsAsOf(ctx, f.AsOf)
+		if err != nil {
+			yield(event.Event{}, err)
+			return
+		}
+
+		// Projects, recording time and seq never change after an entry is
+		// written, so those narrow the scan in SQL. Everything else depends on
+		// the replayed state and is checked per entry.
+		q := f.query()
+		clauses, args := compileTerms(q.Only(query.FieldProject))
+		clauses = append(clauses, "en.time <= ?")
+		args = append(args, sortableTime(f.AsOf))
+		seqClauses, seqArgs := seqRange(f)
+		clauses = append(clauses, seqClauses...)
+		args = append(args, seqArgs...)
+
+		rows, err := s.db.QueryContext(ctx, `
+		SELECT e.seq, e.data
+		FROM entries en
+		JOIN events e ON e.seq = en.seq
+		WHERE `+strings.Join(clauses, " AND ")+`
+		ORDER BY en.seq `+f.Order.sql(), args...)
+		if err != nil {
+			yield(event.Event{}, err)
+			return
+		}
+		defer rows.Close()
+
+		skipped, emitted := 0, 0
+		for rows.Next() {
+			var seq int64
+			var raw string
+			if err := rows.Scan(&seq, &raw); err != nil {
+				yield(event.Event{}, err)
+				return
+			}
+			var e event.Event
+			if err := json.Unmarshal([]byte(raw), &e); err != nil {
+				yield(event.Event{}, err)
+				return
+			}
+			e.Seq = seq
+
+			replayed := event.FoldWith(append([]event.Event{e}, annotations[e.ID]...), event.FoldOptions{IncludeRetracted: true})[0]
+			if replayed.Retracted && !s.includeRetracted {
+				continue
+			}
+			if !q.Match(replayed) {
+				continue
+			}
+			if skipped < f.Offset {
+				skipped++
+				continue
+			}
+			if !yield(replayed, nil) {
+				return
+			}
+			if emitted++; f.Limit > 0 && emitted >= f.Limit {
+				return
+			}
+		}
+		if err := rows.Err(); err != nil {
+			yield(event.Event{}, err)
+		}
+	}
+}
+
+// annotationsAsOf groups the tag, revision and retract events recorded at or
+// before t by target ID, in seq order.
+func (s *Store) annotationsAsOf(ctx context.Context, t time.Time) (map[string][]event.Event, error) {
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT seq, data
+	FROM events
+	WHERE type IN (?, ?, ?) AND time_utc <= ?
+	ORDER BY seq ASC
+	`, event.TagKind, event.RevisionKind, event.RetractKind, sortableTime(t))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string][]event.Event)
+	for rows.Next() {
+		var seq int64
+		var raw string
+		if err := rows.Scan(&seq, &raw); err != nil {
+			return nil, err
+		}
+		var a event.Event
+		if err := json.Unmarshal([]byte(raw), &a); err != nil {
+			return nil, err
+		}
+		a.Seq = seq
+		out[a.Target()] = append(out[a.Target()], a)
+	}
+	return out, rows.Err()
+}
+
+// Collect drains a query into a slice.
+func Collect(results iter.Seq2[event.Event, error]) ([]event.Event, error) {
+	var events []event.Event
+	for e, err := range results {
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+func (o Order) sql() string {
+	if o == Descending {
+		return "DESC"
+	}
+	return "ASC"
+}
+
+// limit returns the SQL LIMIT value; SQLite treats a negative limit as none.
+func (f Filter) limit() int {
+	if f.Limit > 0 {
+		return f.Limit
+	}
+	return -1
+}
+
+func seqRange(f Filter) ([]string, []any) {
+	var clauses []string
+	var args []any
+	if f.MinSeq > 0 {
+		clauses = append(clauses, "en.seq >= ?")
+		args = append(args, f.MinSeq)
+	}
+	if f.MaxSeq > 0 {
+		clauses = append(clauses, "en.seq <= ?")
+		args = append(args, f.MaxSeq)
+	}
+	return clauses, args
+}
+
+// where translates q into a WHERE clause over the entries projection
+// (aliased en), honouring the store's retraction view. The clause is never
+// empty, so callers can always AND onto it.
+func (s *Store) where(q query.Query) (string, []any) {
+	clauses, args := compileTerms(q)
+	if !s.includeRetracted {
+		clauses = append(clauses, "en.retracted = 0")
+	}
+	if len(clauses) == 0 {
+		return "1 = 1", args
+	}
+	return strings.Join(clauses, " AND "), args
+}
+
+// compileTerms translates each query term into a SQL condition on en.
+func compileTerms(q query.Query) ([]string, []any) {
+	var clauses []string
+	var args []any
+	for _, t := range q.Terms {
+		var clause string
+		switch t.Field {
+		case query.FieldKind:
+			clause = "en.kind IN (" + placeholders(len(t.Values)) + ")"
+			args = appendStrings(args, t.Values)
+		case query.FieldTag:
+			clause = "EXISTS (SELECT 1 FROM entry_tags et WHERE et.seq = en.seq AND et.tag IN (" + placeholders(len(t.Values)) + "))"
+			args = appendStrings(args, t.Values)
+		case query.FieldProject:
+			clause = "LOWER(en.project) IN (" + placeholders(len(t.Values)) + ")"
+			args = appendStrings(args, t.Values)
+		case query.FieldPath:
+			clause = pathClause(len(t.Values))
+			for _, v := range t.Values {
+				args = append(args, v, v)
+			}
+		case query.FieldAfter:
+			clause = "en.time >= ?"
+			args = append(args, sortableTime(t.Time))
+		case query.FieldBefore:
+			clause = "en.time < ?"
+			args = append(args, sortableTime(t.Time))
+		case query.FieldText:
+			clause = "en.seq IN (SELECT rowid FROM events_fts WHERE events_fts MATCH ?)"
+			args = append(args, ftsExpression([]query.Term{t}))
+		default:
+			continue
+		}
+		if t.Negated {
+			clause = "NOT (" + clause + ")"
+		}
+		clauses = append(clauses, clause)
+	}
+	return clauses, args
+}
+
+// pathClause matches entries whose files metadata (one path per line) holds
+// any of n paths, or a file under one of them, as query.PathMatches does.
+// Each path takes two arguments. Entries without files never match, so a
+// negated clause keeps them.
+func pathClause(n int) string {
+	const files = "(char(10) || COALESCE(json_extract(en.data, '$.metadata.files'), '') || char(10))"
+	alts := make([]string, 0, 2*n)
+	for range n {
+		alts = append(alts,
+			"instr("+files+", char(10) || ? || char(10)) > 0",
+			"instr("+files+", char(10) || ? || '/') > 0")
+	}
+	return "(" + strings.Join(alts, " OR ") + ")"
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func appendStrings(args []any, values []string) []any {
+	for _, v := range values {
+		args = append(args, v)
+	}
+	return args
+}
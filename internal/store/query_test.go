@@ -0,0 +1,170 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/divijg19/sage/internal/event"
+)
+
+// TestStore_Find_AgreesWithMatch checks that the SQL compilation of a query
+// selects exactly the entries that query.Match accepts after replay.
+func TestStore_Find_AgreesWithMatch(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "sage.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	base := time.Date(2026, 1, 9, 10, 0, 0, 0, time.UTC)
+	ist := time.FixedZone("IST", 5*3600+1800)
+	for _, e := range []event.Event{
+		{ID: "a", Timestamp: base, Project: "api", Kind: event.DecisionKind, Title: "Use JWT", Content: "Stateless auth", Tags: []string{"auth"}},
+		{ID: "b", Timestamp: base.Add(24 * time.Hour), Project: "api", Kind: event.RecordKind, Title: "Token expiry bug", Content: "Exact phrase inside", Tags: []string{"auth", "wip"}},
+		{ID: "c", Timestamp: base.Add(48 * time.Hour).In(ist), Project: "web", Kind: event.CommitKind, Title: "Fix login", Tags: []string{"git", "two words"},
+			Metadata: map[string]string{event.MetaFiles: "internal/store/store.go\ninternal/store/query.go\nREADME.md"}},
+		{ID: "d", Timestamp: base.Add(72 * time.Hour), Project: "web", Title: "Untyped note"},
+		{ID: "t", Timestamp: base.Add(96 * time.Hour), Project: "web", Kind: event.TagKind, Tags: []string{"auth"},
+			Metadata: map[string]string{event.MetaTarget: "d", event.MetaOp: event.TagOpAdd}},
+		{ID: "r", Timestamp: base.Add(96 * time.Hour), Project: "api", Kind: event.RevisionKind, Title: "Use PASETO", Content: "Stateless auth",
+			Metadata: map[string]string{event.MetaTarget: "a", event.MetaKind: string(event.DecisionKind)}},
+	} {
+		if err := s.Append(e); err != nil {
+			t.Fatalf("Append %s: %v", e.ID, err)
+		}
+	}
+
+	all, err := Collect(s.Query(context.Background(), Filter{}))
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+
+	cases := map[string][]string{
+		"":                                 {"a", "b", "c", "d"},
+		"kind:decision":                    {"a"},
+		"kind:record":                      {"b", "d"},
+		"-kind:record":                     {"a", "c"},
+		"tag:auth":                         {"a", "b", "d"},
+		"#auth -tag:wip":                   {"a", "d"},
+		"tag:wip,git":                      {"b", "c"},
+		`tag:"two words"`:                  {"c"},
+		"project:api":                      {"a", "b"},
+		"project:API,web kind:commit":      {"c"},
+		"after:2026-01-10T10:00:00Z":       {"b", "c", "d"},
+		"before:2026-01-11T10:00:00Z":      {"a", "b"},
+		"after:2026-01-11T10:00:00Z":       {"c", "d"},
+		"paseto":                           {"a"},
+		"jwt":                              nil,
+		"tok":                              {"b"},
+		`"exact phrase"`:                   {"b"},
+		`"exact inside"`:                   nil,
+		"auth -stateless":                  {"b", "d"},
+		`kind:decision,record "stateless"`: {"a"},
+		"path:internal/store":              {"c"},
+		"path:internal/store/query.go":     {"c"},
+		"path:internal/st":                 nil,
+		"path:Internal":                    nil,
+		"path:docs,./README.md/":           {"c"},
+		"-path:internal":                   {"a", "b", "d"},
+	}
+	for raw, want := range cases {
+		q := mustQuery(t, raw)
+		found, err := Collect(s.Query(context.Background(), Filter{Match: q}))
+		if err != nil {
+			t.Fatalf("Query(%q): %v", raw, err)
+		}
+		var matched []string
+		for _, e := range all {
+			if q.Match(e) {
+				matched = append(matched, e.ID)
+			}
+		}
+		if got := ids(found); !equalIDs(got, want) || !equalIDs(matched, want) {
+			t.Fatalf("%q: Find=%v Match=%v want %v", raw, got, matched, want)
+		}
+	}
+}
+
+func ids(events []event.Event) []string {
+	var out []string
+	for _, e := range events {
+		out = append(out, e.ID)
+	}
+	return out
+}
+
+func equalIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestStore_Query_FilterFieldsAndPaging(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "sage.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	base := time.Date(2026, 1, 9, 10, 0, 0, 0, time.UTC)
+	for i, tags := range [][]string{{"auth"}, {"auth", "api"}, {"api"}, nil, {"auth", "api"}} {
+		e := event.Event{ID: string(rune('a' + i)), Timestamp: base.Add(time.Duration(i) * time.Hour), Project: "p", Kind: event.RecordKind, Title: "t", Tags: tags}
+		if i%2 == 1 {
+			e.Kind = event.DecisionKind
+		}
+		if err := s.Append(e); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	// Tag "d" later, so it only has the tag from then on.
+	if err := s.Append(event.Event{ID: "t", Timestamp: base.Add(10 * time.Hour), Project: "p", Kind: event.TagKind, Tags: []string{"auth"},
+		Metadata: map[string]string{event.MetaTarget: "d", event.MetaOp: event.TagOpAdd}}); err != nil {
+		t.Fatalf("Append tag: %v", err)
+	}
+
+	ctx := context.Background()
+	cases := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"any tags", Filter{AnyTags: []string{"AUTH", "missing"}}, []string{"a", "b", "d", "e"}},
+		{"all tags", Filter{AllTags: []string{"auth", "api"}}, []string{"b", "e"}},
+		{"kinds", Filter{Kinds: []event.EntryKind{event.DecisionKind}}, []string{"b", "d"}},
+		{"time range", Filter{After: base.Add(time.Hour), Before: base.Add(3 * time.Hour)}, []string{"b", "c"}},
+		{"seq range", Filter{MinSeq: 2, MaxSeq: 3}, []string{"b", "c"}},
+		{"descending page", Filter{Order: Descending, Offset: 1, Limit: 2}, []string{"d", "c"}},
+		{"as of, replayed tags", Filter{AsOf: base.Add(5 * time.Hour), AnyTags: []string{"auth"}}, []string{"a", "b", "e"}},
+		{"as of, paged", Filter{AsOf: base.Add(5 * time.Hour), Order: Descending, Offset: 1, Limit: 2}, []string{"d", "c"}},
+		{"as of, cut off", Filter{AsOf: base.Add(90 * time.Minute)}, []string{"a", "b"}},
+	}
+	for _, tc := range cases {
+		got, err := Collect(s.Query(ctx, tc.filter))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if !equalIDs(ids(got), tc.want) {
+			t.Fatalf("%s: got %v, want %v", tc.name, ids(got), tc.want)
+		}
+	}
+
+	// Breaking out of the loop stops the scan without error.
+	n := 0
+	for _, err := range s.Query(ctx, Filter{}) {
+		if err != nil {
+			t.Fatalf("Query: %v", err)
+		}
+		if n++; n == 2 {
+			break
+		}
+	}
+	if n != 2 {
+		t.Fatalf("expected to stop after 2 entries, got %d", n)
+	}
+}
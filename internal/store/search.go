@@ -0,0 +1,123 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+
+	"github.com/divijg19/sage/internal/event"
+	"github.com/divijg19/sage/internal/query"
+)
+
+// bm25 column weights: title, content, tags, project.
+const searchRank = `bm25(events_fts, 10.0, 1.0, 5.0, 2.0)`
+
+// SearchHit is one ranked full-text match.
+type SearchHit struct {
+	Entry event.Event
+	// Snippet is a short excerpt around the match, with matched terms wrapped
+	// in the requested highlight markers.
+	Snippet string
+	// Score orders hits; higher is more relevant.
+	Score float64
+}
+
+// SearchOptions shapes search results.
+type SearchOptions struct {
+	// Limit caps the number of hits; zero means no limit.
+	Limit int
+	// HighlightStart and HighlightEnd wrap matched terms in Snippet.
+	HighlightStart string
+	HighlightEnd   string
+}
+
+// Search runs q against the current state of the log and returns hits
+// best-first. Free-text terms are matched through the full-text index over
+// titles, content, tags and projects and drive the ranking; every other term
+// filters as in Find. A query with no free text returns its matches newest
+// first, without snippets.
+func (s *Store) Search(q query.Query, opts SearchOptions) ([]SearchHit, error) {
+	var text []query.Term
+	var rest query.Query
+	for _, t := range q.Terms {
+		if t.Field == query.FieldText && !t.Negated {
+			text = append(text, t)
+		} else {
+			rest.Terms = append(rest.Terms, t)
+		}
+	}
+
+	where, args := s.where(rest)
+	var sqlQuery string
+	if len(text) == 0 {
+		sqlQuery = `
+		SELECT en.seq, en.retracted, en.data, '', 0
+		FROM entries en
+		WHERE ` + where + `
+		ORDER BY en.seq DESC`
+	} else {
+		sqlQuery = `
+		SELECT en.seq, en.retracted, en.data, snippet(events_fts, -1, ?, ?, '…', 12), ` + searchRank + `
+		FROM events_fts
+		JOIN entries en ON en.seq = events_fts.rowid
+		WHERE events_fts MATCH ? AND ` + where + `
+		ORDER BY ` + searchRank + `, en.seq DESC`
+		args = append([]any{opts.HighlightStart, opts.HighlightEnd, ftsExpression(text)}, args...)
+	}
+	if opts.Limit > 0 {
+		sqlQuery += ` LIMIT ?`
+		args = append(args, opts.Limit)
+	}
+
+	rows, err := s.db.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []SearchHit
+	for rows.Next() {
+		var hit SearchHit
+		var rank float64
+		e, err := scanEntry(rows, &hit.Snippet, &rank)
+		if err != nil {
+			return nil, err
+		}
+		hit.Entry = e
+		hit.Score = -rank
+		hits = append(hits, hit)
+	}
+	return hits, rows.Err()
+}
+
+// scanEntry reads an entries row selected as (seq, retracted, data, extra...).
+func scanEntry(rows *sql.Rows, extra ...any) (event.Event, error) {
+	var seq int64
+	var retracted int
+	var raw string
+	if err := rows.Scan(append([]any{&seq, &retracted, &raw}, extra...)...); err != nil {
+		return event.Event{}, err
+	}
+	var e event.Event
+	if err := json.Unmarshal([]byte(raw), &e); err != nil {
+		return event.Event{}, err
+	}
+	e.Seq = seq
+	e.Retracted = retracted != 0
+	return e, nil
+}
+
+// ftsExpression turns text terms into an FTS5 MATCH expression (implicit AND).
+// Phrases stay phrases; words become prefix queries. Each term is quoted, so
+// user input never reaches FTS5 syntax.
+func ftsExpression(terms []query.Term) string {
+	parts := make([]string, 0, len(terms))
+	for _, t := range terms {
+		quoted := `"` + strings.ReplaceAll(t.Values[0], `"`, `""`) + `"`
+		if !t.Phrase {
+			quoted += "*"
+		}
+		parts = append(parts, quoted)
+	}
+	return strings.Join(parts, " ")
+}
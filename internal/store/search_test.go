@@ -0,0 +1,135 @@
+package store
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/divijg19/sage/internal/event"
+	"github.com/divijg19/sage/internal/query"
+)
+
+func TestStore_Search_RanksAndFollowsAnnotations(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(filepath.Join(dir, "sage.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	base := time.Date(2026, 1, 9, 10, 0, 0, 0, time.UTC)
+	entries := []event.Event{
+		{ID: "a", Timestamp: base, Project: "api", Kind: event.RecordKind, Title: "Cache notes", Content: "The token cache drifts after deploys."},
+		{ID: "b", Timestamp: base.Add(time.Minute), Project: "api", Kind: event.DecisionKind, Title: "Rotate tokens hourly", Content: "Shorter exposure."},
+		{ID: "c", Timestamp: base.Add(2 * time.Minute), Project: "web", Kind: event.RecordKind, Title: "Résumé page", Content: "Unrelated."},
+	}
+	for _, e := range entries {
+		if err := s.Append(e); err != nil {
+			t.Fatalf("Append %s: %v", e.ID, err)
+		}
+	}
+
+	hits, err := s.Search(mustQuery(t, "token"), SearchOptions{HighlightStart: "<", HighlightEnd: ">"})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(hits) != 2 || hits[0].Entry.ID != "b" || hits[1].Entry.ID != "a" {
+		t.Fatalf("expected title match ranked first, got %+v", hits)
+	}
+	if hits[1].Snippet != "The <token> cache drifts after deploys." {
+		t.Fatalf("unexpected snippet: %q", hits[1].Snippet)
+	}
+
+	if hits, _ := s.Search(mustQuery(t, `"cache drifts"`), SearchOptions{}); len(hits) != 1 || hits[0].Entry.Seq != 1 {
+		t.Fatalf("expected phrase match, got %+v", hits)
+	}
+	if hits, _ := s.Search(mustQuery(t, "resume"), SearchOptions{}); len(hits) != 1 || hits[0].Entry.ID != "c" {
+		t.Fatalf("expected diacritics to be folded, got %+v", hits)
+	}
+	if hits, _ := s.Search(mustQuery(t, "token project:web"), SearchOptions{}); len(hits) != 0 {
+		t.Fatalf("expected project scope to apply, got %+v", hits)
+	}
+	if hits, _ := s.Search(mustQuery(t, "kind:decision"), SearchOptions{}); len(hits) != 1 || hits[0].Entry.ID != "b" || hits[0].Snippet != "" {
+		t.Fatalf("expected filter-only search to list matches without snippets, got %+v", hits)
+	}
+
+	annotations := []event.Event{
+		{ID: "t1", Timestamp: base.Add(time.Hour), Project: "web", Kind: event.TagKind, Tags: []string{"frontend"},
+			Metadata: map[string]string{event.MetaTarget: "c", event.MetaOp: event.TagOpAdd}},
+		{ID: "r1", Timestamp: base.Add(time.Hour), Project: "api", Kind: event.RevisionKind, Title: "Rotate keys hourly", Content: "Shorter exposure.",
+			Metadata: map[string]string{event.MetaTarget: "b"}},
+		{ID: "x1", Timestamp: base.Add(time.Hour), Project: "api", Kind: event.RetractKind,
+			Metadata: map[string]string{event.MetaTarget: "a"}},
+	}
+	for _, e := range annotations {
+		if err := s.Append(e); err != nil {
+			t.Fatalf("Append %s: %v", e.ID, err)
+		}
+	}
+
+	if hits, _ := s.Search(mustQuery(t, "frontend"), SearchOptions{}); len(hits) != 1 || hits[0].Entry.ID != "c" {
+		t.Fatalf("expected tag event to re-index its target, got %+v", hits)
+	}
+	if hits, _ := s.Search(mustQuery(t, "keys"), SearchOptions{}); len(hits) != 1 || hits[0].Entry.Title != "Rotate keys hourly" {
+		t.Fatalf("expected revision to re-index its target, got %+v", hits)
+	}
+	if hits, _ := s.Search(mustQuery(t, "token"), SearchOptions{}); len(hits) != 0 {
+		t.Fatalf("expected revised and retracted entries to drop out, got %+v", hits)
+	}
+	hits, err = s.IncludingRetracted().Search(mustQuery(t, "token"), SearchOptions{})
+	if err != nil {
+		t.Fatalf("Search (including retracted): %v", err)
+	}
+	if len(hits) != 1 || !hits[0].Entry.Retracted {
+		t.Fatalf("expected retracted hit to be marked, got %+v", hits)
+	}
+}
+
+func TestStore_Search_BackfillsExistingDatabase(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "sage.db")
+	s, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	base := time.Date(2026, 1, 9, 10, 0, 0, 0, time.UTC)
+	for _, e := range []event.Event{
+		{ID: "a", Timestamp: base, Project: "p", Kind: event.RecordKind, Title: "Durability first"},
+		{ID: "t", Timestamp: base.Add(time.Minute), Project: "p", Kind: event.TagKind, Tags: []string{"storage"},
+			Metadata: map[string]string{event.MetaTarget: "a", event.MetaOp: event.TagOpAdd}},
+	} {
+		if err := s.Append(e); err != nil {
+			t.Fatalf("Append %s: %v", e.ID, err)
+		}
+	}
+
+	// Simulate a database written before the index existed.
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`DROP TABLE events_fts;`); err != nil {
+		t.Fatalf("drop index: %v", err)
+	}
+
+	reopened, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open (backfill): %v", err)
+	}
+	hits, err := reopened.Search(mustQuery(t, "storage"), SearchOptions{})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(hits) != 1 || hits[0].Entry.ID != "a" || len(hits[0].Entry.Tags) != 1 {
+		t.Fatalf("expected backfilled index with folded tags, got %+v", hits)
+	}
+}
+
+func mustQuery(t *testing.T, raw string) query.Query {
+	t.Helper()
+	q, err := query.Parse(raw, query.Options{})
+	if err != nil {
+		t.Fatalf("query.Parse(%q): %v", raw, err)
+	}
+	return q
+}
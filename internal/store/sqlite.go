@@ -0,0 +1,77 @@
+package store
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// busyTimeout is how long SQLite itself waits on a lock before giving up.
+const busyTimeout = 5 * time.Second
+
+// writeAttempts bounds retryBusy. With the backoff below, the attempts span
+// roughly two seconds beyond the busy timeouts themselves.
+const writeAttempts = 8
+
+// dsn names the database at path with the pragmas every connection needs.
+// database/sql opens connections as it sees fit, so a pragma set with Exec
+// would only reach one of them.
+//
+// Read-write connections use WAL, so readers (timeline, the TUI) never block
+// the background hooks that append and vice versa. synchronous=NORMAL is
+// durable across application crashes in WAL mode, which is what the log needs.
+// Transactions begin IMMEDIATE so two writers queue on the busy timeout
+// instead of deadlocking when both try to upgrade a read lock.
+func dsn(path string, readOnly bool) string {
+	params := url.Values{}
+	params.Add("_pragma", "busy_timeout("+strconv.FormatInt(busyTimeout.Milliseconds(), 10)+")")
+	params.Add("_pragma", "foreign_keys(1)")
+	if readOnly {
+		params.Set("mode", "ro")
+	} else {
+		params.Add("_pragma", "journal_mode(WAL)")
+		params.Add("_pragma", "synchronous(NORMAL)")
+		params.Set("_txlock", "immediate")
+	}
+	return "file:" + escapePath(path) + "?" + params.Encode()
+}
+
+// escapePath keeps characters that delimit a URI out of a file name.
+func escapePath(path string) string {
+	return strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23").Replace(path)
+}
+
+// retryBusy runs write until it succeeds, fails for a reason other than a
+// lock, or runs out of attempts. The busy timeout already waits inside
+// SQLite; this covers the cases it does not, such as a reader holding a WAL
+// snapshot across a checkpoint or a hook outlasting the timeout.
+func retryBusy(write func() error) error {
+	delay := 10 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		err := write()
+		if err == nil || attempt == writeAttempts || !isBusy(err) {
+			return err
+		}
+		// Jitter keeps hooks started by the same git command from retrying in step.
+		time.Sleep(delay + rand.N(delay))
+		delay *= 2
+	}
+}
+
+func isBusy(err error) bool {
+	var e *sqlite.Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	switch e.Code() & 0xff {
+	case sqlite3.SQLITE_BUSY, sqlite3.SQLITE_LOCKED:
+		return true
+	}
+	return false
+}
@@ -3,7 +3,6 @@ package store
 import (
 	"database/sql"
 	"encoding/json"
-	"fmt"
 	"sort"
 	"strings"
 	"time"
@@ -14,314 +13,97 @@ import (
 
 type Store struct {
 	db *sql.DB
+
+	includeRetracted bool
+}
+
+// IncludingRetracted returns a view of the store whose Query and Search keep
+// retracted entries (marked Retracted) instead of hiding them.
+func (s *Store) IncludingRetracted() *Store {
+	return &Store{db: s.db, includeRetracted: true}
 }
 
 func Open(path string) (*Store, error) {
-	db, err := sql.Open("sqlite", path)
+	s, _, err := OpenWithResult(path)
+	return s, err
+}
+
+// OpenWithResult is Open, also reporting the migrations it applied so the
+// caller can say where the pre-migration backup went.
+func OpenWithResult(path string) (*Store, MigrationResult, error) {
+	db, err := sql.Open("sqlite", dsn(path, false))
 	if err != nil {
-		return nil, err
+		return nil, MigrationResult{}, err
 	}
 
-	// Reduce "database is locked" errors under concurrent access (e.g. hooks + reads).
-	// This is a connection-local setting.
-	_, _ = db.Exec(`PRAGMA busy_timeout = 5000;`)
-
-	if err := migrate(db); err != nil {
-		return nil, err
+	result, err := migrate(db, path)
+	if err != nil {
+		db.Close()
+		return nil, result, err
 	}
 
-	return &Store{db: db}, nil
+	return &Store{db: db}, result, nil
 }
 
-func migrate(db *sql.DB) error {
-	// v2 schema adds a numeric, user-facing id (seq).
-	// We migrate existing v1 DBs by copying events in stable chronological order.
-	if ok, err := hasColumn(db, "events", "seq"); err != nil {
-		return err
-	} else if ok {
-		return ensureIndexes(db)
-	}
+// Append records e, retrying with backoff while another process holds the
+// write lock.
+func (s *Store) Append(e event.Event) error {
+	return retryBusy(func() error { return s.append(e) })
+}
 
-	// If this is a v1 DB, migration must be transactional and fail-loud.
-	// We should never drop/rename the existing table unless the copy succeeds.
-	tx, err := db.Begin()
+func (s *Store) append(e event.Event) error {
+	data, err := json.Marshal(e)
 	if err != nil {
 		return err
 	}
-	defer tx.Rollback()
 
-	// Create v2 table and copy data.
-	createV2 := `
-	CREATE TABLE IF NOT EXISTS events_v2 (
-		seq INTEGER PRIMARY KEY AUTOINCREMENT,
-		id TEXT NOT NULL UNIQUE,
-		timestamp TEXT NOT NULL,
-		type TEXT NOT NULL,
-		project TEXT NOT NULL,
-		data TEXT NOT NULL
-	);
-	`
-	if _, err := tx.Exec(createV2); err != nil {
-		return err
-	}
-
-	// Copy from v1 if it exists. Order by timestamp then id for deterministic seq assignment.
-	if ok, err := tableExists(db, "events"); err != nil {
+	tx, err := s.db.Begin()
+	if err != nil {
 		return err
-	} else if ok {
-		copySQL := `
-		INSERT INTO events_v2 (id, timestamp, type, project, data)
-		SELECT id, timestamp, type, project, data
-		FROM events
-		ORDER BY timestamp ASC, id ASC;
-		`
-		if _, err := tx.Exec(copySQL); err != nil {
-			return err
-		}
 	}
+	defer tx.Rollback()
 
-	// Swap tables.
-	// If v1 didn't exist, events_v2 is our empty table.
-	if _, err := tx.Exec(`DROP TABLE IF EXISTS events;`); err != nil {
-		return err
-	}
-	if _, err := tx.Exec(`ALTER TABLE events_v2 RENAME TO events;`); err != nil {
+	res, err := insertEvent(tx, e, data)
+	if err != nil {
 		return err
 	}
-
-	schema := `
-	CREATE INDEX IF NOT EXISTS idx_events_time
-	ON events(timestamp);
-	CREATE UNIQUE INDEX IF NOT EXISTS idx_events_id
-	ON events(id);
-	CREATE INDEX IF NOT EXISTS idx_events_project
-	ON events(project);
-	CREATE INDEX IF NOT EXISTS idx_events_project_seq
-	ON events(project, seq);
-	`
-	if _, err := tx.Exec(schema); err != nil {
+	if err := projectEvent(tx, e, res); err != nil {
 		return err
 	}
 
 	return tx.Commit()
 }
 
-func tableExists(db *sql.DB, table string) (bool, error) {
-	row := db.QueryRow(`SELECT 1 FROM sqlite_master WHERE type='table' AND name = ? LIMIT 1;`, table)
-	var one int
-	if err := row.Scan(&one); err != nil {
-		if err == sql.ErrNoRows {
-			return false, nil
-		}
-		return false, err
-	}
-	return true, nil
-}
-
-func hasColumn(db *sql.DB, table string, column string) (bool, error) {
-	q := fmt.Sprintf("PRAGMA table_info(%s);", table)
-	rows, err := db.Query(q)
-	if err != nil {
-		return false, err
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var cid int
-		var name string
-		var typ string
-		var notnull int
-		var dflt sql.NullString
-		var pk int
-		if err := rows.Scan(&cid, &name, &typ, &notnull, &dflt, &pk); err != nil {
-			return false, err
-		}
-		if name == column {
-			return true, nil
-		}
-	}
-	return false, rows.Err()
-}
-
-func ensureIndexes(db *sql.DB) error {
-	schema := `
-	CREATE INDEX IF NOT EXISTS idx_events_time
-	ON events(timestamp);
-	CREATE UNIQUE INDEX IF NOT EXISTS idx_events_id
-	ON events(id);
-	CREATE INDEX IF NOT EXISTS idx_events_project
-	ON events(project);
-	CREATE INDEX IF NOT EXISTS idx_events_project_seq
-	ON events(project, seq);
-	`
-	_, err := db.Exec(schema)
-	return err
-}
-
-func (s *Store) Append(e event.Event) error {
-	data, err := json.Marshal(e)
-	if err != nil {
-		return err
-	}
-
+func insertEvent(x execer, e event.Event, data []byte) (sql.Result, error) {
 	query := `
-	INSERT INTO events (id, timestamp, type, project, data)
-	VALUES (?, ?, ?, ?, ?)
+	INSERT INTO events (id, timestamp, time_utc, type, project, data, target, link_to)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 	`
-
-	_, err = s.db.Exec(
+	return x.Exec(
 		query,
 		e.ID,
-		e.Timestamp.Format(time.RFC3339),
+		e.Timestamp.Format(time.RFC3339Nano),
+		sortableTime(e.Timestamp),
 		e.Kind,
 		e.Project,
 		string(data),
+		nullIfEmpty(e.Target()),
+		nullIfEmpty(e.Metadata[event.MetaTo]),
 	)
-
-	return err
 }
 
-func (s *Store) List() ([]event.Event, error) {
-	query := `
-	SELECT seq, data
-	FROM events
-	ORDER BY seq ASC
-	`
-
-	rows, err := s.db.Query(query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var events []event.Event
-
-	for rows.Next() {
-		var seq int64
-		var raw string
-		if err := rows.Scan(&seq, &raw); err != nil {
-			return nil, err
-		}
-
-		var e event.Event
-		if err := json.Unmarshal([]byte(raw), &e); err != nil {
-			return nil, err
-		}
-		e.Seq = seq
-
-		events = append(events, e)
-	}
-
-	return events, rows.Err()
-}
-
-func (s *Store) ListByProject(project string) ([]event.Event, error) {
-	query := `
-	SELECT seq, data
-	FROM events
-	WHERE project = ?
-	ORDER BY seq ASC
-	`
-
-	rows, err := s.db.Query(query, project)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var events []event.Event
-
-	for rows.Next() {
-		var seq int64
-		var raw string
-		if err := rows.Scan(&seq, &raw); err != nil {
-			return nil, err
-		}
-
-		var e event.Event
-		if err := json.Unmarshal([]byte(raw), &e); err != nil {
-			return nil, err
-		}
-		e.Seq = seq
-		events = append(events, e)
-	}
-
-	return events, rows.Err()
-}
-
-func (s *Store) ListUntil(t time.Time) ([]event.Event, error) {
-	query := `
-	SELECT seq, data
-	FROM events
-	WHERE timestamp <= ?
-	ORDER BY seq ASC
-	`
-
-	rows, err := s.db.Query(query, t.Format(time.RFC3339))
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var events []event.Event
-
-	for rows.Next() {
-		var seq int64
-		var raw string
-		if err := rows.Scan(&seq, &raw); err != nil {
-			return nil, err
-		}
-
-		var e event.Event
-		if err := json.Unmarshal([]byte(raw), &e); err != nil {
-			return nil, err
-		}
-		e.Seq = seq
-
-		events = append(events, e)
-	}
-
-	return events, rows.Err()
-}
-
-func (s *Store) ListUntilByProject(t time.Time, project string) ([]event.Event, error) {
-	query := `
-	SELECT seq, data
-	FROM events
-	WHERE timestamp <= ? AND project = ?
-	ORDER BY seq ASC
-	`
-
-	rows, err := s.db.Query(query, t.Format(time.RFC3339), project)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var events []event.Event
-
-	for rows.Next() {
-		var seq int64
-		var raw string
-		if err := rows.Scan(&seq, &raw); err != nil {
-			return nil, err
-		}
-
-		var e event.Event
-		if err := json.Unmarshal([]byte(raw), &e); err != nil {
-			return nil, err
-		}
-		e.Seq = seq
-		events = append(events, e)
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
 	}
-
-	return events, rows.Err()
+	return s
 }
 
 func (s *Store) Latest() (*event.Event, error) {
 	query := `
 	SELECT seq, data
 	FROM events
+	WHERE ` + entryKindClause + `
 	ORDER BY seq DESC
 	LIMIT 1
 	`
@@ -342,14 +124,14 @@ func (s *Store) Latest() (*event.Event, error) {
 	}
 	e.Seq = seq
 
-	return &e, nil
+	return s.foldOne(e)
 }
 
 func (s *Store) LatestByProject(project string) (*event.Event, error) {
 	query := `
 	SELECT seq, data
 	FROM events
-	WHERE project = ?
+	WHERE project = ? AND ` + entryKindClause + `
 	ORDER BY seq DESC
 	LIMIT 1
 	`
@@ -369,7 +151,7 @@ func (s *Store) LatestByProject(project string) (*event.Event, error) {
 		return nil, err
 	}
 	e.Seq = seq
-	return &e, nil
+	return s.foldOne(e)
 }
 
 func (s *Store) ListProjects() ([]string, error) {
@@ -390,6 +172,29 @@ func (s *Store) ListProjects() ([]string, error) {
 	return out, rows.Err()
 }
 
+// ListRepoRoots returns the repositories Git hooks have recorded entries from.
+func (s *Store) ListRepoRoots() ([]string, error) {
+	rows, err := s.db.Query(`
+	SELECT DISTINCT json_extract(data, '$.metadata.repo_root') AS root
+	FROM entries
+	WHERE kind IN (?, ?) AND root IS NOT NULL AND root != ''
+	ORDER BY root ASC;`, event.CommitKind, event.GitKind)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []string
+	for rows.Next() {
+		var root string
+		if err := rows.Scan(&root); err != nil {
+			return nil, err
+		}
+		out = append(out, root)
+	}
+	return out, rows.Err()
+}
+
 func (s *Store) Count() (int, error) {
 	row := s.db.QueryRow(`SELECT COUNT(*) FROM events;`)
 	var n int
@@ -400,6 +205,27 @@ func (s *Store) Count() (int, error) {
 }
 
 func (s *Store) GetBySeq(seq int64) (*event.Event, error) {
+	e, err := s.getRawBySeq(seq)
+	if err != nil || e == nil {
+		return e, err
+	}
+	return s.foldOne(*e)
+}
+
+// GetByID returns the entry with the given event ID, folded like GetBySeq.
+func (s *Store) GetByID(id string) (*event.Event, error) {
+	var seq int64
+	err := s.db.QueryRow(`SELECT seq FROM events WHERE id = ? LIMIT 1;`, id).Scan(&seq)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return s.GetBySeq(seq)
+}
+
+func (s *Store) getRawBySeq(seq int64) (*event.Event, error) {
 	query := `
 	SELECT seq, data
 	FROM events
@@ -425,29 +251,177 @@ func (s *Store) GetBySeq(seq int64) (*event.Event, error) {
 	return &e, nil
 }
 
-func (s *Store) UpdateTagsBySeq(seq int64, tags []string) error {
-	e, err := s.GetBySeq(seq)
+// Revision is one version of an entry.
+type Revision struct {
+	// Seq is the seq of the revision event, or the entry's own seq for the original.
+	Seq int64
+	// At is when this version was recorded.
+	At time.Time
+	// Entry is the entry as it looked right after this version was recorded.
+	Entry event.Event
+}
+
+// Revisions returns every version of the entry with the given seq, oldest first.
+// The first element is the entry as originally recorded.
+func (s *Store) Revisions(seq int64) ([]Revision, error) {
+	e, err := s.getRawBySeq(seq)
+	if err != nil || e == nil {
+		return nil, err
+	}
+
+	history := []Revision{{Seq: e.Seq, At: e.Timestamp, Entry: *e}}
+	if e.Kind.IsAnnotation() {
+		return history, nil
+	}
+
+	annotations, err := s.Annotations(e.ID)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if e == nil {
-		return fmt.Errorf("no entry with id %d", seq)
+
+	for i, a := range annotations {
+		if a.Kind != event.RevisionKind {
+			continue
+		}
+		folded := event.FoldWith(append([]event.Event{*e}, annotations[:i+1]...), event.FoldOptions{IncludeRetracted: true})
+		history = append(history, Revision{Seq: a.Seq, At: a.Timestamp, Entry: folded[0]})
 	}
+	return history, nil
+}
 
-	e.Tags = tags
-	b, err := json.Marshal(e)
+// entryKindClause excludes annotation events, which only exist to modify other entries.
+var entryKindClause = func() string {
+	kinds := event.AnnotationKinds()
+	quoted := make([]string, 0, len(kinds))
+	for _, k := range kinds {
+		quoted = append(quoted, "'"+string(k)+"'")
+	}
+	return "type NOT IN (" + strings.Join(quoted, ", ") + ")"
+}()
+
+// Annotations returns every annotation event that targets the entry with the given ID, in seq order.
+func (s *Store) Annotations(id string) ([]event.Event, error) {
+	return annotations(s.db, id)
+}
+
+func annotations(q queryer, id string) ([]event.Event, error) {
+	query := `
+	SELECT seq, data
+	FROM events
+	WHERE target = ?
+	ORDER BY seq ASC
+	`
+
+	rows, err := q.Query(query, id)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer rows.Close()
 
-	_, err = s.db.Exec(`UPDATE events SET data = ? WHERE seq = ?;`, string(b), seq)
-	return err
+	var events []event.Event
+	for rows.Next() {
+		var seq int64
+		var raw string
+		if err := rows.Scan(&seq, &raw); err != nil {
+			return nil, err
+		}
+
+		var e event.Event
+		if err := json.Unmarshal([]byte(raw), &e); err != nil {
+			return nil, err
+		}
+		e.Seq = seq
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
+
+// LinksFor returns every link into or out of the entry with the given ID, in seq order.
+func (s *Store) LinksFor(id string) ([]event.Link, error) {
+	query := `
+	SELECT seq, data
+	FROM events
+	WHERE type = ?
+	AND (target = ? OR link_to = ?)
+	ORDER BY seq ASC
+	`
+	return s.queryLinks(query, event.LinkKind, id, id)
+}
+
+// Links returns every declared link, in seq order.
+func (s *Store) Links() ([]event.Link, error) {
+	query := `
+	SELECT seq, data
+	FROM events
+	WHERE type = ?
+	ORDER BY seq ASC
+	`
+	return s.queryLinks(query, event.LinkKind)
+}
+
+// LinksUntil returns every link declared at or before t, in seq order.
+func (s *Store) LinksUntil(t time.Time) ([]event.Link, error) {
+	query := `
+	SELECT seq, data
+	FROM events
+	WHERE type = ? AND time_utc <= ?
+	ORDER BY seq ASC
+	`
+	return s.queryLinks(query, event.LinkKind, sortableTime(t))
+}
+
+func (s *Store) queryLinks(query string, args ...any) ([]event.Link, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []event.Link
+	for rows.Next() {
+		var seq int64
+		var raw string
+		if err := rows.Scan(&seq, &raw); err != nil {
+			return nil, err
+		}
+
+		var e event.Event
+		if err := json.Unmarshal([]byte(raw), &e); err != nil {
+			return nil, err
+		}
+		e.Seq = seq
+		if l, ok := event.LinkFromEvent(e); ok {
+			links = append(links, l)
+		}
+	}
+
+	return links, rows.Err()
+}
+
+// foldOne applies the annotations targeting e. Annotation events are returned unchanged.
+// Retracted entries are returned marked rather than hidden, so they stay viewable by ID.
+func (s *Store) foldOne(e event.Event) (*event.Event, error) {
+	if e.Kind.IsAnnotation() {
+		return &e, nil
+	}
+
+	annotations, err := s.Annotations(e.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(annotations) == 0 {
+		return &e, nil
+	}
+
+	folded := event.FoldWith(append([]event.Event{e}, annotations...), event.FoldOptions{IncludeRetracted: true})
+	return &folded[0], nil
 }
 
 // ReadEventsFromDB reads events from a DB file without migrating it.
 // This is used for importing legacy per-directory stores.
 func ReadEventsFromDB(path string) ([]event.Event, error) {
-	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	db, err := sql.Open("sqlite", dsn(path, true))
 	if err != nil {
 		return nil, err
 	}
@@ -474,16 +448,40 @@ func ReadEventsFromDB(path string) ([]event.Event, error) {
 	return out, rows.Err()
 }
 
-func isUniqueConstraintErr(err error) bool {
-	if err == nil {
-		return false
+// IsDuplicate reports whether err is Append refusing an event whose ID is
+// already in the log. Other constraint failures (NOT NULL, CHECK, a trigger)
+// are real errors and do not count.
+func IsDuplicate(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed: events.id")
+}
+
+// KnownIDs reports which of ids are already in the log.
+func (s *Store) KnownIDs(ids []string) (map[string]bool, error) {
+	known := make(map[string]bool)
+	if len(ids) == 0 {
+		return known, nil
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := s.db.Query(`SELECT id FROM events WHERE id IN (?`+strings.Repeat(", ?", len(ids)-1)+`);`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		known[id] = true
 	}
-	msg := err.Error()
-	return strings.Contains(msg, "UNIQUE constraint failed") || strings.Contains(msg, "constraint failed")
+	return known, rows.Err()
 }
 
-// ImportEvents appends events into this store in deterministic order.
-// Duplicate IDs are skipped.
+// ImportEvents appends events in deterministic order inside one transaction,
+// so either every new event is recorded or none is. Duplicate IDs are skipped.
 func (s *Store) ImportEvents(events []event.Event) (int, error) {
 	if len(events) == 0 {
 		return 0, nil
@@ -496,16 +494,87 @@ func (s *Store) ImportEvents(events []event.Event) (int, error) {
 		return events[i].Timestamp.Before(events[j].Timestamp)
 	})
 
+	var inserted int
+	err := retryBusy(func() error {
+		var err error
+		inserted, err = s.importEvents(events)
+		return err
+	})
+	return inserted, err
+}
+
+func (s *Store) importEvents(events []event.Event) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
 	inserted := 0
 	for _, e := range events {
-		if err := s.Append(e); err != nil {
-			// If already present, skip.
-			if isUniqueConstraintErr(err) {
-				continue
-			}
-			return inserted, err
+		data, err := json.Marshal(e)
+		if err != nil {
+			return 0, err
+		}
+		res, err := insertEvent(tx, e, data)
+		if IsDuplicate(err) {
+			continue
+		}
+		if err != nil {
+			return 0, err
 		}
 		inserted++
+
+		if err := projectEvent(tx, e, res); err != nil {
+			return 0, err
+		}
+	}
+	return inserted, tx.Commit()
+}
+
+// projectEvent updates the projection rows that e, just inserted with res,
+// affects. It reads through tx, so an annotation is folded with every event
+// committed before it and a concurrent writer cannot project a stale view.
+func projectEvent(tx *sql.Tx, e event.Event, res sql.Result) error {
+	switch {
+	case !e.Kind.IsAnnotation():
+		seq, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		e.Seq = seq
+		return projectEntry(tx, e)
+	case e.Kind != event.LinkKind:
+		return reprojectEntry(tx, e.Target())
+	}
+	return nil
+}
+
+// reprojectEntry rewrites the projection rows of the entry with the given ID
+// from its event and every annotation on it. Unknown IDs are left alone.
+func reprojectEntry(tx *sql.Tx, id string) error {
+	var seq int64
+	var raw string
+	err := tx.QueryRow(`SELECT seq, data FROM events WHERE id = ? LIMIT 1;`, id).Scan(&seq, &raw)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var e event.Event
+	if err := json.Unmarshal([]byte(raw), &e); err != nil {
+		return err
+	}
+	if e.Kind.IsAnnotation() {
+		return nil
+	}
+	e.Seq = seq
+
+	anns, err := annotations(tx, id)
+	if err != nil {
+		return err
 	}
-	return inserted, nil
+	folded := event.FoldWith(append([]event.Event{e}, anns...), event.FoldOptions{IncludeRetracted: true})
+	return projectEntry(tx, folded[0])
 }
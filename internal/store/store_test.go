@@ -1,9 +1,11 @@
 package store
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -48,9 +50,9 @@ func TestStore_AppendListLatest(t *testing.T) {
 		t.Fatalf("Append e2: %v", err)
 	}
 
-	all, err := s.List()
+	all, err := Collect(s.Query(context.Background(), Filter{}))
 	if err != nil {
-		t.Fatalf("List: %v", err)
+		t.Fatalf("Query: %v", err)
 	}
 	if len(all) != 2 {
 		t.Fatalf("expected 2 events, got %d", len(all))
@@ -73,8 +75,16 @@ func TestStore_AppendListLatest(t *testing.T) {
 		t.Fatalf("expected latest title t2, got %q", latest.Title)
 	}
 
-	if err := s.UpdateTagsBySeq(1, []string{"x", "y"}); err != nil {
-		t.Fatalf("UpdateTagsBySeq: %v", err)
+	tagEvt := event.Event{
+		ID:        "tag-1",
+		Timestamp: base.Add(3 * time.Minute),
+		Project:   "proj",
+		Kind:      event.TagKind,
+		Tags:      []string{"x", "y"},
+		Metadata:  map[string]string{event.MetaTarget: "1", event.MetaOp: event.TagOpAdd},
+	}
+	if err := s.Append(tagEvt); err != nil {
+		t.Fatalf("Append tag event: %v", err)
 	}
 	g, err := s.GetBySeq(1)
 	if err != nil {
@@ -83,8 +93,74 @@ func TestStore_AppendListLatest(t *testing.T) {
 	if g == nil {
 		t.Fatalf("expected entry")
 	}
-	if len(g.Tags) != 2 || g.Tags[0] != "x" || g.Tags[1] != "y" {
-		t.Fatalf("expected updated tags [x y], got %v", g.Tags)
+	if len(g.Tags) != 3 || g.Tags[0] != "a" || g.Tags[1] != "x" || g.Tags[2] != "y" {
+		t.Fatalf("expected folded tags [a x y], got %v", g.Tags)
+	}
+
+	// Tag events are folded, never listed, and never count as the latest entry.
+	all, err = Collect(s.Query(context.Background(), Filter{}))
+	if err != nil {
+		t.Fatalf("Query (after tag): %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("expected 2 entries after tagging, got %d", len(all))
+	}
+	latest, err = s.Latest()
+	if err != nil {
+		t.Fatalf("Latest (after tag): %v", err)
+	}
+	if latest == nil || latest.ID != "2" {
+		t.Fatalf("expected latest entry to stay ID=2, got %+v", latest)
+	}
+}
+
+func TestStore_TagEvents_ReplayAtTime(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(filepath.Join(dir, "sage.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	base := time.Date(2026, 1, 9, 10, 0, 0, 0, time.UTC)
+	entry := event.Event{ID: "e", Timestamp: base, Project: "p", Kind: event.DecisionKind, Title: "t", Content: "c", Tags: []string{"auth"}}
+	add := event.Event{ID: "t1", Timestamp: base.Add(time.Hour), Project: "p", Kind: event.TagKind, Tags: []string{"wip"},
+		Metadata: map[string]string{event.MetaTarget: "e", event.MetaOp: event.TagOpAdd}}
+	remove := event.Event{ID: "t2", Timestamp: base.Add(2 * time.Hour), Project: "p", Kind: event.TagKind, Tags: []string{"auth"},
+		Metadata: map[string]string{event.MetaTarget: "e", event.MetaOp: event.TagOpRemove}}
+	for _, e := range []event.Event{entry, add, remove} {
+		if err := s.Append(e); err != nil {
+			t.Fatalf("Append %s: %v", e.ID, err)
+		}
+	}
+
+	cases := []struct {
+		at   time.Time
+		want string
+	}{
+		{base, "auth"},
+		{base.Add(90 * time.Minute), "auth,wip"},
+		{base.Add(3 * time.Hour), "wip"},
+	}
+	for _, tc := range cases {
+		got, err := Collect(s.Query(context.Background(), Filter{AsOf: tc.at, Projects: []string{"p"}}))
+		if err != nil {
+			t.Fatalf("Query: %v", err)
+		}
+		if len(got) != 1 {
+			t.Fatalf("expected 1 entry at %s, got %d", tc.at, len(got))
+		}
+		if strings.Join(got[0].Tags, ",") != tc.want {
+			t.Fatalf("at %s: expected tags %q, got %v", tc.at, tc.want, got[0].Tags)
+		}
+	}
+
+	// The original row is never rewritten.
+	var raw string
+	if err := s.db.QueryRow(`SELECT data FROM events WHERE id = 'e';`).Scan(&raw); err != nil {
+		t.Fatalf("read raw: %v", err)
+	}
+	if raw != mustJSON(t, entry) {
+		t.Fatalf("expected stored entry to be unchanged, got %s", raw)
 	}
 }
 
@@ -149,9 +225,9 @@ func TestStore_MigrateV1_AssignsDeterministicSeqAndIsIdempotent(t *testing.T) {
 		t.Fatalf("Open (migrate): %v", err)
 	}
 
-	got, err := s.List()
+	got, err := Collect(s.Query(context.Background(), Filter{}))
 	if err != nil {
-		t.Fatalf("List: %v", err)
+		t.Fatalf("Query: %v", err)
 	}
 	if len(got) != 2 {
 		t.Fatalf("expected 2 events, got %d", len(got))
@@ -168,15 +244,126 @@ func TestStore_MigrateV1_AssignsDeterministicSeqAndIsIdempotent(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Open (second): %v", err)
 	}
-	got2, err := s2.List()
+	got2, err := Collect(s2.Query(context.Background(), Filter{}))
 	if err != nil {
-		t.Fatalf("List (second): %v", err)
+		t.Fatalf("Query (second): %v", err)
 	}
 	if got2[0].ID != got[0].ID || got2[0].Seq != got[0].Seq || got2[1].ID != got[1].ID || got2[1].Seq != got[1].Seq {
 		t.Fatalf("expected stable ordering across reopen")
 	}
 }
 
+func TestStore_TimeQueriesCompareInstantsAcrossOffsets(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "sage.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	ist := time.FixedZone("IST", 5*3600+1800)
+	est := time.FixedZone("EST", -5*3600)
+	// Recorded at 04:30Z; as a string "2026-01-09T10:00:00+05:30".
+	d1 := event.Event{ID: "d1", Timestamp: time.Date(2026, 1, 9, 10, 0, 0, 0, ist), Project: "p", Kind: event.DecisionKind, Title: "Use JWT"}
+	d2 := event.Event{ID: "d2", Timestamp: time.Date(2026, 1, 9, 10, 0, 0, 0, ist), Project: "p", Kind: event.DecisionKind, Title: "Use sessions"}
+	// Recorded at 14:00Z; as a string "2026-01-09T09:00:00-05:00", which
+	// sorts before the IST entries.
+	l := event.NewLinkEvent("l1", time.Date(2026, 1, 9, 9, 0, 0, 0, est), d1, event.Supersedes, d2)
+	tag := event.Event{ID: "t1", Timestamp: time.Date(2026, 1, 9, 9, 0, 0, 0, est), Project: "p", Kind: event.TagKind, Tags: []string{"auth"},
+		Metadata: map[string]string{event.MetaTarget: "d1", event.MetaOp: event.TagOpAdd}}
+	for _, e := range []event.Event{d1, d2, l, tag} {
+		if err := s.Append(e); err != nil {
+			t.Fatalf("Append %s: %v", e.ID, err)
+		}
+	}
+
+	noon := time.Date(2026, 1, 9, 12, 0, 0, 0, time.UTC)
+	links, err := s.LinksUntil(noon)
+	if err != nil {
+		t.Fatalf("LinksUntil: %v", err)
+	}
+	if len(links) != 0 {
+		t.Fatalf("a link recorded at 14:00Z is not in force at noon UTC: %+v", links)
+	}
+	got, err := Collect(s.Query(context.Background(), Filter{AsOf: noon}))
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(got) != 2 || len(got[0].Tags) != 0 {
+		t.Fatalf("a tag recorded at 14:00Z should not apply at noon UTC: %+v", got)
+	}
+
+	// Sub-second precision survives: just before the link, it is not in force.
+	if links, err = s.LinksUntil(l.Timestamp.Add(-time.Millisecond)); err != nil || len(links) != 0 {
+		t.Fatalf("LinksUntil just before: %+v, %v", links, err)
+	}
+	if links, err = s.LinksUntil(l.Timestamp); err != nil || len(links) != 1 {
+		t.Fatalf("LinksUntil at the link: %+v, %v", links, err)
+	}
+
+	// The original offset is kept for display.
+	var stamp string
+	if err := s.db.QueryRow(`SELECT timestamp FROM events WHERE id = 'l1';`).Scan(&stamp); err != nil {
+		t.Fatal(err)
+	}
+	if stamp != "2026-01-09T09:00:00-05:00" {
+		t.Fatalf("timestamp column should keep the offset, got %q", stamp)
+	}
+}
+
+func TestStore_MigrateUTCTime_BackfillsAndReindexes(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "sage.db")
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	defer db.Close()
+
+	// A database written before time_utc existed.
+	createV2 := `
+	CREATE TABLE events (
+		seq INTEGER PRIMARY KEY AUTOINCREMENT,
+		id TEXT NOT NULL UNIQUE,
+		timestamp TEXT NOT NULL,
+		type TEXT NOT NULL,
+		project TEXT NOT NULL,
+		data TEXT NOT NULL
+	);
+	CREATE INDEX idx_events_time ON events(timestamp);
+	`
+	if _, err := db.Exec(createV2); err != nil {
+		t.Fatalf("create v2: %v", err)
+	}
+	at := time.Date(2026, 1, 9, 10, 0, 0, 123456789, time.FixedZone("IST", 5*3600+1800))
+	e := event.Event{ID: "a", Timestamp: at, Project: "p", Kind: event.RecordKind, Title: "t"}
+	if _, err := db.Exec(`INSERT INTO events (id, timestamp, type, project, data) VALUES (?, ?, ?, ?, ?);`,
+		e.ID, at.Format(time.RFC3339), e.Kind, e.Project, mustJSON(t, e)); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	if _, err := Open(dbPath); err != nil {
+		t.Fatalf("Open (migrate): %v", err)
+	}
+
+	var utc string
+	if err := db.QueryRow(`SELECT time_utc FROM events WHERE id = 'a';`).Scan(&utc); err != nil {
+		t.Fatalf("time_utc: %v", err)
+	}
+	if utc != "2026-01-09T04:30:00.123456789Z" {
+		t.Fatalf("unexpected backfilled time_utc %q", utc)
+	}
+	var index string
+	if err := db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'index' AND name = 'idx_events_time';`).Scan(&index); err != nil {
+		t.Fatalf("idx_events_time: %v", err)
+	}
+	if !strings.Contains(index, "time_utc") {
+		t.Fatalf("idx_events_time should cover time_utc: %s", index)
+	}
+
+	// Reopening is a no-op.
+	if _, err := Open(dbPath); err != nil {
+		t.Fatalf("Open (second): %v", err)
+	}
+}
+
 func mustJSON(t *testing.T, e event.Event) string {
 	t.Helper()
 	b, err := json.Marshal(e)
@@ -185,3 +372,168 @@ func mustJSON(t *testing.T, e event.Event) string {
 	}
 	return string(b)
 }
+
+func TestStore_Revisions_FoldAtTime(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(filepath.Join(dir, "sage.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	base := time.Date(2026, 1, 9, 10, 0, 0, 0, time.UTC)
+	entry := event.Event{ID: "e", Timestamp: base, Project: "p", Kind: event.RecordKind, Title: "Use redis", Content: "fast"}
+	rev := event.Event{ID: "r1", Timestamp: base.Add(time.Hour), Project: "p", Kind: event.RevisionKind, Title: "Use postgres", Content: "durable",
+		Metadata: map[string]string{event.MetaTarget: "e", event.MetaKind: string(event.DecisionKind)}}
+	for _, e := range []event.Event{entry, rev} {
+		if err := s.Append(e); err != nil {
+			t.Fatalf("Append %s: %v", e.ID, err)
+		}
+	}
+
+	current, err := s.GetBySeq(1)
+	if err != nil {
+		t.Fatalf("GetBySeq: %v", err)
+	}
+	if current.Title != "Use postgres" || current.Kind != event.DecisionKind || current.Seq != 1 {
+		t.Fatalf("expected latest revision under seq 1, got %+v", current)
+	}
+
+	before, err := Collect(s.Query(context.Background(), Filter{AsOf: base.Add(30 * time.Minute)}))
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(before) != 1 || before[0].Title != "Use redis" || before[0].Kind != event.RecordKind {
+		t.Fatalf("expected original before the revision, got %+v", before)
+	}
+
+	revisions, err := s.Revisions(1)
+	if err != nil {
+		t.Fatalf("Revisions: %v", err)
+	}
+	if len(revisions) != 2 {
+		t.Fatalf("expected 2 revisions, got %d", len(revisions))
+	}
+	if revisions[0].Entry.Title != "Use redis" || revisions[1].Entry.Title != "Use postgres" || !revisions[1].At.Equal(rev.Timestamp) {
+		t.Fatalf("unexpected revisions: %+v", revisions)
+	}
+}
+
+func TestStore_Retraction_HidesButKeepsHistory(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(filepath.Join(dir, "sage.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	base := time.Date(2026, 1, 9, 10, 0, 0, 0, time.UTC)
+	keep := event.Event{ID: "k", Timestamp: base, Project: "p", Kind: event.RecordKind, Title: "keep", Content: "c"}
+	oops := event.Event{ID: "o", Timestamp: base.Add(time.Minute), Project: "p", Kind: event.RecordKind, Title: "oops", Content: "secret"}
+	retract := event.Event{ID: "x", Timestamp: base.Add(time.Hour), Project: "p", Kind: event.RetractKind,
+		Metadata: map[string]string{event.MetaTarget: "o"}}
+	for _, e := range []event.Event{keep, oops, retract} {
+		if err := s.Append(e); err != nil {
+			t.Fatalf("Append %s: %v", e.ID, err)
+		}
+	}
+
+	visible, err := Collect(s.Query(context.Background(), Filter{}))
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(visible) != 1 || visible[0].ID != "k" {
+		t.Fatalf("expected only the kept entry, got %+v", visible)
+	}
+
+	all, err := Collect(s.IncludingRetracted().Query(context.Background(), Filter{Projects: []string{"p"}}))
+	if err != nil {
+		t.Fatalf("Query (including retracted): %v", err)
+	}
+	if len(all) != 2 || all[0].Retracted || !all[1].Retracted {
+		t.Fatalf("expected retracted entry to be marked, got %+v", all)
+	}
+
+	before, err := Collect(s.Query(context.Background(), Filter{AsOf: base.Add(30 * time.Minute)}))
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(before) != 2 {
+		t.Fatalf("expected both entries before the retraction, got %d", len(before))
+	}
+
+	byID, err := s.GetBySeq(2)
+	if err != nil {
+		t.Fatalf("GetBySeq: %v", err)
+	}
+	if byID == nil || !byID.Retracted {
+		t.Fatalf("expected retracted entry to remain viewable by ID, got %+v", byID)
+	}
+
+	n, err := s.Count()
+	if err != nil {
+		t.Fatalf("Count: %v", err)
+	}
+	if n != 3 {
+		t.Fatalf("expected all rows kept, got %d", n)
+	}
+}
+
+func TestStore_ImportEvents_ProjectsWithinTheBatch(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "sage.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	base := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	if err := s.Append(event.Event{ID: "old", Timestamp: base, Project: "p", Kind: event.RecordKind, Title: "already here"}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	entry := event.Event{ID: "new", Timestamp: base.Add(time.Minute), Project: "p", Kind: event.RecordKind, Title: "imported"}
+	tag := event.Event{
+		ID: "tag", Timestamp: base.Add(2 * time.Minute), Project: "p", Kind: event.TagKind,
+		Tags:     []string{"imported"},
+		Metadata: map[string]string{event.MetaTarget: "new", event.MetaOp: event.TagOpAdd},
+	}
+	// Out of order on purpose, with a duplicate of an existing event.
+	n, err := s.ImportEvents([]event.Event{tag, entry, {ID: "old", Timestamp: base, Project: "p", Kind: event.RecordKind}})
+	if err != nil || n != 2 {
+		t.Fatalf("ImportEvents = %d, %v; want 2 new events", n, err)
+	}
+
+	// The tag targets an entry imported in the same transaction.
+	tagged, err := Collect(s.Query(context.Background(), Filter{AllTags: []string{"imported"}}))
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(tagged) != 1 || tagged[0].ID != "new" || tagged[0].Seq != 2 {
+		t.Fatalf("expected the imported entry tagged, got %+v", tagged)
+	}
+
+	if n, err := s.ImportEvents([]event.Event{entry, tag}); err != nil || n != 0 {
+		t.Fatalf("re-import = %d, %v; want nothing new", n, err)
+	}
+}
+
+func TestStore_ListRepoRoots(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "sage.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	base := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i, e := range []event.Event{
+		{ID: "c1", Kind: event.CommitKind, Metadata: map[string]string{"repo_root": "/src/web"}},
+		{ID: "c2", Kind: event.CommitKind, Metadata: map[string]string{"repo_root": "/src/api"}},
+		{ID: "g1", Kind: event.GitKind, Metadata: map[string]string{"repo_root": "/src/web"}},
+		{ID: "r1", Kind: event.RecordKind, Metadata: map[string]string{"repo_root": "/src/notes"}},
+		{ID: "c3", Kind: event.CommitKind},
+	} {
+		e.Timestamp, e.Project, e.Title = base.Add(time.Duration(i)*time.Minute), "p", e.ID
+		if err := s.Append(e); err != nil {
+			t.Fatalf("Append %s: %v", e.ID, err)
+		}
+	}
+
+	roots, err := s.ListRepoRoots()
+	if err != nil || strings.Join(roots, ",") != "/src/api,/src/web" {
+		t.Fatalf("ListRepoRoots = %v, %v", roots, err)
+	}
+}
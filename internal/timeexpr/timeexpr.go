@@ -0,0 +1,425 @@
+// Package timeexpr parses the time expressions accepted wherever Sage asks
+// for a time (--at, --since, --until, --day, after:/before:):
+//
+//	2026-01-09T21:30:00+05:30   RFC3339
+//	2026-01-09T21:30            local date and time
+//	2026-01-09  2026-01  2026-W02
+//	now  today  yesterday  tomorrow
+//	friday  last friday 17:00  this monday  next tue 9am
+//	3d ago  2 weeks ago  an hour ago
+//	start of week  end of last month  this year
+//	@{2.weeks.ago}              git reflog style; dots or underscores for spaces
+//
+// Everything without an explicit offset is read in the parser's location
+// (the local timezone by default). Weeks start on Monday.
+package timeexpr
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Span is the period an expression names: [Start, End). Expressions naming
+// an instant (3d ago, 17:00, RFC3339) have End equal to Start; calendar
+// expressions (yesterday, 2026-W02, last month) cover the whole period.
+type Span struct {
+	Start time.Time
+	End   time.Time
+}
+
+// IsInstant reports whether the span names a single instant.
+func (s Span) IsInstant() bool {
+	return s.End.Equal(s.Start)
+}
+
+// Parser parses expressions relative to a clock.
+type Parser struct {
+	// Now returns the current time; nil means time.Now.
+	Now func() time.Time
+	// Location reads dates and times of day; nil means time.Local.
+	Location *time.Location
+}
+
+// Parse parses s with the real clock in the local timezone.
+func Parse(s string) (time.Time, error) {
+	return Parser{}.Parse(s)
+}
+
+// ParseSpan parses s with the real clock in the local timezone.
+func ParseSpan(s string) (Span, error) {
+	return Parser{}.ParseSpan(s)
+}
+
+// Parse returns the instant s names, which for calendar expressions is the
+// start of the period.
+func (p Parser) Parse(s string) (time.Time, error) {
+	span, err := p.ParseSpan(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return span.Start, nil
+}
+
+// ParseSpan returns the period s names.
+func (p Parser) ParseSpan(s string) (Span, error) {
+	raw := strings.TrimSpace(s)
+	if raw == "" {
+		return Span{}, fmt.Errorf("empty time")
+	}
+	loc := p.location()
+
+	expr := raw
+	if strings.HasPrefix(expr, "@{") && strings.HasSuffix(expr, "}") {
+		expr = strings.TrimSpace(expr[2 : len(expr)-1])
+	}
+	if span, ok := parseAbsolute(expr, loc); ok {
+		return span, nil
+	}
+	expr = strings.NewReplacer(".", " ", "_", " ").Replace(strings.ToLower(expr))
+	words := strings.Fields(expr)
+	if len(words) == 0 {
+		return Span{}, fmt.Errorf("empty time")
+	}
+
+	ctx := parseContext{now: p.now().In(loc), loc: loc}
+	span, err := ctx.parse(words)
+	if err != nil {
+		return Span{}, fmt.Errorf("unrecognised time %q: %w", raw, err)
+	}
+	return span, nil
+}
+
+func (p Parser) now() time.Time {
+	if p.Now != nil {
+		return p.Now()
+	}
+	return time.Now()
+}
+
+func (p Parser) location() *time.Location {
+	if p.Location != nil {
+		return p.Location
+	}
+	return time.Local
+}
+
+var isoWeek = regexp.MustCompile(`^(\d{4})-?[wW](\d{2})$`)
+
+// parseAbsolute handles the fixed layouts, which need the original casing.
+func parseAbsolute(s string, loc *time.Location) (Span, bool) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return instant(t), true
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return instant(t), true
+		}
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, loc); err == nil {
+		return daySpan(t), true
+	}
+	if t, err := time.ParseInLocation("2006-01", s, loc); err == nil {
+		return monthSpan(t), true
+	}
+	if m := isoWeek.FindStringSubmatch(s); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		week, _ := strconv.Atoi(m[2])
+		if start, ok := isoWeekStart(year, week, loc); ok {
+			return Span{Start: start, End: start.AddDate(0, 0, 7)}, true
+		}
+	}
+	return Span{}, false
+}
+
+// isoWeekStart returns the Monday starting ISO week (year, week).
+func isoWeekStart(year, week int, loc *time.Location) (time.Time, bool) {
+	if week < 1 || week > 53 {
+		return time.Time{}, false
+	}
+	// January 4th always falls in week 1.
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, loc)
+	start := startOfWeek(jan4).AddDate(0, 0, (week-1)*7)
+	if y, w := start.ISOWeek(); y != year || w != week {
+		return time.Time{}, false
+	}
+	return start, true
+}
+
+type parseContext struct {
+	now time.Time
+	loc *time.Location
+}
+
+func (c parseContext) parse(words []string) (Span, error) {
+	words, clock, hasClock, err := splitClock(words)
+	if err != nil {
+		return Span{}, err
+	}
+
+	if len(words) == 0 {
+		// A bare time of day means today.
+		return instant(c.at(c.now, clock)), nil
+	}
+
+	span, err := c.parseDate(words)
+	if err != nil {
+		return Span{}, err
+	}
+	if !hasClock {
+		return span, nil
+	}
+	if !span.IsInstant() && span.End.Sub(span.Start) > 25*time.Hour {
+		return Span{}, fmt.Errorf("a time of day needs a single day")
+	}
+	return instant(c.at(span.Start, clock)), nil
+}
+
+func (c parseContext) parseDate(words []string) (Span, error) {
+	phrase := strings.Join(words, " ")
+	if span, ok := parseAbsolute(phrase, c.loc); ok {
+		return span, nil
+	}
+
+	switch phrase {
+	case "now":
+		return instant(c.now), nil
+	case "today":
+		return daySpan(c.now), nil
+	case "yesterday":
+		return daySpan(c.now.AddDate(0, 0, -1)), nil
+	case "tomorrow":
+		return daySpan(c.now.AddDate(0, 0, 1)), nil
+	}
+
+	if len(words) >= 3 && (words[0] == "start" || words[0] == "end") && words[1] == "of" {
+		span, err := c.parseDate(words[2:])
+		if err != nil {
+			return Span{}, err
+		}
+		if span.IsInstant() {
+			return Span{}, fmt.Errorf("%q names an instant, not a period", strings.Join(words[2:], " "))
+		}
+		if words[0] == "start" {
+			return instant(span.Start), nil
+		}
+		return instant(span.End.Add(-time.Nanosecond)), nil
+	}
+
+	if words[len(words)-1] == "ago" {
+		return c.parseAgo(words[:len(words)-1])
+	}
+
+	modifier := ""
+	rest := words
+	switch words[0] {
+	case "this", "last", "next":
+		modifier, rest = words[0], words[1:]
+	}
+	if len(rest) != 1 {
+		return Span{}, fmt.Errorf("unknown expression")
+	}
+
+	if day, ok := weekdays[rest[0]]; ok {
+		return daySpan(c.weekday(day, modifier)), nil
+	}
+
+	offset := map[string]int{"": 0, "this": 0, "last": -1, "next": 1}[modifier]
+	switch rest[0] {
+	case "day":
+		return daySpan(c.now.AddDate(0, 0, offset)), nil
+	case "week":
+		start := startOfWeek(c.now).AddDate(0, 0, 7*offset)
+		return Span{Start: start, End: start.AddDate(0, 0, 7)}, nil
+	case "month":
+		return monthSpan(time.Date(c.now.Year(), c.now.Month()+time.Month(offset), 1, 0, 0, 0, 0, c.loc)), nil
+	case "year":
+		start := time.Date(c.now.Year()+offset, time.January, 1, 0, 0, 0, 0, c.loc)
+		return Span{Start: start, End: start.AddDate(1, 0, 0)}, nil
+	}
+	return Span{}, fmt.Errorf("unknown expression")
+}
+
+// weekday resolves a weekday name. Alone (or with "last") it is the most
+// recent such day: today counts for a bare name but not for "last". "this"
+// is that day of the current week and "next" the first one after today.
+func (c parseContext) weekday(day time.Weekday, modifier string) time.Time {
+	today := startOfDay(c.now)
+	back := (int(today.Weekday()) - int(day) + 7) % 7
+	switch modifier {
+	case "last":
+		if back == 0 {
+			back = 7
+		}
+		return today.AddDate(0, 0, -back)
+	case "next":
+		ahead := (int(day) - int(today.Weekday()) + 7) % 7
+		if ahead == 0 {
+			ahead = 7
+		}
+		return today.AddDate(0, 0, ahead)
+	case "this":
+		return startOfWeek(today).AddDate(0, 0, (int(day)+6)%7)
+	default:
+		return today.AddDate(0, 0, -back)
+	}
+}
+
+// parseAgo reads "3d", "3 days", "a day" or "an hour" (before "ago").
+func (c parseContext) parseAgo(words []string) (Span, error) {
+	var count, unit string
+	switch len(words) {
+	case 1:
+		m := compactAmount.FindStringSubmatch(words[0])
+		if m == nil {
+			return Span{}, fmt.Errorf("expected an amount before \"ago\"")
+		}
+		count, unit = m[1], m[2]
+	case 2:
+		count, unit = words[0], words[1]
+	default:
+		return Span{}, fmt.Errorf("expected an amount before \"ago\"")
+	}
+
+	n := 1
+	if count != "a" && count != "an" {
+		var err error
+		if n, err = strconv.Atoi(count); err != nil || n < 0 {
+			return Span{}, fmt.Errorf("invalid amount %q", count)
+		}
+	}
+
+	switch normalizeUnit(unit) {
+	case "second":
+		return instant(c.now.Add(-time.Duration(n) * time.Second)), nil
+	case "minute":
+		return instant(c.now.Add(-time.Duration(n) * time.Minute)), nil
+	case "hour":
+		return instant(c.now.Add(-time.Duration(n) * time.Hour)), nil
+	case "day":
+		return instant(c.now.AddDate(0, 0, -n)), nil
+	case "week":
+		return instant(c.now.AddDate(0, 0, -7*n)), nil
+	case "month":
+		return instant(c.now.AddDate(0, -n, 0)), nil
+	case "year":
+		return instant(c.now.AddDate(-n, 0, 0)), nil
+	}
+	return Span{}, fmt.Errorf("unknown unit %q", unit)
+}
+
+var compactAmount = regexp.MustCompile(`^(\d+)([a-z]+)$`)
+
+func normalizeUnit(u string) string {
+	switch u {
+	case "s", "sec", "secs", "second", "seconds":
+		return "second"
+	case "m", "min", "mins", "minute", "minutes":
+		return "minute"
+	case "h", "hr", "hrs", "hour", "hours":
+		return "hour"
+	case "d", "day", "days":
+		return "day"
+	case "w", "wk", "wks", "week", "weeks":
+		return "week"
+	case "mo", "month", "months":
+		return "month"
+	case "y", "yr", "yrs", "year", "years":
+		return "year"
+	}
+	return ""
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thurs": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+type clockTime struct {
+	hour, minute, second int
+}
+
+var clockPattern = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(?::(\d{2}))?(am|pm)?$`)
+
+// splitClock removes a trailing time of day ("17:00", "5pm", "5:30 pm",
+// "noon", optionally after "at") from words.
+func splitClock(words []string) ([]string, clockTime, bool, error) {
+	last := words[len(words)-1]
+	n := 1
+	if (last == "am" || last == "pm") && len(words) >= 2 {
+		last = words[len(words)-2] + last
+		n = 2
+	}
+
+	var clock clockTime
+	switch last {
+	case "noon":
+		clock = clockTime{hour: 12}
+	case "midnight":
+		clock = clockTime{}
+	default:
+		m := clockPattern.FindStringSubmatch(last)
+		// A bare number is only a time of day with am/pm ("5pm"), so that
+		// "3 days ago" and "2026" keep their meaning.
+		if m == nil || (m[2] == "" && m[4] == "") {
+			return words, clockTime{}, false, nil
+		}
+		clock.hour, _ = strconv.Atoi(m[1])
+		clock.minute, _ = strconv.Atoi(m[2])
+		clock.second, _ = strconv.Atoi(m[3])
+		switch m[4] {
+		case "am", "pm":
+			if clock.hour < 1 || clock.hour > 12 {
+				return nil, clockTime{}, false, fmt.Errorf("invalid time of day %q", last)
+			}
+			clock.hour %= 12
+			if m[4] == "pm" {
+				clock.hour += 12
+			}
+		}
+		if clock.hour > 23 || clock.minute > 59 || clock.second > 59 {
+			return nil, clockTime{}, false, fmt.Errorf("invalid time of day %q", last)
+		}
+	}
+
+	words = words[:len(words)-n]
+	if len(words) > 0 && words[len(words)-1] == "at" {
+		words = words[:len(words)-1]
+	}
+	return words, clock, true, nil
+}
+
+func (c parseContext) at(day time.Time, clock clockTime) time.Time {
+	day = day.In(c.loc)
+	return time.Date(day.Year(), day.Month(), day.Day(), clock.hour, clock.minute, clock.second, 0, c.loc)
+}
+
+func instant(t time.Time) Span {
+	return Span{Start: t, End: t}
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func startOfWeek(t time.Time) time.Time {
+	day := startOfDay(t)
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+func daySpan(t time.Time) Span {
+	start := startOfDay(t)
+	return Span{Start: start, End: start.AddDate(0, 0, 1)}
+}
+
+func monthSpan(t time.Time) Span {
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	return Span{Start: start, End: start.AddDate(0, 1, 0)}
+}
@@ -0,0 +1,265 @@
+package timeexpr
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// The clock is fixed at Wednesday 2026-01-14 15:04:05 in a non-UTC zone, so
+// every expression is checked against the parser's location rather than UTC.
+var (
+	zone  = time.FixedZone("IST", 5*3600+1800)
+	clock = time.Date(2026, time.January, 14, 15, 4, 5, 0, zone)
+)
+
+func fixedParser() Parser {
+	return Parser{Now: func() time.Time { return clock }, Location: zone}
+}
+
+func at(year int, month time.Month, day, hour, min, sec int) time.Time {
+	return time.Date(year, month, day, hour, min, sec, 0, zone)
+}
+
+func day(year int, month time.Month, d int) Span {
+	start := at(year, month, d, 0, 0, 0)
+	return Span{Start: start, End: start.AddDate(0, 0, 1)}
+}
+
+func point(t time.Time) Span {
+	return Span{Start: t, End: t}
+}
+
+func TestParseSpan(t *testing.T) {
+	endOf := func(t time.Time) Span { return point(t.Add(-time.Nanosecond)) }
+
+	tests := []struct {
+		in   string
+		want Span
+	}{
+		// Absolute forms.
+		{"2026-01-09T21:30:00+05:30", point(at(2026, 1, 9, 21, 30, 0))},
+		{"2026-01-09T16:00:00Z", point(time.Date(2026, 1, 9, 16, 0, 0, 0, time.UTC))},
+		{"2026-01-09T21:30:00.5+05:30", point(at(2026, 1, 9, 21, 30, 0).Add(500 * time.Millisecond))},
+		{"2026-01-09T21:30", point(at(2026, 1, 9, 21, 30, 0))},
+		{"2026-01-09T21:30:15", point(at(2026, 1, 9, 21, 30, 15))},
+		{"2026-01-09", day(2026, 1, 9)},
+		{"  2026-01-09  ", day(2026, 1, 9)},
+		{"2026-01-09 17:00", point(at(2026, 1, 9, 17, 0, 0))},
+		{"2026-01-09 at 5pm", point(at(2026, 1, 9, 17, 0, 0))},
+		{"2026-01", Span{Start: at(2026, 1, 1, 0, 0, 0), End: at(2026, 2, 1, 0, 0, 0)}},
+		{"2025-12", Span{Start: at(2025, 12, 1, 0, 0, 0), End: at(2026, 1, 1, 0, 0, 0)}},
+
+		// ISO weeks (Monday to Monday).
+		{"2026-W02", Span{Start: at(2026, 1, 5, 0, 0, 0), End: at(2026, 1, 12, 0, 0, 0)}},
+		{"2026W02", Span{Start: at(2026, 1, 5, 0, 0, 0), End: at(2026, 1, 12, 0, 0, 0)}},
+		{"2026-w02", Span{Start: at(2026, 1, 5, 0, 0, 0), End: at(2026, 1, 12, 0, 0, 0)}},
+		{"2026-W01", Span{Start: at(2025, 12, 29, 0, 0, 0), End: at(2026, 1, 5, 0, 0, 0)}},
+		{"2020-W53", Span{Start: at(2020, 12, 28, 0, 0, 0), End: at(2021, 1, 4, 0, 0, 0)}},
+		{"2026-W53", Span{Start: at(2026, 12, 28, 0, 0, 0), End: at(2027, 1, 4, 0, 0, 0)}},
+
+		// Named days.
+		{"now", point(clock)},
+		{"today", day(2026, 1, 14)},
+		{"Today", day(2026, 1, 14)},
+		{"yesterday", day(2026, 1, 13)},
+		{"tomorrow", day(2026, 1, 15)},
+		{"yesterday 17:00", point(at(2026, 1, 13, 17, 0, 0))},
+		{"yesterday at 17:00", point(at(2026, 1, 13, 17, 0, 0))},
+		{"yesterday 5pm", point(at(2026, 1, 13, 17, 0, 0))},
+		{"yesterday 5 pm", point(at(2026, 1, 13, 17, 0, 0))},
+		{"today 9:30am", point(at(2026, 1, 14, 9, 30, 0))},
+		{"today 12am", point(at(2026, 1, 14, 0, 0, 0))},
+		{"today 12pm", point(at(2026, 1, 14, 12, 0, 0))},
+		{"today noon", point(at(2026, 1, 14, 12, 0, 0))},
+		{"tomorrow midnight", point(at(2026, 1, 15, 0, 0, 0))},
+		{"17:00", point(at(2026, 1, 14, 17, 0, 0))},
+		{"08:15:30", point(at(2026, 1, 14, 8, 15, 30))},
+		{"at 9am", point(at(2026, 1, 14, 9, 0, 0))},
+
+		// Weekdays (today is Wednesday the 14th).
+		{"friday", day(2026, 1, 9)},
+		{"fri", day(2026, 1, 9)},
+		{"last friday", day(2026, 1, 9)},
+		{"last friday 17:00", point(at(2026, 1, 9, 17, 0, 0))},
+		{"LAST Friday 17:00", point(at(2026, 1, 9, 17, 0, 0))},
+		{"wednesday", day(2026, 1, 14)},
+		{"last wednesday", day(2026, 1, 7)},
+		{"this wednesday", day(2026, 1, 14)},
+		{"next wednesday", day(2026, 1, 21)},
+		{"this friday", day(2026, 1, 16)},
+		{"this monday", day(2026, 1, 12)},
+		{"this sunday", day(2026, 1, 18)},
+		{"next monday", day(2026, 1, 19)},
+		{"next tue 9am", point(at(2026, 1, 20, 9, 0, 0))},
+		{"sun", day(2026, 1, 11)},
+		{"thursday", day(2026, 1, 8)},
+
+		// Relative amounts.
+		{"3d ago", point(at(2026, 1, 11, 15, 4, 5))},
+		{"3 days ago", point(at(2026, 1, 11, 15, 4, 5))},
+		{"1 day ago", point(at(2026, 1, 13, 15, 4, 5))},
+		{"a day ago", point(at(2026, 1, 13, 15, 4, 5))},
+		{"2 weeks ago", point(at(2025, 12, 31, 15, 4, 5))},
+		{"2w ago", point(at(2025, 12, 31, 15, 4, 5))},
+		{"an hour ago", point(at(2026, 1, 14, 14, 4, 5))},
+		{"90 minutes ago", point(at(2026, 1, 14, 13, 34, 5))},
+		{"90m ago", point(at(2026, 1, 14, 13, 34, 5))},
+		{"10s ago", point(at(2026, 1, 14, 15, 3, 55))},
+		{"36h ago", point(at(2026, 1, 13, 3, 4, 5))},
+		{"1mo ago", point(at(2025, 12, 14, 15, 4, 5))},
+		{"2 months ago", point(at(2025, 11, 14, 15, 4, 5))},
+		{"1 year ago", point(at(2025, 1, 14, 15, 4, 5))},
+		{"0d ago", point(clock)},
+		{"3 days ago 09:00", point(at(2026, 1, 11, 9, 0, 0))},
+
+		// Periods and their edges.
+		{"this week", Span{Start: at(2026, 1, 12, 0, 0, 0), End: at(2026, 1, 19, 0, 0, 0)}},
+		{"week", Span{Start: at(2026, 1, 12, 0, 0, 0), End: at(2026, 1, 19, 0, 0, 0)}},
+		{"last week", Span{Start: at(2026, 1, 5, 0, 0, 0), End: at(2026, 1, 12, 0, 0, 0)}},
+		{"next week", Span{Start: at(2026, 1, 19, 0, 0, 0), End: at(2026, 1, 26, 0, 0, 0)}},
+		{"this month", Span{Start: at(2026, 1, 1, 0, 0, 0), End: at(2026, 2, 1, 0, 0, 0)}},
+		{"last month", Span{Start: at(2025, 12, 1, 0, 0, 0), End: at(2026, 1, 1, 0, 0, 0)}},
+		{"next month", Span{Start: at(2026, 2, 1, 0, 0, 0), End: at(2026, 3, 1, 0, 0, 0)}},
+		{"this year", Span{Start: at(2026, 1, 1, 0, 0, 0), End: at(2027, 1, 1, 0, 0, 0)}},
+		{"last year", Span{Start: at(2025, 1, 1, 0, 0, 0), End: at(2026, 1, 1, 0, 0, 0)}},
+		{"last day", day(2026, 1, 13)},
+		{"start of week", point(at(2026, 1, 12, 0, 0, 0))},
+		{"start of this week", point(at(2026, 1, 12, 0, 0, 0))},
+		{"start of last week", point(at(2026, 1, 5, 0, 0, 0))},
+		{"start of next week", point(at(2026, 1, 19, 0, 0, 0))},
+		{"end of week", endOf(at(2026, 1, 19, 0, 0, 0))},
+		{"start of month", point(at(2026, 1, 1, 0, 0, 0))},
+		{"start of last month", point(at(2025, 12, 1, 0, 0, 0))},
+		{"end of last month", endOf(at(2026, 1, 1, 0, 0, 0))},
+		{"start of year", point(at(2026, 1, 1, 0, 0, 0))},
+		{"start of day", point(at(2026, 1, 14, 0, 0, 0))},
+		{"end of yesterday", endOf(at(2026, 1, 14, 0, 0, 0))},
+		{"start of 2026-W02", point(at(2026, 1, 5, 0, 0, 0))},
+		{"end of 2026-01", endOf(at(2026, 2, 1, 0, 0, 0))},
+		{"start of last friday", point(at(2026, 1, 9, 0, 0, 0))},
+
+		// Git reflog style and dotted forms.
+		{"@{2.weeks.ago}", point(at(2025, 12, 31, 15, 4, 5))},
+		{"@{yesterday}", day(2026, 1, 13)},
+		{"@{last_friday}", day(2026, 1, 9)},
+		{"@{2026-01-09T21:30}", point(at(2026, 1, 9, 21, 30, 0))},
+		{"@{ 3.days.ago }", point(at(2026, 1, 11, 15, 4, 5))},
+		{"2.weeks.ago", point(at(2025, 12, 31, 15, 4, 5))},
+		{"start_of_week", point(at(2026, 1, 12, 0, 0, 0))},
+		{"last.friday.17:00", point(at(2026, 1, 9, 17, 0, 0))},
+	}
+
+	p := fixedParser()
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := p.ParseSpan(tt.in)
+			if err != nil {
+				t.Fatalf("ParseSpan(%q): %v", tt.in, err)
+			}
+			if !got.Start.Equal(tt.want.Start) || !got.End.Equal(tt.want.End) {
+				t.Fatalf("ParseSpan(%q) = [%s, %s), want [%s, %s)", tt.in,
+					got.Start.Format(time.RFC3339Nano), got.End.Format(time.RFC3339Nano),
+					tt.want.Start.Format(time.RFC3339Nano), tt.want.End.Format(time.RFC3339Nano))
+			}
+		})
+	}
+}
+
+func TestParseSpan_Errors(t *testing.T) {
+	tests := []struct {
+		in      string
+		wantErr string
+	}{
+		{"", "empty"},
+		{"   ", "empty"},
+		{"@{}", "empty"},
+		{"someday", "unknown expression"},
+		{"3 parsecs ago", "unknown unit"},
+		{"ago", "expected an amount"},
+		{"few days ago", "invalid amount"},
+		{"-3 days ago", "invalid amount"},
+		{"start of now", "names an instant"},
+		{"start of", "unknown expression"},
+		{"25:00", "invalid time of day"},
+		{"13pm", "invalid time of day"},
+		{"0am", "invalid time of day"},
+		{"12:61", "invalid time of day"},
+		{"last week 17:00", "needs a single day"},
+		{"2026-01 09:00", "needs a single day"},
+		{"2025-W53", "unknown expression"},
+		{"2026-W00", "unknown expression"},
+		{"yesterday tomorrow", "unknown expression"},
+		{"last", "unknown expression"},
+		{"next fortnight", "unknown expression"},
+		{"2026-13-01", "unknown expression"},
+	}
+
+	p := fixedParser()
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := p.ParseSpan(tt.in)
+			if err == nil {
+				t.Fatalf("ParseSpan(%q) = %v, want error", tt.in, got)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ParseSpan(%q) error %q, want it to mention %q", tt.in, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParse_ReturnsSpanStart(t *testing.T) {
+	p := fixedParser()
+	got, err := p.Parse("last week")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(at(2026, 1, 5, 0, 0, 0)) {
+		t.Fatalf("Parse(last week) = %v", got)
+	}
+}
+
+func TestParseSpan_DaysFollowDaylightSaving(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	// Clocks go forward on 2026-03-08, so that day is 23 hours long.
+	p := Parser{Now: func() time.Time { return time.Date(2026, 3, 9, 12, 0, 0, 0, ny) }, Location: ny}
+
+	got, err := p.ParseSpan("yesterday")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.End.Sub(got.Start) != 23*time.Hour {
+		t.Fatalf("expected a 23-hour day, got %v", got.End.Sub(got.Start))
+	}
+
+	got, err = p.ParseSpan("yesterday 17:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 3, 8, 17, 0, 0, 0, ny); !got.Start.Equal(want) {
+		t.Fatalf("yesterday 17:00 = %v, want %v", got.Start, want)
+	}
+
+	got, err = p.ParseSpan("1 day ago")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 3, 8, 12, 0, 0, 0, ny); !got.Start.Equal(want) {
+		t.Fatalf("1 day ago = %v, want the same wall-clock time (%v)", got.Start, want)
+	}
+}
+
+func TestParseSpan_DefaultsToLocalClock(t *testing.T) {
+	before := time.Now()
+	got, err := Parse("now")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Before(before) || got.After(time.Now()) || got.Location() != time.Local {
+		t.Fatalf("Parse(now) = %v", got)
+	}
+}